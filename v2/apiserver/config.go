@@ -2,6 +2,8 @@ package main
 
 // nolint: lll
 import (
+	"context"
+
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
 	authxMongodb "github.com/brigadecore/brigade/v2/apiserver/internal/authx/mongodb"
 	authxREST "github.com/brigadecore/brigade/v2/apiserver/internal/authx/rest"
@@ -9,6 +11,7 @@ import (
 	coreKubernetes "github.com/brigadecore/brigade/v2/apiserver/internal/core/kubernetes"
 	coreMongodb "github.com/brigadecore/brigade/v2/apiserver/internal/core/mongodb"
 	coreREST "github.com/brigadecore/brigade/v2/apiserver/internal/core/rest"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/oidc"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/queue/amqp"
@@ -35,27 +38,79 @@ func getAPIServerFromEnvironment() (restmachinery.Server, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Bring the database schema up to date before anything else touches it
+	if err = mongodb.Migrate(context.Background(), database); err != nil {
+		return nil, err
+	}
 	kubeClient, err := kubernetes.Client()
 	if err != nil {
 		return nil, err
 	}
 
-	// Service Accounts
+	tokenHasher := crypto.NewTokenHasher(apiConfig.TokenHashKey())
+
+	rolesStore, err := authxMongodb.NewRolesStore(database)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service Accounts-- depends on roles
 	serviceAccountsStore, err := authxMongodb.NewServiceAccountsStore(database)
 	if err != nil {
 		return nil, err
 	}
-	serviceAccountsService :=
-		authx.NewServiceAccountsService(serviceAccountsStore)
+	serviceAccountsService := authx.NewServiceAccountsService(
+		serviceAccountsStore,
+		rolesStore,
+		tokenHasher,
+	)
+
+	// Role definitions-- depends on roles
+	roleDefinitionsStore, err := authxMongodb.NewRoleDefinitionsStore(database)
+	if err != nil {
+		return nil, err
+	}
+	roleDefinitionsService :=
+		authx.NewRoleDefinitionsService(roleDefinitionsStore, rolesStore)
+	projectAuthorize := authx.NewProjectAuthorizer(roleDefinitionsStore)
 
 	// Users
 	usersStore, err := authxMongodb.NewUsersStore(database)
 	if err != nil {
 		return nil, err
 	}
-	usersService := authx.NewUsersService(usersStore)
+	usersService := authx.NewUsersService(usersStore, rolesStore)
+
+	// Teams-- depends on users and service accounts
+	teamsStore, err := authxMongodb.NewTeamsStore(database)
+	if err != nil {
+		return nil, err
+	}
+	teamsService :=
+		authx.NewTeamsService(teamsStore, usersStore, serviceAccountsStore)
 
-	// Sessions-- depends on users
+	// Personal access tokens-- depends on users
+	personalAccessTokensStore, err :=
+		authxMongodb.NewPersonalAccessTokensStore(database)
+	if err != nil {
+		return nil, err
+	}
+	personalAccessTokensService :=
+		authx.NewPersonalAccessTokensService(personalAccessTokensStore, tokenHasher)
+
+	// User invitations-- depends on users
+	userInvitationsStore, err := authxMongodb.NewUserInvitationsStore(database)
+	if err != nil {
+		return nil, err
+	}
+	userInvitationsService :=
+		authx.NewUserInvitationsService(userInvitationsStore, usersStore)
+
+	// Sessions-- depends on users and user invitations
+	authxConfig, err := authx.GetConfigFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
 	oauth2Config, oidcIdentityVerifier, err :=
 		oidc.GetConfigAndVerifierFromEnvironment()
 	if err != nil {
@@ -68,17 +123,17 @@ func getAPIServerFromEnvironment() (restmachinery.Server, error) {
 	sessionsService := authx.NewSessionsService(
 		sessionsStore,
 		usersStore,
+		userInvitationsStore,
 		apiConfig.RootUserEnabled(),
 		apiConfig.HashedRootUserPassword(),
 		oauth2Config,
 		oidcIdentityVerifier,
+		apiConfig.SessionTTL(),
+		apiConfig.RefreshTokenTTL(),
+		authxConfig,
+		tokenHasher,
 	)
 
-	rolesStore, err := authxMongodb.NewRolesStore(database)
-	if err != nil {
-		return nil, err
-	}
-
 	substrateConfig, err := core.GetConfigFromEnvironment()
 	if err != nil {
 		return nil, err
@@ -101,14 +156,20 @@ func getAPIServerFromEnvironment() (restmachinery.Server, error) {
 		usersStore,
 		serviceAccountsStore,
 		rolesStore,
+		teamsStore,
 		substrate,
+		projectAuthorize,
 	)
-	secretsService := core.NewSecretsService(projectsStore, secretsStore)
+	secretsService :=
+		core.NewSecretsService(projectsStore, secretsStore, projectAuthorize)
 	projectRolesService := core.NewProjectRolesService(
 		projectsStore,
 		usersStore,
 		serviceAccountsStore,
 		rolesStore,
+		teamsStore,
+		roleDefinitionsStore,
+		projectAuthorize,
 	)
 
 	// Events-- depends on projects
@@ -124,22 +185,60 @@ func getAPIServerFromEnvironment() (restmachinery.Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	eventsService := core.NewEventsService(projectsStore, eventsStore, substrate)
+	coolLogsStore := coreMongodb.NewLogsStore(database)
+	eventsService := core.NewEventsService(
+		projectsStore,
+		eventsStore,
+		coolLogsStore,
+		substrate,
+		projectAuthorize,
+		tokenHasher,
+	)
 	workersService :=
 		core.NewWorkersService(projectsStore, eventsStore, workersStore, substrate)
 	jobsService :=
 		core.NewJobsService(projectsStore, eventsStore, jobsStore, substrate)
+	eventRetentionService := core.NewEventRetentionService(
+		projectsStore,
+		eventsStore,
+		eventsService,
+		coreMongodb.NewEventRetentionLeaseStore(database),
+		projectAuthorize,
+	)
+	// Enforce event retention policies in the background for as long as the
+	// process lives. Every replica does this, but a lease ensures that only one
+	// of them sweeps at a time.
+	go eventRetentionService.Run(
+		context.Background(),
+		substrateConfig.EventRetentionInterval,
+	)
 	logsService := core.NewLogsService(
 		projectsStore,
 		eventsStore,
+		secretsStore,
 		coreKubernetes.NewLogsStore(kubeClient),
-		coreMongodb.NewLogsStore(database),
+		coolLogsStore,
+		projectAuthorize,
 	)
 
 	systemRolesService := system.NewRolesService(
 		usersStore,
 		serviceAccountsStore,
 		rolesStore,
+		teamsStore,
+	)
+	systemBackupsService := system.NewBackupsService(
+		projectsStore,
+		secretsStore,
+		usersStore,
+		serviceAccountsStore,
+		rolesStore,
+		teamsStore,
+		personalAccessTokensStore,
+		userInvitationsStore,
+		roleDefinitionsStore,
+		substrate,
+		tokenHasher,
 	)
 
 	baseEndpoints := &restmachinery.BaseEndpoints{
@@ -148,87 +247,197 @@ func getAPIServerFromEnvironment() (restmachinery.Server, error) {
 			eventsService.GetByWorkerToken,
 			usersService.Get,
 			serviceAccountsService.GetByToken,
+			personalAccessTokensService.GetByToken,
 			apiConfig.RootUserEnabled(),
 			apiConfig.HashedSchedulerToken(),
 			apiConfig.HashedObserverToken(),
 		),
 	}
 
+	endpoints := getEndpoints(
+		baseEndpoints,
+		services{
+			serviceAccounts:      serviceAccountsService,
+			sessions:             sessionsService,
+			users:                usersService,
+			userInvitations:      userInvitationsService,
+			teams:                teamsService,
+			personalAccessTokens: personalAccessTokensService,
+			roleDefinitions:      roleDefinitionsService,
+			events:               eventsService,
+			eventRetention:       eventRetentionService,
+			workers:              workersService,
+			jobs:                 jobsService,
+			logs:                 logsService,
+			projects:             projectsService,
+			secrets:              secretsService,
+			projectRoles:         projectRolesService,
+			systemRoles:          systemRolesService,
+			systemBackups:        systemBackupsService,
+		},
+	)
+	openAPIDocument, err :=
+		restmachinery.NewOpenAPIDocument(endpoints, "/brigade/schemas")
+	if err != nil {
+		return nil, err
+	}
+
 	return restmachinery.NewServer(
 		apiConfig,
 		baseEndpoints,
-		[]restmachinery.Endpoints{
-			&authxREST.ServiceAccountEndpoints{
-				BaseEndpoints: baseEndpoints,
-				ServiceAccountSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/service-account.json",
-				),
-				Service: serviceAccountsService,
-			},
-			&authxREST.SessionsEndpoints{
-				BaseEndpoints: baseEndpoints,
-				Service:       sessionsService,
-			},
-			&authxREST.UsersEndpoints{
-				BaseEndpoints: baseEndpoints,
-				Service:       usersService,
-			},
-			&coreREST.EventsEndpoints{
-				BaseEndpoints: baseEndpoints,
-				EventSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/event.json",
-				),
-				Service: eventsService,
-			},
-			&coreREST.WorkersEndpoints{
-				BaseEndpoints: baseEndpoints,
-				WorkerStatusSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/worker-status.json",
-				),
-				Service: workersService,
-			},
-			&coreREST.JobsEndpoints{
-				BaseEndpoints: baseEndpoints,
-				JobSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/job.json",
-				),
-				JobStatusSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/job-status.json",
-				),
-				Service: jobsService,
-			},
-			&coreREST.LogsEndpoints{
-				BaseEndpoints: baseEndpoints,
-				Service:       logsService,
-			},
-			&coreREST.ProjectsEndpoints{
-				BaseEndpoints: baseEndpoints,
-				ProjectSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/project.json",
-				),
-				Service: projectsService,
-			},
-			&coreREST.SecretsEndpoints{
-				BaseEndpoints: baseEndpoints,
-				SecretSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/secret.json",
-				),
-				Service: secretsService,
-			},
-			&coreREST.ProjectsRolesEndpoints{
-				BaseEndpoints: baseEndpoints,
-				ProjectRoleAssignmentSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/project-role-assignment.json",
-				),
-				Service: projectRolesService,
-			},
-			&systemREST.RolesEndpoints{
-				BaseEndpoints: baseEndpoints,
-				SystemRoleAssignmentSchemaLoader: gojsonschema.NewReferenceLoader(
-					"file:///brigade/schemas/system-role-assignment.json",
-				),
-				Service: systemRolesService,
-			},
-		},
+		endpoints,
+		openAPIDocument,
 	), nil
 }
+
+// services bundles together all of the services exposed by the API server.
+type services struct {
+	serviceAccounts      authx.ServiceAccountsService
+	sessions             authx.SessionsService
+	users                authx.UsersService
+	userInvitations      authx.UserInvitationsService
+	teams                authx.TeamsService
+	personalAccessTokens authx.PersonalAccessTokensService
+	roleDefinitions      authx.RoleDefinitionsService
+	events               core.EventsService
+	eventRetention       core.EventRetentionService
+	workers              core.WorkersService
+	jobs                 core.JobsService
+	logs                 core.LogsService
+	projects             core.ProjectsService
+	secrets              core.SecretsService
+	projectRoles         core.ProjectRolesService
+	systemRoles          system.RolesService
+	systemBackups        system.BackupsService
+}
+
+// getEndpoints returns all of the API server's Endpoints.
+func getEndpoints(
+	baseEndpoints *restmachinery.BaseEndpoints,
+	s services,
+) []restmachinery.Endpoints {
+	return []restmachinery.Endpoints{
+		&authxREST.ServiceAccountEndpoints{
+			BaseEndpoints: baseEndpoints,
+			ServiceAccountSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/service-account.json",
+			),
+			TokenRotationOptionsSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/token-rotation-options.json",
+			),
+			Service: s.serviceAccounts,
+		},
+		&authxREST.SessionsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			SessionRefreshRequestSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/session-refresh-request.json",
+			),
+			Service: s.sessions,
+		},
+		&authxREST.UsersEndpoints{
+			BaseEndpoints: baseEndpoints,
+			Service:       s.users,
+		},
+		&authxREST.UserInvitationsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			UserInvitationSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/user-invitation.json",
+			),
+			Service: s.userInvitations,
+		},
+		&authxREST.TeamsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			TeamSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/team.json",
+			),
+			TeamMemberSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/team-member.json",
+			),
+			Service: s.teams,
+		},
+		&authxREST.PersonalAccessTokensEndpoints{
+			BaseEndpoints: baseEndpoints,
+			PersonalAccessTokenSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/personal-access-token.json",
+			),
+			Service: s.personalAccessTokens,
+		},
+		&authxREST.RoleDefinitionsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			RoleDefinitionSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/role-definition.json",
+			),
+			Service: s.roleDefinitions,
+		},
+		&coreREST.EventsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			EventSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/event.json",
+			),
+			Service: s.events,
+		},
+		&coreREST.EventRetentionEndpoints{
+			BaseEndpoints: baseEndpoints,
+			Service:       s.eventRetention,
+		},
+		&coreREST.WorkersEndpoints{
+			BaseEndpoints: baseEndpoints,
+			WorkerStatusSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/worker-status.json",
+			),
+			Service: s.workers,
+		},
+		&coreREST.JobsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			JobSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/job.json",
+			),
+			JobStatusSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/job-status.json",
+			),
+			Service: s.jobs,
+		},
+		&coreREST.LogsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			Service:       s.logs,
+		},
+		&coreREST.ProjectsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			ProjectSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/project.json",
+			),
+			Service: s.projects,
+		},
+		&coreREST.SecretsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			SecretSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/secret.json",
+			),
+			Service: s.secrets,
+		},
+		&coreREST.ProjectsRolesEndpoints{
+			BaseEndpoints: baseEndpoints,
+			ProjectRoleAssignmentSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/project-role-assignment.json",
+			),
+			Service: s.projectRoles,
+		},
+		&systemREST.RolesEndpoints{
+			BaseEndpoints: baseEndpoints,
+			SystemRoleAssignmentSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/system-role-assignment.json",
+			),
+			Service: s.systemRoles,
+		},
+		&systemREST.BackupsEndpoints{
+			BaseEndpoints: baseEndpoints,
+			BackupOptionsSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/backup-options.json",
+			),
+			RestoreRequestSchemaLoader: gojsonschema.NewReferenceLoader(
+				"file:///brigade/schemas/restore-request.json",
+			),
+			Service: s.systemBackups,
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package authx
+
+import (
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+)
+
+const envconfigPrefix = "API_SERVER"
+
+// UserProvisioningPolicy is a type whose values govern which identities vouched
+// for by an OpenID Connect identity provider, but previously unknown to
+// Brigade, are automatically turned into new Users upon logging in.
+type UserProvisioningPolicy string
+
+const (
+	// UserProvisioningPolicyOpen represents a policy wherein any identity
+	// vouched for by the identity provider is provisioned as a new User.
+	UserProvisioningPolicyOpen UserProvisioningPolicy = "OPEN"
+	// UserProvisioningPolicyRestricted represents a policy wherein an identity
+	// vouched for by the identity provider is provisioned as a new User only if
+	// its email address belongs to an allowed domain or has been explicitly
+	// invited using a UserInvitation.
+	UserProvisioningPolicyRestricted UserProvisioningPolicy = "RESTRICTED"
+	// UserProvisioningPolicyDisabled represents a policy wherein no new Users are
+	// provisioned. Only existing Users may log in.
+	UserProvisioningPolicyDisabled UserProvisioningPolicy = "DISABLED"
+)
+
+// Config represents configuration for authentication and authorization
+// concerns.
+type Config struct {
+	// UserProvisioningPolicy governs which previously unknown identities are
+	// provisioned as new Users upon logging in.
+	UserProvisioningPolicy UserProvisioningPolicy `envconfig:"USER_PROVISIONING_POLICY"` // nolint: lll
+	// AllowedEmailDomains enumerates email domains whose identities are
+	// provisioned as new Users upon logging in when the UserProvisioningPolicy
+	// is UserProvisioningPolicyRestricted.
+	AllowedEmailDomains []string `envconfig:"ALLOWED_EMAIL_DOMAINS"`
+}
+
+// NewConfigWithDefaults returns a Config object with default values already
+// applied.
+func NewConfigWithDefaults() Config {
+	return Config{
+		UserProvisioningPolicy: UserProvisioningPolicyOpen,
+	}
+}
+
+// GetConfigFromEnvironment returns configuration derived from environment
+// variables.
+func GetConfigFromEnvironment() (Config, error) {
+	c := NewConfigWithDefaults()
+	if err := envconfig.Process(envconfigPrefix, &c); err != nil {
+		return c, err
+	}
+	c.UserProvisioningPolicy =
+		UserProvisioningPolicy(strings.ToUpper(string(c.UserProvisioningPolicy)))
+	switch c.UserProvisioningPolicy {
+	case UserProvisioningPolicyOpen,
+		UserProvisioningPolicyRestricted,
+		UserProvisioningPolicyDisabled:
+	default:
+		return c, errors.Errorf(
+			"the value of the USER_PROVISIONING_POLICY environment variable must "+
+				"be one of %s, %s, or %s; got %q",
+			UserProvisioningPolicyOpen,
+			UserProvisioningPolicyRestricted,
+			UserProvisioningPolicyDisabled,
+			c.UserProvisioningPolicy,
+		)
+	}
+	for i, domain := range c.AllowedEmailDomains {
+		c.AllowedEmailDomains[i] =
+			strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+	}
+	return c, nil
+}
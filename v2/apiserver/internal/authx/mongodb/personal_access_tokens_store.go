@@ -0,0 +1,193 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type personalAccessTokensStore struct {
+	collection *mongo.Collection
+}
+
+func NewPersonalAccessTokensStore(
+	database *mongo.Database,
+) (authx.PersonalAccessTokensStore, error) {
+	return &personalAccessTokensStore{
+		collection: database.Collection("personal-access-tokens"),
+	}, nil
+}
+
+func (p *personalAccessTokensStore) Create(
+	ctx context.Context,
+	personalAccessToken authx.PersonalAccessToken,
+) error {
+	if _, err := p.collection.InsertOne(
+		ctx,
+		personalAccessToken,
+	); err != nil {
+		if writeException, ok := err.(mongo.WriteException); ok {
+			if len(writeException.WriteErrors) == 1 &&
+				writeException.WriteErrors[0].Code == 11000 {
+				return &meta.ErrConflict{
+					Type: "PersonalAccessToken",
+					ID:   personalAccessToken.ID,
+					Reason: fmt.Sprintf(
+						"User %q already has a personal access token named %q.",
+						personalAccessToken.UserID,
+						personalAccessToken.Name,
+					),
+				}
+			}
+		}
+		return errors.Wrapf(
+			err,
+			"error inserting new personal access token %q",
+			personalAccessToken.ID,
+		)
+	}
+	return nil
+}
+
+func (p *personalAccessTokensStore) List(
+	ctx context.Context,
+	userID string,
+	opts meta.ListOptions,
+) (authx.PersonalAccessTokenList, error) {
+	personalAccessTokens := authx.PersonalAccessTokenList{}
+
+	criteria := bson.M{"userID": userID}
+
+	findCriteria := criteria
+	if opts.Continue != "" {
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
+		if err != nil {
+			return personalAccessTokens, err
+		}
+		findCriteria = bson.M{
+			"$and": []bson.M{
+				criteria,
+				mongodb.KeysetCriteria(continueCreated, continueID, false),
+			},
+		}
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: 1},
+			{Key: "id", Value: 1},
+		},
+	)
+	findOptions.SetLimit(opts.Limit)
+	cur, err := p.collection.Find(ctx, findCriteria, findOptions)
+	if err != nil {
+		return personalAccessTokens,
+			errors.Wrap(err, "error finding personal access tokens")
+	}
+	if err := cur.All(ctx, &personalAccessTokens.Items); err != nil {
+		return personalAccessTokens,
+			errors.Wrap(err, "error decoding personal access tokens")
+	}
+
+	if int64(len(personalAccessTokens.Items)) == opts.Limit {
+		lastItem := personalAccessTokens.Items[opts.Limit-1]
+		remaining, err := p.collection.CountDocuments(
+			ctx,
+			bson.M{
+				"$and": []bson.M{
+					criteria,
+					mongodb.KeysetCriteria(lastItem.Created, lastItem.ID, false),
+				},
+			},
+		)
+		if err != nil {
+			return personalAccessTokens, errors.Wrap(
+				err,
+				"error counting remaining personal access tokens",
+			)
+		}
+		if remaining > 0 {
+			personalAccessTokens.Continue =
+				mongodb.EncodeContinueToken(lastItem.Created, lastItem.ID)
+			personalAccessTokens.RemainingItemCount = remaining
+		}
+	}
+
+	return personalAccessTokens, nil
+}
+
+func (p *personalAccessTokensStore) GetByHashedToken(
+	ctx context.Context,
+	hashedToken string,
+) (authx.PersonalAccessToken, error) {
+	personalAccessToken := authx.PersonalAccessToken{}
+	res := p.collection.FindOne(ctx, bson.M{"hashedToken": hashedToken})
+	if res.Err() == mongo.ErrNoDocuments {
+		return personalAccessToken, &meta.ErrNotFound{
+			Type: "PersonalAccessToken",
+		}
+	}
+	if res.Err() != nil {
+		return personalAccessToken, errors.Wrap(
+			res.Err(),
+			"error finding personal access token by hashed token",
+		)
+	}
+	if err := res.Decode(&personalAccessToken); err != nil {
+		return personalAccessToken,
+			errors.Wrap(err, "error decoding personal access token")
+	}
+	return personalAccessToken, nil
+}
+
+func (p *personalAccessTokensStore) RehashToken(
+	ctx context.Context,
+	legacyHashedToken string,
+	hashedToken string,
+) error {
+	if _, err := p.collection.UpdateOne(
+		ctx,
+		bson.M{"hashedToken": legacyHashedToken},
+		bson.M{
+			"$set": bson.M{
+				"hashedToken": hashedToken,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "error updating personal access token hash")
+	}
+	return nil
+}
+
+func (p *personalAccessTokensStore) Delete(
+	ctx context.Context,
+	userID string,
+	id string,
+) error {
+	res, err := p.collection.DeleteOne(
+		ctx,
+		bson.M{
+			"id":     id,
+			"userID": userID,
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting personal access token %q", id)
+	}
+	if res.DeletedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "PersonalAccessToken",
+			ID:   id,
+		}
+	}
+	return nil
+}
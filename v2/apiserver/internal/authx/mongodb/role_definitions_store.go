@@ -0,0 +1,138 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type roleDefinitionsStore struct {
+	collection *mongo.Collection
+}
+
+func NewRoleDefinitionsStore(
+	database *mongo.Database,
+) (authx.RoleDefinitionsStore, error) {
+	return &roleDefinitionsStore{
+		collection: database.Collection("role-definitions"),
+	}, nil
+}
+
+func (r *roleDefinitionsStore) Create(
+	ctx context.Context,
+	roleDefinition authx.RoleDefinition,
+) error {
+	if _, err := r.collection.InsertOne(ctx, roleDefinition); err != nil {
+		if writeException, ok := err.(mongo.WriteException); ok {
+			if len(writeException.WriteErrors) == 1 &&
+				writeException.WriteErrors[0].Code == 11000 {
+				return &meta.ErrConflict{
+					Type: "RoleDefinition",
+					ID:   roleDefinition.ID,
+					Reason: fmt.Sprintf(
+						"A role named %q already exists.",
+						roleDefinition.ID,
+					),
+				}
+			}
+		}
+		return errors.Wrapf(
+			err,
+			"error inserting new role definition %q",
+			roleDefinition.ID,
+		)
+	}
+	return nil
+}
+
+func (r *roleDefinitionsStore) List(
+	ctx context.Context,
+) (authx.RoleDefinitionList, error) {
+	roleDefinitions := authx.RoleDefinitionList{}
+	findOptions := options.Find()
+	findOptions.SetSort(bson.M{"id": 1})
+	cur, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return roleDefinitions, errors.Wrap(err, "error finding role definitions")
+	}
+	if err := cur.All(ctx, &roleDefinitions.Items); err != nil {
+		return roleDefinitions,
+			errors.Wrap(err, "error decoding role definitions")
+	}
+	return roleDefinitions, nil
+}
+
+func (r *roleDefinitionsStore) Get(
+	ctx context.Context,
+	id string,
+) (authx.RoleDefinition, error) {
+	roleDefinition := authx.RoleDefinition{}
+	res := r.collection.FindOne(ctx, bson.M{"id": id})
+	if res.Err() == mongo.ErrNoDocuments {
+		return roleDefinition, &meta.ErrNotFound{
+			Type: "RoleDefinition",
+			ID:   id,
+		}
+	}
+	if res.Err() != nil {
+		return roleDefinition,
+			errors.Wrapf(res.Err(), "error finding role definition %q", id)
+	}
+	if err := res.Decode(&roleDefinition); err != nil {
+		return roleDefinition,
+			errors.Wrapf(err, "error decoding role definition %q", id)
+	}
+	return roleDefinition, nil
+}
+
+func (r *roleDefinitionsStore) Update(
+	ctx context.Context,
+	roleDefinition authx.RoleDefinition,
+) error {
+	res, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"id": roleDefinition.ID},
+		bson.M{
+			"$set": bson.M{
+				"description": roleDefinition.Description,
+				"permissions": roleDefinition.Permissions,
+				"lastUpdated": time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating role definition %q",
+			roleDefinition.ID,
+		)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "RoleDefinition",
+			ID:   roleDefinition.ID,
+		}
+	}
+	return nil
+}
+
+func (r *roleDefinitionsStore) Delete(ctx context.Context, id string) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return errors.Wrapf(err, "error deleting role definition %q", id)
+	}
+	if res.DeletedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "RoleDefinition",
+			ID:   id,
+		}
+	}
+	return nil
+}
@@ -2,22 +2,34 @@ package mongodb
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type rolesStore struct {
 	usersCollection           *mongo.Collection
 	serviceAccountsCollection *mongo.Collection
+	// groupsCollection holds one document per group that has been granted one
+	// or more Roles. Groups themselves are managed by an OpenID Connect identity
+	// provider, so these documents exist only to record Roles.
+	groupsCollection *mongo.Collection
+	teamsCollection  *mongo.Collection
 }
 
 func NewRolesStore(database *mongo.Database) (authx.RolesStore, error) {
 	return &rolesStore{
 		usersCollection:           database.Collection("users"),
 		serviceAccountsCollection: database.Collection("service-accounts"),
+		groupsCollection:          database.Collection("groups"),
+		teamsCollection:           database.Collection("teams"),
 	}, nil
 }
 
@@ -28,10 +40,17 @@ func (r *rolesStore) Grant(
 	roles ...authx.Role,
 ) error {
 	var collection *mongo.Collection
+	// Documents for groups are created on demand
+	upsert := false
 	if principalType == authx.PrincipalTypeUser {
 		collection = r.usersCollection
 	} else if principalType == authx.PrincipalTypeServiceAccount {
 		collection = r.serviceAccountsCollection
+	} else if principalType == authx.PrincipalTypeGroup {
+		collection = r.groupsCollection
+		upsert = true
+	} else if principalType == authx.PrincipalTypeTeam {
+		collection = r.teamsCollection
 	} else {
 		return nil
 	}
@@ -56,7 +75,15 @@ func (r *rolesStore) Grant(
 					},
 				},
 			},
+			options.Update().SetUpsert(upsert),
 		); err != nil {
+			if writeException, ok := err.(mongo.WriteException); ok && upsert {
+				if len(writeException.WriteErrors) == 1 &&
+					writeException.WriteErrors[0].Code == 11000 {
+					// The document already exists and already includes the Role
+					continue
+				}
+			}
 			return errors.Wrapf(
 				err,
 				"error updating user %s %q",
@@ -79,6 +106,10 @@ func (r *rolesStore) Revoke(
 		collection = r.usersCollection
 	} else if principalType == authx.PrincipalTypeServiceAccount {
 		collection = r.serviceAccountsCollection
+	} else if principalType == authx.PrincipalTypeGroup {
+		collection = r.groupsCollection
+	} else if principalType == authx.PrincipalTypeTeam {
+		collection = r.teamsCollection
 	} else {
 		return nil
 	}
@@ -106,3 +137,356 @@ func (r *rolesStore) Revoke(
 	}
 	return nil
 }
+
+func (r *rolesStore) ListForGroups(
+	ctx context.Context,
+	groups ...string,
+) ([]authx.Role, error) {
+	if len(groups) == 0 {
+		return []authx.Role{}, nil
+	}
+	roles, err := distinctRoles(
+		ctx,
+		r.groupsCollection,
+		bson.M{
+			"id": bson.M{
+				"$in": groups,
+			},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding roles for groups")
+	}
+	return roles, nil
+}
+
+func (r *rolesStore) ListForTeamMember(
+	ctx context.Context,
+	principalType authx.PrincipalType,
+	principalID string,
+) ([]authx.Role, error) {
+	roles, err := distinctRoles(
+		ctx,
+		r.teamsCollection,
+		bson.M{
+			"members": authx.TeamMember{
+				PrincipalType: principalType,
+				PrincipalID:   principalID,
+			},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error finding roles for teams of %s %q",
+			principalType,
+			principalID,
+		)
+	}
+	return roles, nil
+}
+
+// distinctRoles returns all Roles recorded in documents from the provided
+// collection that match the provided criteria, without duplicates.
+func distinctRoles(
+	ctx context.Context,
+	collection *mongo.Collection,
+	criteria bson.M,
+) ([]authx.Role, error) {
+	roles := []authx.Role{}
+	cur, err := collection.Aggregate(
+		ctx,
+		[]bson.M{
+			{
+				"$match": criteria,
+			},
+			{
+				"$unwind": "$roles",
+			},
+			{
+				"$group": bson.M{
+					"_id": "$roles",
+				},
+			},
+			{
+				"$sort": bson.D{
+					{Key: "_id.type", Value: 1},
+					{Key: "_id.name", Value: 1},
+					{Key: "_id.scope", Value: 1},
+				},
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	results := []struct {
+		Role authx.Role `bson:"_id"`
+	}{}
+	if err = cur.All(ctx, &results); err != nil {
+		return nil, errors.Wrap(err, "error decoding roles")
+	}
+	for _, result := range results {
+		roles = append(roles, result.Role)
+	}
+	return roles, nil
+}
+
+func (r *rolesStore) List(
+	ctx context.Context,
+	selector authx.RoleAssignmentsSelector,
+	opts meta.ListOptions,
+) (authx.RoleAssignmentList, error) {
+	roleAssignments := authx.RoleAssignmentList{}
+
+	var after *authx.RoleAssignment
+	if opts.Continue != "" {
+		var err error
+		if after, err = parseRoleAssignmentContinueToken(opts.Continue); err != nil {
+			return roleAssignments, err
+		}
+	}
+
+	roleCriteria := bson.M{}
+	if selector.RoleType != "" {
+		roleCriteria["roles.type"] = selector.RoleType
+	}
+	if selector.Role != "" {
+		roleCriteria["roles.name"] = selector.Role
+	}
+	if selector.Scope != "" {
+		if selector.IncludeGlobalScope {
+			roleCriteria["roles.scope"] = bson.M{
+				"$in": []string{selector.Scope, authx.RoleScopeGlobal},
+			}
+		} else {
+			roleCriteria["roles.scope"] = selector.Scope
+		}
+	}
+	// Narrows the documents considered before their Roles are unwound
+	principalCriteria := bson.M{}
+	if selector.PrincipalID != "" {
+		principalCriteria["id"] = selector.PrincipalID
+	}
+	if len(roleCriteria) > 0 {
+		elemCriteria := bson.M{}
+		for key, value := range roleCriteria {
+			elemCriteria[strings.TrimPrefix(key, "roles.")] = value
+		}
+		principalCriteria["roles"] = bson.M{"$elemMatch": elemCriteria}
+	}
+
+	// Role assignments are listed one principal type at a time, in the order
+	// the principal types appear here
+	principals := []struct {
+		principalType authx.PrincipalType
+		collection    *mongo.Collection
+	}{
+		{authx.PrincipalTypeUser, r.usersCollection},
+		{authx.PrincipalTypeServiceAccount, r.serviceAccountsCollection},
+		{authx.PrincipalTypeGroup, r.groupsCollection},
+		{authx.PrincipalTypeTeam, r.teamsCollection},
+	}
+
+	// pipeline returns an aggregation pipeline that selects, in order, the
+	// matching role assignments of the principals in a single collection. If
+	// a non-nil RoleAssignment is provided, only those that follow it are
+	// selected.
+	pipeline := func(after *authx.RoleAssignment) []bson.M {
+		stages := []bson.M{
+			{
+				"$match": principalCriteria,
+			},
+			{
+				"$unwind": "$roles",
+			},
+			{
+				"$match": roleCriteria,
+			},
+		}
+		if after != nil {
+			stages = append(
+				stages,
+				bson.M{
+					"$match": bson.M{
+						"$or": []bson.M{
+							{"id": bson.M{"$gt": after.PrincipalID}},
+							{
+								"id":         after.PrincipalID,
+								"roles.name": bson.M{"$gt": after.Role},
+							},
+							{
+								"id":          after.PrincipalID,
+								"roles.name":  after.Role,
+								"roles.scope": bson.M{"$gt": after.Scope},
+							},
+						},
+					},
+				},
+			)
+		}
+		return append(
+			stages,
+			bson.M{
+				"$sort": bson.D{
+					{Key: "id", Value: 1},
+					{Key: "roles.name", Value: 1},
+					{Key: "roles.scope", Value: 1},
+				},
+			},
+		)
+	}
+
+	// position returns the index of the specified principal type within
+	// principals or -1 if it is not found there.
+	position := func(principalType authx.PrincipalType) int {
+		for i, principal := range principals {
+			if principal.principalType == principalType {
+				return i
+			}
+		}
+		return -1
+	}
+
+	start := 0
+	if after != nil {
+		if start = position(after.PrincipalType); start < 0 {
+			return roleAssignments, &meta.ErrBadRequest{
+				Reason: "The continue token is invalid.",
+			}
+		}
+	}
+
+	for i := start; i < len(principals); i++ {
+		principal := principals[i]
+		if selector.PrincipalType != "" &&
+			selector.PrincipalType != principal.principalType {
+			continue
+		}
+		stages := pipeline(nil)
+		if i == start {
+			stages = pipeline(after)
+		}
+		if opts.Limit > 0 {
+			stages = append(
+				stages,
+				bson.M{
+					"$limit": opts.Limit - int64(len(roleAssignments.Items)),
+				},
+			)
+		}
+		cur, err := principal.collection.Aggregate(ctx, stages)
+		if err != nil {
+			return roleAssignments, errors.Wrapf(
+				err,
+				"error finding role assignments for principals of type %s",
+				principal.principalType,
+			)
+		}
+		results := []struct {
+			PrincipalID string     `bson:"id"`
+			Role        authx.Role `bson:"roles"`
+		}{}
+		if err = cur.All(ctx, &results); err != nil {
+			return roleAssignments, errors.Wrapf(
+				err,
+				"error decoding role assignments for principals of type %s",
+				principal.principalType,
+			)
+		}
+		for _, result := range results {
+			roleAssignments.Items = append(
+				roleAssignments.Items,
+				authx.RoleAssignment{
+					Role:          result.Role.Name,
+					Scope:         result.Role.Scope,
+					PrincipalType: principal.principalType,
+					PrincipalID:   result.PrincipalID,
+				},
+			)
+		}
+		if opts.Limit > 0 && int64(len(roleAssignments.Items)) == opts.Limit {
+			break
+		}
+	}
+
+	if opts.Limit == 0 || int64(len(roleAssignments.Items)) < opts.Limit {
+		return roleAssignments, nil
+	}
+
+	// Count what remains after the last item on this page
+	lastItem := roleAssignments.Items[opts.Limit-1]
+	var remaining int64
+	for i := position(lastItem.PrincipalType); i < len(principals); i++ {
+		principal := principals[i]
+		if selector.PrincipalType != "" &&
+			selector.PrincipalType != principal.principalType {
+			continue
+		}
+		stages := pipeline(nil)
+		if principal.principalType == lastItem.PrincipalType {
+			stages = pipeline(&lastItem)
+		}
+		cur, err := principal.collection.Aggregate(
+			ctx,
+			append(stages, bson.M{"$count": "count"}),
+		)
+		if err != nil {
+			return roleAssignments, errors.Wrapf(
+				err,
+				"error counting remaining role assignments for principals of type %s",
+				principal.principalType,
+			)
+		}
+		results := []struct {
+			Count int64 `bson:"count"`
+		}{}
+		if err = cur.All(ctx, &results); err != nil {
+			return roleAssignments, errors.Wrapf(
+				err,
+				"error decoding count of remaining role assignments for principals "+
+					"of type %s",
+				principal.principalType,
+			)
+		}
+		if len(results) > 0 {
+			remaining += results[0].Count
+		}
+	}
+	if remaining > 0 {
+		roleAssignments.Continue = roleAssignmentContinueToken(lastItem)
+		roleAssignments.RemainingItemCount = remaining
+	}
+
+	return roleAssignments, nil
+}
+
+// roleAssignmentContinueToken returns an opaque token that records the
+// position of the provided RoleAssignment within a list of RoleAssignments.
+func roleAssignmentContinueToken(roleAssignment authx.RoleAssignment) string {
+	// Marshaling this type cannot fail
+	tokenBytes, _ := json.Marshal(roleAssignment)
+	return base64.RawURLEncoding.EncodeToString(tokenBytes)
+}
+
+// parseRoleAssignmentContinueToken decodes the provided token, which should
+// have been obtained from roleAssignmentContinueToken, and returns the
+// RoleAssignment that it records. A *meta.ErrBadRequest is returned if the
+// token is malformed.
+func parseRoleAssignmentContinueToken(
+	token string,
+) (*authx.RoleAssignment, error) {
+	badTokenErr := &meta.ErrBadRequest{
+		Reason: "The continue token is invalid.",
+	}
+	tokenBytes, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, badTokenErr
+	}
+	roleAssignment := authx.RoleAssignment{}
+	if err = json.Unmarshal(tokenBytes, &roleAssignment); err != nil ||
+		roleAssignment.PrincipalType == "" {
+		return nil, badTokenErr
+	}
+	return &roleAssignment, nil
+}
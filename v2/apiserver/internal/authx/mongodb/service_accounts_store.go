@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
@@ -20,38 +21,7 @@ type serviceAccountsStore struct {
 func NewServiceAccountsStore(
 	database *mongo.Database,
 ) (authx.ServiceAccountsStore, error) {
-	ctx, cancel :=
-		context.WithTimeout(context.Background(), createIndexTimeout)
-	defer cancel()
-	unique := true
 	collection := database.Collection("service-accounts")
-	if _, err := collection.Indexes().CreateMany(
-		ctx,
-		[]mongo.IndexModel{
-			{
-				Keys: bson.M{
-					"id": 1,
-				},
-				Options: &options.IndexOptions{
-					Unique: &unique,
-				},
-			},
-			// Fast lookup by bearer token
-			{
-				Keys: bson.M{
-					"hashedToken": 1,
-				},
-				Options: &options.IndexOptions{
-					Unique: &unique,
-				},
-			},
-		},
-	); err != nil {
-		return nil, errors.Wrap(
-			err,
-			"error adding indexes to service accounts collection",
-		)
-	}
 	return &serviceAccountsStore{
 		collection: collection,
 	}, nil
@@ -89,19 +59,43 @@ func (s *serviceAccountsStore) Create(
 
 func (s *serviceAccountsStore) List(
 	ctx context.Context,
+	selector authx.ServiceAccountsSelector,
 	opts meta.ListOptions,
 ) (authx.ServiceAccountList, error) {
 	serviceAccounts := authx.ServiceAccountList{}
 
 	criteria := bson.M{}
+	if labelCriteria := mongodb.LabelSelectorCriteria(
+		"metadataLabels",
+		selector.LabelSelector,
+	); len(labelCriteria) > 0 {
+		criteria["$and"] = labelCriteria
+	}
+
+	findCriteria := criteria
 	if opts.Continue != "" {
-		criteria["id"] = bson.M{"$gt": opts.Continue}
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
+		if err != nil {
+			return serviceAccounts, err
+		}
+		findCriteria = bson.M{
+			"$and": []bson.M{
+				criteria,
+				mongodb.KeysetCriteria(continueCreated, continueID, false),
+			},
+		}
 	}
 
 	findOptions := options.Find()
-	findOptions.SetSort(bson.M{"id": 1})
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: 1},
+			{Key: "id", Value: 1},
+		},
+	)
 	findOptions.SetLimit(opts.Limit)
-	cur, err := s.collection.Find(ctx, criteria, findOptions)
+	cur, err := s.collection.Find(ctx, findCriteria, findOptions)
 	if err != nil {
 		return serviceAccounts,
 			errors.Wrap(err, "error finding service accounts")
@@ -112,15 +106,23 @@ func (s *serviceAccountsStore) List(
 	}
 
 	if int64(len(serviceAccounts.Items)) == opts.Limit {
-		continueID := serviceAccounts.Items[opts.Limit-1].ID
-		criteria["id"] = bson.M{"$gt": continueID}
-		remaining, err := s.collection.CountDocuments(ctx, criteria)
+		lastItem := serviceAccounts.Items[opts.Limit-1]
+		remaining, err := s.collection.CountDocuments(
+			ctx,
+			bson.M{
+				"$and": []bson.M{
+					criteria,
+					mongodb.KeysetCriteria(lastItem.Created, lastItem.ID, false),
+				},
+			},
+		)
 		if err != nil {
 			return serviceAccounts,
 				errors.Wrap(err, "error counting remaining service accounts")
 		}
 		if remaining > 0 {
-			serviceAccounts.Continue = continueID
+			serviceAccounts.Continue =
+				mongodb.EncodeContinueToken(lastItem.Created, lastItem.ID)
 			serviceAccounts.RemainingItemCount = remaining
 		}
 	}
@@ -162,8 +164,18 @@ func (s *serviceAccountsStore) GetByHashedToken(
 	hashedToken string,
 ) (authx.ServiceAccount, error) {
 	serviceAccount := authx.ServiceAccount{}
-	res :=
-		s.collection.FindOne(ctx, bson.M{"hashedToken": hashedToken})
+	// A ServiceAccount's previous token remains usable for a grace period
+	// following a token rotation, so match on either token. Expiry is enforced
+	// by the service layer.
+	res := s.collection.FindOne(
+		ctx,
+		bson.M{
+			"$or": []bson.M{
+				{"hashedToken": hashedToken},
+				{"previousHashedToken": hashedToken},
+			},
+		},
+	)
 	if res.Err() == mongo.ErrNoDocuments {
 		return serviceAccount, &meta.ErrNotFound{
 			Type: "ServiceAccount",
@@ -190,7 +202,8 @@ func (s *serviceAccountsStore) Lock(ctx context.Context, id string) error {
 		bson.M{"id": id},
 		bson.M{
 			"$set": bson.M{
-				"locked": time.Now(),
+				"locked":      time.Now(),
+				"lastUpdated": time.Now(),
 			},
 		},
 	)
@@ -213,13 +226,21 @@ func (s *serviceAccountsStore) Unlock(
 	id string,
 	newHashedToken string,
 ) error {
+	now := time.Now()
 	res, err := s.collection.UpdateOne(
 		ctx,
 		bson.M{"id": id},
 		bson.M{
 			"$set": bson.M{
-				"locked":      nil,
-				"hashedToken": newHashedToken,
+				"locked":       nil,
+				"hashedToken":  newHashedToken,
+				"tokenCreated": now,
+				"lastUpdated":  now,
+			},
+			"$unset": bson.M{
+				"tokenExpires":         "",
+				"previousHashedToken":  "",
+				"previousTokenExpires": "",
 			},
 		},
 	)
@@ -234,3 +255,99 @@ func (s *serviceAccountsStore) Unlock(
 	}
 	return nil
 }
+
+func (s *serviceAccountsStore) UpdateToken(
+	ctx context.Context,
+	serviceAccount authx.ServiceAccount,
+	expectedHashedToken string,
+) error {
+	set := bson.M{
+		"hashedToken":  serviceAccount.HashedToken,
+		"tokenCreated": serviceAccount.TokenCreated,
+		"lastUpdated":  time.Now(),
+	}
+	unset := bson.M{}
+	if serviceAccount.TokenExpires != nil {
+		set["tokenExpires"] = serviceAccount.TokenExpires
+	} else {
+		unset["tokenExpires"] = ""
+	}
+	if serviceAccount.PreviousHashedToken != "" {
+		set["previousHashedToken"] = serviceAccount.PreviousHashedToken
+		set["previousTokenExpires"] = serviceAccount.PreviousTokenExpires
+	} else {
+		unset["previousHashedToken"] = ""
+		unset["previousTokenExpires"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	res, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"id":          serviceAccount.ID,
+			"hashedToken": expectedHashedToken,
+		},
+		update,
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating token for service account %q",
+			serviceAccount.ID,
+		)
+	}
+	if res.MatchedCount == 0 {
+		// Distinguish between a ServiceAccount that doesn't exist and one whose
+		// token was changed out from under us
+		count, err := s.collection.CountDocuments(
+			ctx,
+			bson.M{"id": serviceAccount.ID},
+		)
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"error counting service accounts with id %q",
+				serviceAccount.ID,
+			)
+		}
+		if count == 0 {
+			return &meta.ErrNotFound{
+				Type: "ServiceAccount",
+				ID:   serviceAccount.ID,
+			}
+		}
+		return &meta.ErrConflict{
+			Type: "ServiceAccount",
+			ID:   serviceAccount.ID,
+			Reason: fmt.Sprintf(
+				"The token for service account %q was changed concurrently. "+
+					"Please try again.",
+				serviceAccount.ID,
+			),
+		}
+	}
+	return nil
+}
+
+func (s *serviceAccountsStore) RehashToken(
+	ctx context.Context,
+	legacyHashedToken string,
+	hashedToken string,
+) error {
+	for _, field := range []string{"hashedToken", "previousHashedToken"} {
+		if _, err := s.collection.UpdateOne(
+			ctx,
+			bson.M{field: legacyHashedToken},
+			bson.M{
+				"$set": bson.M{
+					field: hashedToken,
+				},
+			},
+		); err != nil {
+			return errors.Wrap(err, "error updating service account token hash")
+		}
+	}
+	return nil
+}
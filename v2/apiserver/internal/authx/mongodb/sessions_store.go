@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,35 +18,7 @@ type sessionsStore struct {
 }
 
 func NewSessionsStore(database *mongo.Database) (authx.SessionsStore, error) {
-	ctx, cancel :=
-		context.WithTimeout(context.Background(), createIndexTimeout)
-	defer cancel()
-	unique := true
 	collection := database.Collection("sessions")
-	if _, err := collection.Indexes().CreateMany(
-		ctx,
-		[]mongo.IndexModel{
-			{
-				Keys: bson.M{
-					"id": 1,
-				},
-				Options: &options.IndexOptions{
-					Unique: &unique,
-				},
-			},
-			// Fast lookup by token
-			{
-				Keys: bson.M{
-					"hashedToken": 1,
-				},
-				Options: &options.IndexOptions{
-					Unique: &unique,
-				},
-			},
-		},
-	); err != nil {
-		return nil, errors.Wrap(err, "error adding indexes to sessions collection")
-	}
 	return &sessionsStore{
 		collection: collection,
 	}, nil
@@ -61,6 +34,103 @@ func (s *sessionsStore) Create(
 	return nil
 }
 
+func (s *sessionsStore) List(
+	ctx context.Context,
+	selector authx.SessionsSelector,
+	opts meta.ListOptions,
+) (authx.SessionList, error) {
+	sessions := authx.SessionList{}
+
+	now := time.Now()
+	criteria := bson.M{
+		"authenticated": bson.M{"$ne": nil},
+		"$or": []bson.M{
+			{"expires": bson.M{"$gt": now}},
+			{"refreshTokenExpires": bson.M{"$gt": now}},
+		},
+	}
+	if !selector.AllUsers {
+		criteria["userID"] = selector.UserID
+		// Root sessions are the only authenticated sessions with no user ID
+		criteria["root"] = selector.UserID == ""
+	}
+
+	findCriteria := criteria
+	if opts.Continue != "" {
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
+		if err != nil {
+			return sessions, err
+		}
+		findCriteria = bson.M{
+			"$and": []bson.M{
+				criteria,
+				mongodb.KeysetCriteria(continueCreated, continueID, false),
+			},
+		}
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: 1},
+			{Key: "id", Value: 1},
+		},
+	)
+	findOptions.SetLimit(opts.Limit)
+	cur, err := s.collection.Find(ctx, findCriteria, findOptions)
+	if err != nil {
+		return sessions, errors.Wrap(err, "error finding sessions")
+	}
+	if err := cur.All(ctx, &sessions.Items); err != nil {
+		return sessions, errors.Wrap(err, "error decoding sessions")
+	}
+
+	if int64(len(sessions.Items)) == opts.Limit {
+		lastItem := sessions.Items[opts.Limit-1]
+		remaining, err := s.collection.CountDocuments(
+			ctx,
+			bson.M{
+				"$and": []bson.M{
+					criteria,
+					mongodb.KeysetCriteria(lastItem.Created, lastItem.ID, false),
+				},
+			},
+		)
+		if err != nil {
+			return sessions, errors.Wrap(err, "error counting remaining sessions")
+		}
+		if remaining > 0 {
+			sessions.Continue =
+				mongodb.EncodeContinueToken(lastItem.Created, lastItem.ID)
+			sessions.RemainingItemCount = remaining
+		}
+	}
+
+	return sessions, nil
+}
+
+func (s *sessionsStore) Get(
+	ctx context.Context,
+	id string,
+) (authx.Session, error) {
+	session := authx.Session{}
+	res := s.collection.FindOne(ctx, bson.M{"id": id})
+	if res.Err() == mongo.ErrNoDocuments {
+		return session, &meta.ErrNotFound{
+			Type: "Session",
+			ID:   id,
+		}
+	}
+	if res.Err() != nil {
+		return session, errors.Wrapf(res.Err(), "error finding session %q", id)
+	}
+	if err := res.Decode(&session); err != nil {
+		return session, errors.Wrapf(err, "error decoding session %q", id)
+	}
+	return session, nil
+}
+
 func (s *sessionsStore) GetByHashedOAuth2State(
 	ctx context.Context,
 	hashedOAuth2State string,
@@ -110,11 +180,57 @@ func (s *sessionsStore) GetByHashedToken(
 	return session, nil
 }
 
+func (s *sessionsStore) GetByHashedRefreshToken(
+	ctx context.Context,
+	hashedRefreshToken string,
+) (authx.Session, error) {
+	session := authx.Session{}
+	res := s.collection.FindOne(
+		ctx,
+		bson.M{"hashedRefreshToken": hashedRefreshToken},
+	)
+	if res.Err() == mongo.ErrNoDocuments {
+		return session, &meta.ErrNotFound{
+			Type: "Session",
+		}
+	}
+	if res.Err() != nil {
+		return session, errors.Wrap(
+			res.Err(),
+			"error finding session by hashed refresh token",
+		)
+	}
+	if err := res.Decode(&session); err != nil {
+		return session, errors.Wrap(err, "error decoding session")
+	}
+	return session, nil
+}
+
+func (s *sessionsStore) RehashToken(
+	ctx context.Context,
+	legacyHashedToken string,
+	hashedToken string,
+) error {
+	if _, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"hashedToken": legacyHashedToken},
+		bson.M{
+			"$set": bson.M{
+				"hashedToken": hashedToken,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "error updating session token hash")
+	}
+	return nil
+}
+
 func (s *sessionsStore) Authenticate(
 	ctx context.Context,
 	sessionID string,
 	userID string,
 	expires time.Time,
+	refreshTokenExpires time.Time,
 ) error {
 	res, err := s.collection.UpdateOne(
 		ctx,
@@ -123,9 +239,10 @@ func (s *sessionsStore) Authenticate(
 		},
 		bson.M{
 			"$set": bson.M{
-				"userID":        userID,
-				"authenticated": time.Now(),
-				"expires":       expires,
+				"userID":              userID,
+				"authenticated":       time.Now(),
+				"expires":             expires,
+				"refreshTokenExpires": refreshTokenExpires,
 			},
 		},
 	)
@@ -141,6 +258,37 @@ func (s *sessionsStore) Authenticate(
 	return nil
 }
 
+func (s *sessionsStore) Refresh(
+	ctx context.Context,
+	session authx.Session,
+	expectedHashedRefreshToken string,
+) error {
+	res, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"id":                 session.ID,
+			"hashedRefreshToken": expectedHashedRefreshToken,
+		},
+		bson.M{
+			"$set": bson.M{
+				"hashedToken":        session.HashedToken,
+				"hashedRefreshToken": session.HashedRefreshToken,
+				"expires":            session.Expires,
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error updating session %q", session.ID)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "Session",
+			ID:   session.ID,
+		}
+	}
+	return nil
+}
+
 func (s *sessionsStore) Delete(ctx context.Context, id string) error {
 	res, err := s.collection.DeleteOne(ctx, bson.M{"id": id})
 	if err != nil {
@@ -0,0 +1,183 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type teamsStore struct {
+	collection *mongo.Collection
+}
+
+func NewTeamsStore(database *mongo.Database) (authx.TeamsStore, error) {
+	return &teamsStore{
+		collection: database.Collection("teams"),
+	}, nil
+}
+
+func (t *teamsStore) Create(ctx context.Context, team authx.Team) error {
+	if _, err := t.collection.InsertOne(ctx, team); err != nil {
+		if writeException, ok := err.(mongo.WriteException); ok {
+			if len(writeException.WriteErrors) == 1 &&
+				writeException.WriteErrors[0].Code == 11000 {
+				return &meta.ErrConflict{
+					Type:   "Team",
+					ID:     team.ID,
+					Reason: fmt.Sprintf("A team with the ID %q already exists.", team.ID),
+				}
+			}
+		}
+		return errors.Wrapf(err, "error inserting new team %q", team.ID)
+	}
+	return nil
+}
+
+func (t *teamsStore) List(
+	ctx context.Context,
+	opts meta.ListOptions,
+) (authx.TeamList, error) {
+	teams := authx.TeamList{}
+
+	criteria := bson.M{}
+	if opts.Continue != "" {
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
+		if err != nil {
+			return teams, err
+		}
+		criteria = mongodb.KeysetCriteria(continueCreated, continueID, false)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: 1},
+			{Key: "id", Value: 1},
+		},
+	)
+	findOptions.SetLimit(opts.Limit)
+	cur, err := t.collection.Find(ctx, criteria, findOptions)
+	if err != nil {
+		return teams, errors.Wrap(err, "error finding teams")
+	}
+	if err := cur.All(ctx, &teams.Items); err != nil {
+		return teams, errors.Wrap(err, "error decoding teams")
+	}
+
+	if int64(len(teams.Items)) == opts.Limit {
+		lastItem := teams.Items[opts.Limit-1]
+		remaining, err := t.collection.CountDocuments(
+			ctx,
+			mongodb.KeysetCriteria(lastItem.Created, lastItem.ID, false),
+		)
+		if err != nil {
+			return teams, errors.Wrap(err, "error counting remaining teams")
+		}
+		if remaining > 0 {
+			teams.Continue =
+				mongodb.EncodeContinueToken(lastItem.Created, lastItem.ID)
+			teams.RemainingItemCount = remaining
+		}
+	}
+
+	return teams, nil
+}
+
+func (t *teamsStore) Get(ctx context.Context, id string) (authx.Team, error) {
+	team := authx.Team{}
+	res := t.collection.FindOne(ctx, bson.M{"id": id})
+	if res.Err() == mongo.ErrNoDocuments {
+		return team, &meta.ErrNotFound{
+			Type: "Team",
+			ID:   id,
+		}
+	}
+	if res.Err() != nil {
+		return team, errors.Wrapf(res.Err(), "error finding team %q", id)
+	}
+	if err := res.Decode(&team); err != nil {
+		return team, errors.Wrapf(err, "error decoding team %q", id)
+	}
+	return team, nil
+}
+
+func (t *teamsStore) AddMember(
+	ctx context.Context,
+	id string,
+	member authx.TeamMember,
+) error {
+	res, err := t.collection.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{
+			"$addToSet": bson.M{
+				"members": member,
+			},
+			"$set": bson.M{
+				"lastUpdated": time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error updating team %q", id)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "Team",
+			ID:   id,
+		}
+	}
+	return nil
+}
+
+func (t *teamsStore) RemoveMember(
+	ctx context.Context,
+	id string,
+	member authx.TeamMember,
+) error {
+	res, err := t.collection.UpdateOne(
+		ctx,
+		bson.M{"id": id},
+		bson.M{
+			"$pull": bson.M{
+				"members": member,
+			},
+			"$set": bson.M{
+				"lastUpdated": time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error updating team %q", id)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "Team",
+			ID:   id,
+		}
+	}
+	return nil
+}
+
+func (t *teamsStore) Delete(ctx context.Context, id string) error {
+	res, err := t.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return errors.Wrapf(err, "error deleting team %q", id)
+	}
+	if res.DeletedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "Team",
+			ID:   id,
+		}
+	}
+	return nil
+}
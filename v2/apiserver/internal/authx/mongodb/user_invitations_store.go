@@ -0,0 +1,146 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type userInvitationsStore struct {
+	collection *mongo.Collection
+}
+
+func NewUserInvitationsStore(
+	database *mongo.Database,
+) (authx.UserInvitationsStore, error) {
+	return &userInvitationsStore{
+		collection: database.Collection("user-invitations"),
+	}, nil
+}
+
+func (u *userInvitationsStore) Create(
+	ctx context.Context,
+	userInvitation authx.UserInvitation,
+) error {
+	if _, err := u.collection.InsertOne(ctx, userInvitation); err != nil {
+		if writeException, ok := err.(mongo.WriteException); ok {
+			if len(writeException.WriteErrors) == 1 &&
+				writeException.WriteErrors[0].Code == 11000 {
+				return &meta.ErrConflict{
+					Type: "UserInvitation",
+					ID:   userInvitation.ID,
+					Reason: fmt.Sprintf(
+						"An invitation for %q already exists.",
+						userInvitation.ID,
+					),
+				}
+			}
+		}
+		return errors.Wrapf(
+			err,
+			"error inserting new user invitation %q",
+			userInvitation.ID,
+		)
+	}
+	return nil
+}
+
+func (u *userInvitationsStore) List(
+	ctx context.Context,
+	opts meta.ListOptions,
+) (authx.UserInvitationList, error) {
+	userInvitations := authx.UserInvitationList{}
+
+	criteria := bson.M{}
+	if opts.Continue != "" {
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
+		if err != nil {
+			return userInvitations, err
+		}
+		criteria = mongodb.KeysetCriteria(continueCreated, continueID, false)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: 1},
+			{Key: "id", Value: 1},
+		},
+	)
+	findOptions.SetLimit(opts.Limit)
+	cur, err := u.collection.Find(ctx, criteria, findOptions)
+	if err != nil {
+		return userInvitations,
+			errors.Wrap(err, "error finding user invitations")
+	}
+	if err := cur.All(ctx, &userInvitations.Items); err != nil {
+		return userInvitations,
+			errors.Wrap(err, "error decoding user invitations")
+	}
+
+	if int64(len(userInvitations.Items)) == opts.Limit {
+		lastItem := userInvitations.Items[opts.Limit-1]
+		remaining, err := u.collection.CountDocuments(
+			ctx,
+			mongodb.KeysetCriteria(lastItem.Created, lastItem.ID, false),
+		)
+		if err != nil {
+			return userInvitations, errors.Wrap(
+				err,
+				"error counting remaining user invitations",
+			)
+		}
+		if remaining > 0 {
+			userInvitations.Continue =
+				mongodb.EncodeContinueToken(lastItem.Created, lastItem.ID)
+			userInvitations.RemainingItemCount = remaining
+		}
+	}
+
+	return userInvitations, nil
+}
+
+func (u *userInvitationsStore) Get(
+	ctx context.Context,
+	id string,
+) (authx.UserInvitation, error) {
+	userInvitation := authx.UserInvitation{}
+	res := u.collection.FindOne(ctx, bson.M{"id": id})
+	if res.Err() == mongo.ErrNoDocuments {
+		return userInvitation, &meta.ErrNotFound{
+			Type: "UserInvitation",
+			ID:   id,
+		}
+	}
+	if res.Err() != nil {
+		return userInvitation,
+			errors.Wrapf(res.Err(), "error finding user invitation %q", id)
+	}
+	if err := res.Decode(&userInvitation); err != nil {
+		return userInvitation,
+			errors.Wrapf(err, "error decoding user invitation %q", id)
+	}
+	return userInvitation, nil
+}
+
+func (u *userInvitationsStore) Delete(ctx context.Context, id string) error {
+	res, err := u.collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return errors.Wrapf(err, "error deleting user invitation %q", id)
+	}
+	if res.DeletedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "UserInvitation",
+			ID:   id,
+		}
+	}
+	return nil
+}
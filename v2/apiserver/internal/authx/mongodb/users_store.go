@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,24 +20,7 @@ type usersStore struct {
 }
 
 func NewUsersStore(database *mongo.Database) (authx.UsersStore, error) {
-	ctx, cancel :=
-		context.WithTimeout(context.Background(), createIndexTimeout)
-	defer cancel()
-	unique := true
 	collection := database.Collection("users")
-	if _, err := collection.Indexes().CreateOne(
-		ctx,
-		mongo.IndexModel{
-			Keys: bson.M{
-				"id": 1,
-			},
-			Options: &options.IndexOptions{
-				Unique: &unique,
-			},
-		},
-	); err != nil {
-		return nil, errors.Wrap(err, "error adding indexes to users collection")
-	}
 	return &usersStore{
 		collection:         collection,
 		sessionsCollection: database.Collection("sessions"),
@@ -71,19 +55,43 @@ func (u *usersStore) Count(ctx context.Context) (int64, error) {
 
 func (u *usersStore) List(
 	ctx context.Context,
+	selector authx.UsersSelector,
 	opts meta.ListOptions,
 ) (authx.UserList, error) {
 	users := authx.UserList{}
 
 	criteria := bson.M{}
+	if labelCriteria := mongodb.LabelSelectorCriteria(
+		"metadataLabels",
+		selector.LabelSelector,
+	); len(labelCriteria) > 0 {
+		criteria["$and"] = labelCriteria
+	}
+
+	findCriteria := criteria
 	if opts.Continue != "" {
-		criteria["id"] = bson.M{"$gt": opts.Continue}
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
+		if err != nil {
+			return users, err
+		}
+		findCriteria = bson.M{
+			"$and": []bson.M{
+				criteria,
+				mongodb.KeysetCriteria(continueCreated, continueID, false),
+			},
+		}
 	}
 
 	findOptions := options.Find()
-	findOptions.SetSort(bson.M{"id": 1})
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: 1},
+			{Key: "id", Value: 1},
+		},
+	)
 	findOptions.SetLimit(opts.Limit)
-	cur, err := u.collection.Find(ctx, criteria, findOptions)
+	cur, err := u.collection.Find(ctx, findCriteria, findOptions)
 	if err != nil {
 		return users, errors.Wrap(err, "error finding users")
 	}
@@ -92,14 +100,22 @@ func (u *usersStore) List(
 	}
 
 	if int64(len(users.Items)) == opts.Limit {
-		continueID := users.Items[opts.Limit-1].ID
-		criteria["id"] = bson.M{"$gt": continueID}
-		remaining, err := u.collection.CountDocuments(ctx, criteria)
+		lastItem := users.Items[opts.Limit-1]
+		remaining, err := u.collection.CountDocuments(
+			ctx,
+			bson.M{
+				"$and": []bson.M{
+					criteria,
+					mongodb.KeysetCriteria(lastItem.Created, lastItem.ID, false),
+				},
+			},
+		)
 		if err != nil {
 			return users, errors.Wrap(err, "error counting remaining users")
 		}
 		if remaining > 0 {
-			users.Continue = continueID
+			users.Continue =
+				mongodb.EncodeContinueToken(lastItem.Created, lastItem.ID)
 			users.RemainingItemCount = remaining
 		}
 	}
@@ -128,13 +144,48 @@ func (u *usersStore) Get(
 	return user, nil
 }
 
+func (u *usersStore) UpdateGroups(
+	ctx context.Context,
+	id string,
+	groups []string,
+) error {
+	update := bson.M{
+		"$set": bson.M{
+			"groups":      groups,
+			"lastUpdated": time.Now(),
+		},
+	}
+	if len(groups) == 0 {
+		update = bson.M{
+			"$set": bson.M{
+				"lastUpdated": time.Now(),
+			},
+			"$unset": bson.M{
+				"groups": "",
+			},
+		}
+	}
+	res, err := u.collection.UpdateOne(ctx, bson.M{"id": id}, update)
+	if err != nil {
+		return errors.Wrapf(err, "error updating groups for user %q", id)
+	}
+	if res.MatchedCount == 0 {
+		return &meta.ErrNotFound{
+			Type: "User",
+			ID:   id,
+		}
+	}
+	return nil
+}
+
 func (u *usersStore) Lock(ctx context.Context, id string) error {
 	res, err := u.collection.UpdateOne(
 		ctx,
 		bson.M{"id": id},
 		bson.M{
 			"$set": bson.M{
-				"locked": time.Now(),
+				"locked":      time.Now(),
+				"lastUpdated": time.Now(),
 			},
 		},
 	)
@@ -169,7 +220,8 @@ func (u *usersStore) Unlock(ctx context.Context, id string) error {
 		bson.M{"id": id},
 		bson.M{
 			"$set": bson.M{
-				"locked": nil,
+				"locked":      nil,
+				"lastUpdated": time.Now(),
 			},
 		},
 	)
@@ -0,0 +1,186 @@
+package authx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+)
+
+// Permission represents the ability to perform a single action (a verb) on a
+// single type of project-level resource. Permissions are expressed as
+// RESOURCE_TYPE:VERB-- for instance, events:create. Project-level Roles,
+// whether built-in or custom, are bundles of Permissions.
+type Permission string
+
+const (
+	// PermissionEventRetentionEnforce represents the ability to enforce a
+	// Project's Event retention policy on demand.
+	PermissionEventRetentionEnforce Permission = "event-retention:enforce"
+	// PermissionEventRetentionPreview represents the ability to preview which
+	// of a Project's Events its Event retention policy would delete.
+	PermissionEventRetentionPreview Permission = "event-retention:preview"
+	// PermissionEventsCancel represents the ability to cancel a Project's
+	// Events.
+	PermissionEventsCancel Permission = "events:cancel"
+	// PermissionEventsCreate represents the ability to create new Events for a
+	// Project.
+	PermissionEventsCreate Permission = "events:create"
+	// PermissionEventsDelete represents the ability to delete a Project's
+	// Events.
+	PermissionEventsDelete Permission = "events:delete"
+	// PermissionLogsGet represents the ability to retrieve logs for a Project's
+	// Events.
+	PermissionLogsGet Permission = "logs:get"
+	// PermissionProjectsDelete represents the ability to delete a Project.
+	PermissionProjectsDelete Permission = "projects:delete"
+	// PermissionProjectsUpdate represents the ability to update a Project,
+	// including its Worker template.
+	PermissionProjectsUpdate Permission = "projects:update"
+	// PermissionRoleAssignmentsCreate represents the ability to grant
+	// project-level Roles for a Project.
+	PermissionRoleAssignmentsCreate Permission = "role-assignments:create"
+	// PermissionRoleAssignmentsDelete represents the ability to revoke
+	// project-level Roles for a Project.
+	PermissionRoleAssignmentsDelete Permission = "role-assignments:delete"
+	// PermissionSecretsDelete represents the ability to unset a Project's
+	// Secrets.
+	PermissionSecretsDelete Permission = "secrets:delete"
+	// PermissionSecretsUpdate represents the ability to set a Project's Secrets.
+	PermissionSecretsUpdate Permission = "secrets:update"
+)
+
+// Permissions enumerates all known Permissions.
+var Permissions = []Permission{
+	PermissionEventRetentionEnforce,
+	PermissionEventRetentionPreview,
+	PermissionEventsCancel,
+	PermissionEventsCreate,
+	PermissionEventsDelete,
+	PermissionLogsGet,
+	PermissionProjectsDelete,
+	PermissionProjectsUpdate,
+	PermissionRoleAssignmentsCreate,
+	PermissionRoleAssignmentsDelete,
+	PermissionSecretsDelete,
+	PermissionSecretsUpdate,
+}
+
+// builtInProjectRolePermissions maps the names of built-in project-level Roles
+// to the Permissions they bundle.
+var builtInProjectRolePermissions = map[RoleName][]Permission{
+	RoleNameProjectAdmin: {
+		PermissionEventRetentionEnforce,
+		PermissionProjectsDelete,
+		PermissionRoleAssignmentsCreate,
+		PermissionRoleAssignmentsDelete,
+		PermissionSecretsDelete,
+		PermissionSecretsUpdate,
+	},
+	RoleNameProjectDeveloper: {
+		PermissionProjectsUpdate,
+	},
+	RoleNameProjectUser: {
+		PermissionEventRetentionPreview,
+		PermissionEventsCancel,
+		PermissionEventsCreate,
+		PermissionEventsDelete,
+		PermissionLogsGet,
+	},
+}
+
+// BuiltInProjectRolePermissions returns the Permissions bundled by the
+// built-in project-level Role having the provided RoleName. The bool returned
+// is false if no such built-in Role exists.
+func BuiltInProjectRolePermissions(name RoleName) ([]Permission, bool) {
+	permissions, ok := builtInProjectRolePermissions[name]
+	return permissions, ok
+}
+
+// isKnownPermission returns a bool indicating whether the provided Permission
+// is one of the known Permissions.
+func isKnownPermission(permission Permission) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectAuthorizeFn is the signature for any function that can, presumably,
+// retrieve a principal from the provided Context and make an access control
+// decision based on whether that principal holds project-level Roles for the
+// specified Project that, together, grant ALL of the specified Permissions.
+type ProjectAuthorizeFn func(
+	ctx context.Context,
+	projectID string,
+	permissions ...Permission,
+) error
+
+func AlwaysProjectAuthorize(context.Context, string, ...Permission) error {
+	return nil
+}
+
+func NeverProjectAuthorize(context.Context, string, ...Permission) error {
+	return &meta.ErrAuthorization{}
+}
+
+// NewProjectAuthorizer returns a ProjectAuthorizeFn that resolves the
+// project-level Roles held by the principal associated with a Context into
+// Permissions. Built-in Roles resolve to fixed bundles of Permissions. Custom
+// Roles are resolved using the provided RoleDefinitionsStore.
+func NewProjectAuthorizer(
+	roleDefinitionsStore RoleDefinitionsStore,
+) ProjectAuthorizeFn {
+	return func(
+		ctx context.Context,
+		projectID string,
+		permissions ...Permission,
+	) error {
+		principal, ok := ctx.Value(principalContextKey{}).(Principal)
+		if !ok {
+			return &meta.ErrAuthorization{}
+		}
+		held := map[Permission]struct{}{}
+		for _, role := range principal.Roles() {
+			if role.Type != RoleTypeProject ||
+				(role.Scope != projectID && role.Scope != RoleScopeGlobal) {
+				continue
+			}
+			rolePermissions, ok := builtInProjectRolePermissions[role.Name]
+			if !ok {
+				roleDefinition, err :=
+					roleDefinitionsStore.Get(ctx, string(role.Name))
+				if err != nil {
+					if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
+						// The custom Role has been deleted out from under the principal
+						continue
+					}
+					return errors.Wrapf(
+						err,
+						"error retrieving role definition %q from store",
+						role.Name,
+					)
+				}
+				rolePermissions = roleDefinition.Permissions
+			}
+			for _, permission := range rolePermissions {
+				held[permission] = struct{}{}
+			}
+		}
+		for _, permission := range permissions {
+			if _, ok := held[permission]; !ok {
+				return &meta.ErrAuthorization{
+					Reason: fmt.Sprintf(
+						"Permission %q is required for project %q.",
+						permission,
+						projectID,
+					),
+				}
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,379 @@
+package authx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// PersonalAccessTokenList is an ordered and pageable list of
+// PersonalAccessTokens.
+type PersonalAccessTokenList struct {
+	// ListMeta contains list metadata.
+	meta.ListMeta `json:"metadata"`
+	// Items is a slice of PersonalAccessTokens.
+	Items []PersonalAccessToken `json:"items,omitempty"`
+}
+
+// MarshalJSON amends PersonalAccessTokenList instances with type metadata.
+func (p PersonalAccessTokenList) MarshalJSON() ([]byte, error) {
+	type Alias PersonalAccessTokenList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "PersonalAccessTokenList",
+			},
+			Alias: (Alias)(p),
+		},
+	)
+}
+
+// PersonalAccessToken represents a long-lived token that a User can use to
+// authenticate to the Brigade API non-interactively, e.g. from scripts. A
+// PersonalAccessToken acts on behalf of the User who owns it, optionally with
+// only a subset of that User's Roles.
+type PersonalAccessToken struct {
+	// ObjectMeta encapsulates PersonalAccessToken metadata.
+	meta.ObjectMeta `json:"metadata" bson:",inline"`
+	// UserID is the identifier of the User who owns the PersonalAccessToken.
+	UserID string `json:"userID" bson:"userID"`
+	// Name is a name, unique among the owning User's PersonalAccessTokens, that
+	// describes the PersonalAccessToken's purpose.
+	Name string `json:"name" bson:"name"`
+	// HashedToken is a secure, one-way hash of the PersonalAccessToken's token.
+	HashedToken string `json:"-" bson:"hashedToken"`
+	// Expires indicates when the PersonalAccessToken expires. If this field's
+	// value is nil, the PersonalAccessToken never expires.
+	Expires *time.Time `json:"expires,omitempty" bson:"expires,omitempty"`
+	// Roles optionally restricts the PersonalAccessToken to a subset of the
+	// owning User's Roles. If empty, the PersonalAccessToken carries all of the
+	// owning User's Roles. Roles the User holds by virtue of group membership
+	// are never carried.
+	Roles []Role `json:"roles,omitempty" bson:"roles,omitempty"`
+}
+
+// MarshalJSON amends PersonalAccessToken instances with type metadata.
+func (p PersonalAccessToken) MarshalJSON() ([]byte, error) {
+	type Alias PersonalAccessToken
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "PersonalAccessToken",
+			},
+			Alias: (Alias)(p),
+		},
+	)
+}
+
+// EffectiveRoles returns those of the provided Roles (presumably held by the
+// owning User) that the PersonalAccessToken carries. Roles that the
+// PersonalAccessToken was restricted to, but that the owning User no longer
+// holds, are never included.
+func (p *PersonalAccessToken) EffectiveRoles(userRoles []Role) []Role {
+	if len(p.Roles) == 0 {
+		return userRoles
+	}
+	roles := []Role{}
+	for _, role := range p.Roles {
+		if roleHeld(role, userRoles) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// roleHeld returns a bool indicating whether the provided Role is encompassed
+// by any of the provided held Roles. A held Role with a global scope
+// encompasses any Role of the same type and name.
+func roleHeld(role Role, heldRoles []Role) bool {
+	for _, heldRole := range heldRoles {
+		if heldRole.Type == role.Type && heldRole.Name == role.Name &&
+			(heldRole.Scope == role.Scope || heldRole.Scope == RoleScopeGlobal) {
+			return true
+		}
+	}
+	return false
+}
+
+type personalAccessTokenIDContextKey struct{}
+
+// ContextWithPersonalAccessTokenID returns a context derived from the provided
+// one that records that the request was authenticated using the
+// PersonalAccessToken having the specified identifier.
+func ContextWithPersonalAccessTokenID(
+	ctx context.Context,
+	personalAccessTokenID string,
+) context.Context {
+	return context.WithValue(
+		ctx,
+		personalAccessTokenIDContextKey{},
+		personalAccessTokenID,
+	)
+}
+
+// PersonalAccessTokenIDFromContext returns the identifier of the
+// PersonalAccessToken that was used to authenticate the request associated
+// with the provided context. It returns an empty string if the request was
+// not authenticated using a PersonalAccessToken.
+func PersonalAccessTokenIDFromContext(ctx context.Context) string {
+	id := ctx.Value(personalAccessTokenIDContextKey{})
+	if id == nil {
+		return ""
+	}
+	return id.(string)
+}
+
+// PersonalAccessTokensService is the specialized interface for managing
+// PersonalAccessTokens. It's decoupled from underlying technology choices (e.g.
+// data store) to keep business logic reusable and consistent while the
+// underlying tech stack remains free to change.
+type PersonalAccessTokensService interface {
+	// Create creates a new PersonalAccessToken owned by the User making the
+	// request and returns its Token. Any Roles the PersonalAccessToken is
+	// restricted to must be held by that User. If the User already owns a
+	// PersonalAccessToken having the same name, implementations MUST return a
+	// *meta.ErrConflict error.
+	Create(context.Context, PersonalAccessToken) (Token, error)
+	// List retrieves a PersonalAccessTokenList containing the
+	// PersonalAccessTokens owned by the User making the request, ordered by age,
+	// oldest first.
+	List(context.Context, meta.ListOptions) (PersonalAccessTokenList, error)
+	// GetByToken retrieves a single PersonalAccessToken specified by token. If no
+	// such PersonalAccessToken exists, implementations MUST return a
+	// *meta.ErrNotFound error. If the PersonalAccessToken has expired,
+	// implementations MUST return a *meta.ErrAuthentication error.
+	GetByToken(context.Context, string) (PersonalAccessToken, error)
+	// Revoke deletes a single PersonalAccessToken, owned by the User making the
+	// request, specified by its identifier. If no such PersonalAccessToken
+	// exists, implementations MUST return a *meta.ErrNotFound error.
+	Revoke(context.Context, string) error
+}
+
+type personalAccessTokensService struct {
+	personalAccessTokensStore PersonalAccessTokensStore
+	tokenHasher               crypto.TokenHasher
+}
+
+// NewPersonalAccessTokensService returns a specialized interface for managing
+// PersonalAccessTokens.
+func NewPersonalAccessTokensService(
+	personalAccessTokensStore PersonalAccessTokensStore,
+	tokenHasher crypto.TokenHasher,
+) PersonalAccessTokensService {
+	return &personalAccessTokensService{
+		personalAccessTokensStore: personalAccessTokensStore,
+		tokenHasher:               tokenHasher,
+	}
+}
+
+func (p *personalAccessTokensService) Create(
+	ctx context.Context,
+	personalAccessToken PersonalAccessToken,
+) (Token, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	if PersonalAccessTokenIDFromContext(ctx) != "" {
+		return Token{}, &meta.ErrAuthorization{
+			Reason: "Personal access tokens cannot be used to create other " +
+				"personal access tokens.",
+		}
+	}
+
+	now := time.Now()
+	if personalAccessToken.Expires != nil &&
+		!personalAccessToken.Expires.After(now) {
+		return Token{}, &meta.ErrBadRequest{
+			Reason: "Token expiry must be in the future.",
+		}
+	}
+	for _, role := range personalAccessToken.Roles {
+		if !roleHeld(role, user.Roles()) {
+			return Token{}, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Personal access tokens cannot carry roles their owner does not "+
+						"hold; user %q does not hold role %s with scope %q.",
+					user.ID,
+					role.Name,
+					role.Scope,
+				),
+			}
+		}
+	}
+
+	token := Token{
+		Value: crypto.NewTypedToken(crypto.TokenTypePersonalAccess),
+	}
+	personalAccessToken.ID = uuid.NewV4().String()
+	personalAccessToken.Created = &now
+	personalAccessToken.LastUpdated = &now
+	personalAccessToken.CreatedBy = PrincipalReferenceFromContext(ctx)
+	personalAccessToken.UserID = user.ID
+	personalAccessToken.HashedToken = p.tokenHasher.Hash(token.Value)
+	if err := p.personalAccessTokensStore.Create(
+		ctx,
+		personalAccessToken,
+	); err != nil {
+		return Token{}, errors.Wrapf(
+			err,
+			"error storing new personal access token %q for user %q",
+			personalAccessToken.Name,
+			user.ID,
+		)
+	}
+	return token, nil
+}
+
+func (p *personalAccessTokensService) List(
+	ctx context.Context,
+	opts meta.ListOptions,
+) (PersonalAccessTokenList, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return PersonalAccessTokenList{}, err
+	}
+
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+	personalAccessTokens, err :=
+		p.personalAccessTokensStore.List(ctx, user.ID, opts)
+	if err != nil {
+		return personalAccessTokens, errors.Wrapf(
+			err,
+			"error retrieving personal access tokens for user %q from store",
+			user.ID,
+		)
+	}
+	return personalAccessTokens, nil
+}
+
+func (p *personalAccessTokensService) GetByToken(
+	ctx context.Context,
+	token string,
+) (PersonalAccessToken, error) {
+
+	// No authz requirements here because this is is never invoked at the explicit
+	// request of an end user; rather it is invoked only by the system itself.
+
+	var personalAccessToken PersonalAccessToken
+	_, err := crypto.LookupByHashedToken(
+		p.tokenHasher,
+		token,
+		func(hashedToken string) (err error) {
+			personalAccessToken, err =
+				p.personalAccessTokensStore.GetByHashedToken(ctx, hashedToken)
+			return err
+		},
+		func(legacyHashedToken, hashedToken string) error {
+			return errors.Wrapf(
+				p.personalAccessTokensStore.RehashToken(
+					ctx,
+					legacyHashedToken,
+					hashedToken,
+				),
+				"error rehashing personal access token %q in store",
+				personalAccessToken.ID,
+			)
+		},
+	)
+	if err != nil {
+		return personalAccessToken, errors.Wrap(
+			err,
+			"error retrieving personal access token from store by hashed token",
+		)
+	}
+	if personalAccessToken.Expires != nil &&
+		time.Now().After(*personalAccessToken.Expires) {
+		return personalAccessToken, &meta.ErrAuthentication{
+			Reason: fmt.Sprintf(
+				"Supplied personal access token %q expired at %s.",
+				personalAccessToken.Name,
+				personalAccessToken.Expires.UTC().Format(time.RFC3339),
+			),
+		}
+	}
+	return personalAccessToken, nil
+}
+
+func (p *personalAccessTokensService) Revoke(
+	ctx context.Context,
+	id string,
+) error {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := p.personalAccessTokensStore.Delete(ctx, user.ID, id); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing personal access token %q from store",
+			id,
+		)
+	}
+	return nil
+}
+
+// userFromContext returns the User associated with the provided context. If
+// the principal associated with the context is not a User, a
+// *meta.ErrBadRequest error is returned, since only Users own
+// PersonalAccessTokens.
+func userFromContext(ctx context.Context) (*User, error) {
+	user, ok := ctx.Value(principalContextKey{}).(*User)
+	if !ok {
+		return nil, &meta.ErrBadRequest{
+			Reason: "Only users can manage personal access tokens.",
+		}
+	}
+	return user, nil
+}
+
+// PersonalAccessTokensStore is an interface for components that implement
+// PersonalAccessToken persistence concerns.
+type PersonalAccessTokensStore interface {
+	// Create persists a new PersonalAccessToken in the underlying data store. If
+	// the owning User already has a PersonalAccessToken having the same name,
+	// implementations MUST return a *meta.ErrConflict error.
+	Create(context.Context, PersonalAccessToken) error
+	// List retrieves a PersonalAccessTokenList containing the
+	// PersonalAccessTokens owned by the specified User from the underlying data
+	// store, with its Items ordered by age, oldest first.
+	List(
+		ctx context.Context,
+		userID string,
+		opts meta.ListOptions,
+	) (PersonalAccessTokenList, error)
+	// GetByHashedToken retrieves a single PersonalAccessToken having the provided
+	// hashed token from the underlying data store. If no such
+	// PersonalAccessToken exists, implementations MUST return a
+	// *meta.ErrNotFound error.
+	GetByHashedToken(context.Context, string) (PersonalAccessToken, error)
+	// RehashToken replaces the specified legacy hash of a PersonalAccessToken
+	// with a new hash of the same token in the underlying data store. If no
+	// PersonalAccessToken has the specified legacy hash, this is a no-op.
+	RehashToken(
+		ctx context.Context,
+		legacyHashedToken string,
+		hashedToken string,
+	) error
+	// Delete deletes the specified PersonalAccessToken, owned by the specified
+	// User, from the underlying data store. If no such PersonalAccessToken
+	// exists, implementations MUST return a *meta.ErrNotFound error.
+	Delete(ctx context.Context, userID string, id string) error
+}
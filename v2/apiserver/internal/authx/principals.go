@@ -1,6 +1,10 @@
 package authx
 
-import "context"
+import (
+	"context"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+)
 
 // PrincipalType is a type whose values can be used to disambiguate one type of
 // principal from another. For instance, when assigning a Role to a pincipal
@@ -9,11 +13,31 @@ import "context"
 type PrincipalType string
 
 const (
+	// PrincipalTypeGroup represents a group of Users, as asserted by an OpenID
+	// Connect identity provider. Roles assigned to a group are held by all Users
+	// belonging to it.
+	PrincipalTypeGroup PrincipalType = "GROUP"
 	// PrincipalTypeServiceAccount represents a principal that is a
 	// ServiceAccount.
 	PrincipalTypeServiceAccount PrincipalType = "SERVICE_ACCOUNT"
+	// PrincipalTypeTeam represents a Team of Users and ServiceAccounts. Roles
+	// assigned to a Team are held by all of its members.
+	PrincipalTypeTeam PrincipalType = "TEAM"
 	// PrincipalTypeUser represents a principal that is a User.
 	PrincipalTypeUser PrincipalType = "USER"
+
+	// The following represent principals internal to Brigade. They serve to
+	// identify those principals in references, but Roles cannot be assigned to
+	// them.
+
+	// PrincipalTypeObserver represents the Brigade observer component.
+	PrincipalTypeObserver PrincipalType = "OBSERVER"
+	// PrincipalTypeRoot represents the root user.
+	PrincipalTypeRoot PrincipalType = "ROOT"
+	// PrincipalTypeScheduler represents the Brigade scheduler component.
+	PrincipalTypeScheduler PrincipalType = "SCHEDULER"
+	// PrincipalTypeWorker represents a Worker.
+	PrincipalTypeWorker PrincipalType = "WORKER"
 )
 
 var (
@@ -88,3 +112,37 @@ func ContextWithPrincipal(
 func PincipalFromContext(ctx context.Context) Principal {
 	return ctx.Value(principalContextKey{}).(Principal)
 }
+
+// PrincipalReferenceFromContext returns a reference to the principal
+// associated with the provided context. It returns nil if no principal is
+// associated with the context.
+func PrincipalReferenceFromContext(
+	ctx context.Context,
+) *meta.PrincipalReference {
+	var principalType PrincipalType
+	var principalID string
+	switch p := ctx.Value(principalContextKey{}).(type) {
+	case *User:
+		principalType = PrincipalTypeUser
+		principalID = p.ID
+	case *ServiceAccount:
+		principalType = PrincipalTypeServiceAccount
+		principalID = p.ID
+	case *observer:
+		principalType = PrincipalTypeObserver
+	case *root:
+		principalType = PrincipalTypeRoot
+	case *scheduler:
+		principalType = PrincipalTypeScheduler
+	case *worker:
+		// Workers are identified by the Event they're handling
+		principalType = PrincipalTypeWorker
+		principalID = p.eventID
+	default:
+		return nil
+	}
+	return &meta.PrincipalReference{
+		Type: string(principalType),
+		ID:   principalID,
+	}
+}
@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/gorilla/mux"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type PersonalAccessTokensEndpoints struct {
+	*restmachinery.BaseEndpoints
+	PersonalAccessTokenSchemaLoader gojsonschema.JSONLoader
+	Service                         authx.PersonalAccessTokensService
+}
+
+func (p *PersonalAccessTokensEndpoints) Register(router *mux.Router) {
+	// Create personal access token
+	router.HandleFunc(
+		"/v2/personal-access-tokens",
+		p.TokenAuthFilter.Decorate(p.create),
+	).Methods(http.MethodPost)
+
+	// List personal access tokens
+	router.HandleFunc(
+		"/v2/personal-access-tokens",
+		p.TokenAuthFilter.Decorate(p.list),
+	).Methods(http.MethodGet)
+
+	// Revoke personal access token
+	router.HandleFunc(
+		"/v2/personal-access-tokens/{id}",
+		p.TokenAuthFilter.Decorate(p.revoke),
+	).Methods(http.MethodDelete)
+}
+
+func (p *PersonalAccessTokensEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/personal-access-tokens",
+			Summary:       "Create a personal access token for the current user",
+			RequestSchema: "personal-access-token.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v2/personal-access-tokens",
+			Summary:     "List the current user's personal access tokens",
+			QueryParams: restmachinery.ListQueryParams(),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/personal-access-tokens/{id}",
+			Summary: "Revoke one of the current user's personal access tokens",
+		},
+	}
+}
+
+func (p *PersonalAccessTokensEndpoints) create(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	personalAccessToken := authx.PersonalAccessToken{}
+	p.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: p.PersonalAccessTokenSchemaLoader,
+			ReqBodyObj:          &personalAccessToken,
+			EndpointLogic: func() (interface{}, error) {
+				return p.Service.Create(r.Context(), personalAccessToken)
+			},
+			SuccessCode: http.StatusCreated,
+		},
+	)
+}
+
+func (p *PersonalAccessTokensEndpoints) list(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	opts := meta.ListOptions{
+		Continue: r.URL.Query().Get("continue"),
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if opts.Limit, err = strconv.ParseInt(limitStr, 10, 64); err != nil ||
+			opts.Limit < 1 || opts.Limit > 100 {
+			p.WriteAPIResponse(
+				w,
+				http.StatusBadRequest,
+				&meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for "limit" query parameter`,
+						limitStr,
+					),
+				},
+			)
+			return
+		}
+	}
+	p.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return p.Service.List(r.Context(), opts)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (p *PersonalAccessTokensEndpoints) revoke(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	p.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, p.Service.Revoke(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+)
+
+// RoleAssignmentsSelectorQueryParams describes the query parameters understood
+// by RoleAssignmentsSelectorFromQuery. Endpoints for listing system-level and
+// project-level RoleAssignments both use them.
+var RoleAssignmentsSelectorQueryParams = []restmachinery.QueryParam{
+	{
+		Name: "principalType",
+		Description: "Select only assignments to principals of this type; " +
+			"USER, SERVICE_ACCOUNT, GROUP, or TEAM",
+	},
+	{
+		Name:        "principalID",
+		Description: "Select only assignments to the principal having this ID",
+	},
+	{
+		Name:        "role",
+		Description: "Select only assignments of the specified role",
+	},
+}
+
+// RoleAssignmentsSelectorFromQuery builds an authx.RoleAssignmentsSelector from
+// the provided request's query parameters.
+func RoleAssignmentsSelectorFromQuery(
+	r *http.Request,
+) authx.RoleAssignmentsSelector {
+	query := r.URL.Query()
+	return authx.RoleAssignmentsSelector{
+		PrincipalType: authx.PrincipalType(query.Get("principalType")),
+		PrincipalID:   query.Get("principalID"),
+		Role:          authx.RoleName(query.Get("role")),
+	}
+}
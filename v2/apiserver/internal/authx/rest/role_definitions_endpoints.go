@@ -0,0 +1,174 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/gorilla/mux"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RoleDefinitionsEndpoints struct {
+	*restmachinery.BaseEndpoints
+	RoleDefinitionSchemaLoader gojsonschema.JSONLoader
+	Service                    authx.RoleDefinitionsService
+}
+
+func (r *RoleDefinitionsEndpoints) Register(router *mux.Router) {
+	// Create role definition
+	router.HandleFunc(
+		"/v2/role-definitions",
+		r.TokenAuthFilter.Decorate(r.create),
+	).Methods(http.MethodPost)
+
+	// List role definitions
+	router.HandleFunc(
+		"/v2/role-definitions",
+		r.TokenAuthFilter.Decorate(r.list),
+	).Methods(http.MethodGet)
+
+	// Get role definition
+	router.HandleFunc(
+		"/v2/role-definitions/{id}",
+		r.TokenAuthFilter.Decorate(r.get),
+	).Methods(http.MethodGet)
+
+	// Update role definition
+	router.HandleFunc(
+		"/v2/role-definitions/{id}",
+		r.TokenAuthFilter.Decorate(r.update),
+	).Methods(http.MethodPut)
+
+	// Delete role definition
+	router.HandleFunc(
+		"/v2/role-definitions/{id}",
+		r.TokenAuthFilter.Decorate(r.delete),
+	).Methods(http.MethodDelete)
+}
+
+func (r *RoleDefinitionsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/role-definitions",
+			Summary:       "Define a custom project role",
+			RequestSchema: "role-definition.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/role-definitions",
+			Summary: "List built-in and custom project role definitions",
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/role-definitions/{id}",
+			Summary: "Get a project role definition",
+		},
+		{
+			Method:        http.MethodPut,
+			Path:          "/v2/role-definitions/{id}",
+			Summary:       "Update a custom project role definition",
+			RequestSchema: "role-definition.json",
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/role-definitions/{id}",
+			Summary: "Delete a custom project role definition",
+		},
+	}
+}
+
+func (r *RoleDefinitionsEndpoints) create(
+	w http.ResponseWriter,
+	req *http.Request,
+) {
+	roleDefinition := authx.RoleDefinition{}
+	r.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   req,
+			ReqBodySchemaLoader: r.RoleDefinitionSchemaLoader,
+			ReqBodyObj:          &roleDefinition,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, r.Service.Create(req.Context(), roleDefinition)
+			},
+			SuccessCode: http.StatusCreated,
+		},
+	)
+}
+
+func (r *RoleDefinitionsEndpoints) list(
+	w http.ResponseWriter,
+	req *http.Request,
+) {
+	r.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: req,
+			EndpointLogic: func() (interface{}, error) {
+				return r.Service.List(req.Context())
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (r *RoleDefinitionsEndpoints) get(
+	w http.ResponseWriter,
+	req *http.Request,
+) {
+	r.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: req,
+			EndpointLogic: func() (interface{}, error) {
+				return r.Service.Get(req.Context(), mux.Vars(req)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (r *RoleDefinitionsEndpoints) update(
+	w http.ResponseWriter,
+	req *http.Request,
+) {
+	roleDefinition := authx.RoleDefinition{}
+	r.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   req,
+			ReqBodySchemaLoader: r.RoleDefinitionSchemaLoader,
+			ReqBodyObj:          &roleDefinition,
+			EndpointLogic: func() (interface{}, error) {
+				if mux.Vars(req)["id"] != roleDefinition.ID {
+					return nil, &meta.ErrBadRequest{
+						Reason: "The role definition IDs in the URL path and request " +
+							"body do not match.",
+					}
+				}
+				return nil, r.Service.Update(req.Context(), roleDefinition)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (r *RoleDefinitionsEndpoints) delete(
+	w http.ResponseWriter,
+	req *http.Request,
+) {
+	r.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: req,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, r.Service.Delete(req.Context(), mux.Vars(req)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
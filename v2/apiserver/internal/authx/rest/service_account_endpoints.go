@@ -14,8 +14,9 @@ import (
 
 type ServiceAccountEndpoints struct {
 	*restmachinery.BaseEndpoints
-	ServiceAccountSchemaLoader gojsonschema.JSONLoader
-	Service                    authx.ServiceAccountsService
+	ServiceAccountSchemaLoader       gojsonschema.JSONLoader
+	TokenRotationOptionsSchemaLoader gojsonschema.JSONLoader
+	Service                          authx.ServiceAccountsService
 }
 
 func (s *ServiceAccountEndpoints) Register(router *mux.Router) {
@@ -48,6 +49,56 @@ func (s *ServiceAccountEndpoints) Register(router *mux.Router) {
 		"/v2/service-accounts/{id}/lock",
 		s.TokenAuthFilter.Decorate(s.unlock),
 	).Methods(http.MethodDelete)
+
+	// Rotate service account token
+	router.HandleFunc(
+		"/v2/service-accounts/{id}/token-rotations",
+		s.TokenAuthFilter.Decorate(s.rotateToken),
+	).Methods(http.MethodPost)
+}
+
+func (s *ServiceAccountEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/service-accounts",
+			Summary:       "Create a service account",
+			RequestSchema: "service-account.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/service-accounts",
+			Summary: "List service accounts",
+			QueryParams: restmachinery.ListQueryParams(
+				restmachinery.LabelsQueryParam,
+			),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/service-accounts/{id}",
+			Summary: "Get a service account",
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/v2/service-accounts/{id}/lock",
+			Summary: "Lock a service account out of the system",
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/v2/service-accounts/{id}/lock",
+			Summary: "Restore a locked service account's access to the system " +
+				"and issue a new token",
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/v2/service-accounts/{id}/token-rotations",
+			Summary: "Issue a new token for a service account, optionally " +
+				"leaving the existing token valid for a grace period",
+			RequestSchema: "token-rotation-options.json",
+			SuccessCode:   http.StatusCreated,
+		},
+	}
 }
 
 func (s *ServiceAccountEndpoints) create(
@@ -70,6 +121,15 @@ func (s *ServiceAccountEndpoints) create(
 }
 
 func (s *ServiceAccountEndpoints) list(w http.ResponseWriter, r *http.Request) {
+	selector := authx.ServiceAccountsSelector{}
+	if labelsStr := r.URL.Query().Get("labels"); labelsStr != "" {
+		var err error
+		if selector.LabelSelector, err =
+			meta.ParseLabelSelector(labelsStr); err != nil {
+			s.WriteAPIResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
 	opts := meta.ListOptions{
 		Continue: r.URL.Query().Get("continue"),
 	}
@@ -95,7 +155,7 @@ func (s *ServiceAccountEndpoints) list(w http.ResponseWriter, r *http.Request) {
 			W: w,
 			R: r,
 			EndpointLogic: func() (interface{}, error) {
-				return s.Service.List(r.Context(), opts)
+				return s.Service.List(r.Context(), selector, opts)
 			},
 			SuccessCode: http.StatusOK,
 		},
@@ -142,3 +202,22 @@ func (s *ServiceAccountEndpoints) unlock(
 		},
 	)
 }
+
+func (s *ServiceAccountEndpoints) rotateToken(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	opts := authx.TokenRotationOptions{}
+	s.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: s.TokenRotationOptionsSchemaLoader,
+			ReqBodyObj:          &opts,
+			EndpointLogic: func() (interface{}, error) {
+				return s.Service.RotateToken(r.Context(), mux.Vars(r)["id"], opts)
+			},
+			SuccessCode: http.StatusCreated,
+		},
+	)
+}
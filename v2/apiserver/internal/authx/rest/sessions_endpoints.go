@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -9,11 +10,13 @@ import (
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 type SessionsEndpoints struct {
 	*restmachinery.BaseEndpoints
-	Service authx.SessionsService
+	SessionRefreshRequestSchemaLoader gojsonschema.JSONLoader
+	Service                           authx.SessionsService
 }
 
 func (s *SessionsEndpoints) Register(router *mux.Router) {
@@ -23,6 +26,18 @@ func (s *SessionsEndpoints) Register(router *mux.Router) {
 		s.create, // No filters applied to this request
 	).Methods(http.MethodPost)
 
+	// List sessions
+	router.HandleFunc(
+		"/v2/sessions",
+		s.TokenAuthFilter.Decorate(s.list),
+	).Methods(http.MethodGet)
+
+	// Revoke session
+	router.HandleFunc(
+		"/v2/sessions/{id}",
+		s.TokenAuthFilter.Decorate(s.revoke),
+	).Methods(http.MethodDelete)
+
 	// Delete session
 	router.HandleFunc(
 		"/v2/session",
@@ -34,6 +49,76 @@ func (s *SessionsEndpoints) Register(router *mux.Router) {
 		"/v2/session/auth",
 		s.authenticate, // No filters applied to this request
 	).Methods(http.MethodGet)
+
+	// Refresh session
+	router.HandleFunc(
+		"/v2/session/refresh",
+		s.refresh, // No filters applied to this request
+	).Methods(http.MethodPost)
+}
+
+func (s *SessionsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method: http.MethodPost,
+			Path:   "/v2/sessions",
+			Summary: "Create a session; root sessions require a basic auth " +
+				"header",
+			QueryParams: []restmachinery.QueryParam{
+				{
+					Name:        "root",
+					Description: "Whether to create a session for the root user",
+				},
+			},
+			SuccessCode:     http.StatusCreated,
+			Unauthenticated: true,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/sessions",
+			Summary: "List active sessions",
+			QueryParams: restmachinery.ListQueryParams(
+				restmachinery.QueryParam{
+					Name: "userID",
+					Description: "List the sessions of the specified user instead of " +
+						"those of the requester",
+				},
+				restmachinery.QueryParam{
+					Name:        "all",
+					Description: "Whether to list the sessions of all users",
+				},
+			),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/sessions/{id}",
+			Summary: "Revoke a session",
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/session",
+			Summary: "Delete the current session",
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/session/auth",
+			Summary: "Complete OpenID Connect authentication of a user session",
+			QueryParams: []restmachinery.QueryParam{
+				{Name: "state", Description: "The OAuth2 state"},
+				{Name: "code", Description: "The OAuth2 authorization code"},
+			},
+			SuccessContentType: "text/plain",
+			Unauthenticated:    true,
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/v2/session/refresh",
+			Summary: "Exchange a refresh token for a new session token and " +
+				"refresh token",
+			RequestSchema:   "session-refresh-request.json",
+			Unauthenticated: true,
+		},
+	}
 }
 
 func (s *SessionsEndpoints) create(w http.ResponseWriter, r *http.Request) {
@@ -73,6 +158,89 @@ func (s *SessionsEndpoints) create(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (s *SessionsEndpoints) list(w http.ResponseWriter, r *http.Request) {
+	selector := authx.SessionsSelector{
+		UserID: r.URL.Query().Get("userID"),
+	}
+	if allStr := r.URL.Query().Get("all"); allStr != "" {
+		var err error
+		if selector.AllUsers, err = strconv.ParseBool(allStr); err != nil {
+			s.WriteAPIResponse(
+				w,
+				http.StatusBadRequest,
+				&meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for "all" query parameter`,
+						allStr,
+					),
+				},
+			)
+			return
+		}
+	}
+	opts := meta.ListOptions{
+		Continue: r.URL.Query().Get("continue"),
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if opts.Limit, err = strconv.ParseInt(limitStr, 10, 64); err != nil ||
+			opts.Limit < 1 || opts.Limit > 100 {
+			s.WriteAPIResponse(
+				w,
+				http.StatusBadRequest,
+				&meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for "limit" query parameter`,
+						limitStr,
+					),
+				},
+			)
+			return
+		}
+	}
+	s.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return s.Service.List(r.Context(), selector, opts)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (s *SessionsEndpoints) revoke(w http.ResponseWriter, r *http.Request) {
+	s.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, s.Service.Revoke(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (s *SessionsEndpoints) refresh(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		RefreshToken string `json:"refreshToken"`
+	}{}
+	s.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: s.SessionRefreshRequestSchemaLoader,
+			ReqBodyObj:          &req,
+			EndpointLogic: func() (interface{}, error) {
+				return s.Service.Refresh(r.Context(), req.RefreshToken)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (s *SessionsEndpoints) delete(w http.ResponseWriter, r *http.Request) {
 	s.ServeRequest(
 		restmachinery.InboundRequest{
@@ -0,0 +1,216 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/gorilla/mux"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type TeamsEndpoints struct {
+	*restmachinery.BaseEndpoints
+	TeamSchemaLoader       gojsonschema.JSONLoader
+	TeamMemberSchemaLoader gojsonschema.JSONLoader
+	Service                authx.TeamsService
+}
+
+func (t *TeamsEndpoints) Register(router *mux.Router) {
+	// Create team
+	router.HandleFunc(
+		"/v2/teams",
+		t.TokenAuthFilter.Decorate(t.create),
+	).Methods(http.MethodPost)
+
+	// List teams
+	router.HandleFunc(
+		"/v2/teams",
+		t.TokenAuthFilter.Decorate(t.list),
+	).Methods(http.MethodGet)
+
+	// Get team
+	router.HandleFunc(
+		"/v2/teams/{id}",
+		t.TokenAuthFilter.Decorate(t.get),
+	).Methods(http.MethodGet)
+
+	// Delete team
+	router.HandleFunc(
+		"/v2/teams/{id}",
+		t.TokenAuthFilter.Decorate(t.delete),
+	).Methods(http.MethodDelete)
+
+	// Add team member
+	router.HandleFunc(
+		"/v2/teams/{id}/members",
+		t.TokenAuthFilter.Decorate(t.addMember),
+	).Methods(http.MethodPost)
+
+	// Remove team member
+	router.HandleFunc(
+		"/v2/teams/{id}/members",
+		t.TokenAuthFilter.Decorate(t.removeMember),
+	).Methods(http.MethodDelete)
+}
+
+func (t *TeamsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/teams",
+			Summary:       "Create a team",
+			RequestSchema: "team.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v2/teams",
+			Summary:     "List teams",
+			QueryParams: restmachinery.ListQueryParams(),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/teams/{id}",
+			Summary: "Get a team",
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/teams/{id}",
+			Summary: "Delete a team and all roles assigned to it",
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/teams/{id}/members",
+			Summary:       "Add a user or service account to a team",
+			RequestSchema: "team-member.json",
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/teams/{id}/members",
+			Summary: "Remove a user or service account from a team",
+			QueryParams: []restmachinery.QueryParam{
+				{
+					Name:        "principalType",
+					Description: "The type of principal; USER or SERVICE_ACCOUNT",
+				},
+				{Name: "principalID", Description: "The ID of the principal"},
+			},
+		},
+	}
+}
+
+func (t *TeamsEndpoints) create(w http.ResponseWriter, r *http.Request) {
+	team := authx.Team{}
+	t.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: t.TeamSchemaLoader,
+			ReqBodyObj:          &team,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, t.Service.Create(r.Context(), team)
+			},
+			SuccessCode: http.StatusCreated,
+		},
+	)
+}
+
+func (t *TeamsEndpoints) list(w http.ResponseWriter, r *http.Request) {
+	opts := meta.ListOptions{
+		Continue: r.URL.Query().Get("continue"),
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if opts.Limit, err = strconv.ParseInt(limitStr, 10, 64); err != nil ||
+			opts.Limit < 1 || opts.Limit > 100 {
+			t.WriteAPIResponse(
+				w,
+				http.StatusBadRequest,
+				&meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for "limit" query parameter`,
+						limitStr,
+					),
+				},
+			)
+			return
+		}
+	}
+	t.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return t.Service.List(r.Context(), opts)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (t *TeamsEndpoints) get(w http.ResponseWriter, r *http.Request) {
+	t.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return t.Service.Get(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (t *TeamsEndpoints) delete(w http.ResponseWriter, r *http.Request) {
+	t.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, t.Service.Delete(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (t *TeamsEndpoints) addMember(w http.ResponseWriter, r *http.Request) {
+	member := authx.TeamMember{}
+	t.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: t.TeamMemberSchemaLoader,
+			ReqBodyObj:          &member,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, t.Service.AddMember(r.Context(), mux.Vars(r)["id"], member)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (t *TeamsEndpoints) removeMember(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	member := authx.TeamMember{
+		PrincipalType: authx.PrincipalType(r.URL.Query().Get("principalType")),
+		PrincipalID:   r.URL.Query().Get("principalID"),
+	}
+	t.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil,
+					t.Service.RemoveMember(r.Context(), mux.Vars(r)["id"], member)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/gorilla/mux"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type UserInvitationsEndpoints struct {
+	*restmachinery.BaseEndpoints
+	UserInvitationSchemaLoader gojsonschema.JSONLoader
+	Service                    authx.UserInvitationsService
+}
+
+func (u *UserInvitationsEndpoints) Register(router *mux.Router) {
+	// Create user invitation
+	router.HandleFunc(
+		"/v2/user-invitations",
+		u.TokenAuthFilter.Decorate(u.create),
+	).Methods(http.MethodPost)
+
+	// List user invitations
+	router.HandleFunc(
+		"/v2/user-invitations",
+		u.TokenAuthFilter.Decorate(u.list),
+	).Methods(http.MethodGet)
+
+	// Delete user invitation
+	router.HandleFunc(
+		"/v2/user-invitations/{id}",
+		u.TokenAuthFilter.Decorate(u.delete),
+	).Methods(http.MethodDelete)
+}
+
+func (u *UserInvitationsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/user-invitations",
+			Summary:       "Invite a new user by email address",
+			RequestSchema: "user-invitation.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v2/user-invitations",
+			Summary:     "List outstanding user invitations",
+			QueryParams: restmachinery.ListQueryParams(),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/user-invitations/{id}",
+			Summary: "Withdraw a user invitation",
+		},
+	}
+}
+
+func (u *UserInvitationsEndpoints) create(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	userInvitation := authx.UserInvitation{}
+	u.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: u.UserInvitationSchemaLoader,
+			ReqBodyObj:          &userInvitation,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, u.Service.Create(r.Context(), userInvitation)
+			},
+			SuccessCode: http.StatusCreated,
+		},
+	)
+}
+
+func (u *UserInvitationsEndpoints) list(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	opts := meta.ListOptions{
+		Continue: r.URL.Query().Get("continue"),
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if opts.Limit, err = strconv.ParseInt(limitStr, 10, 64); err != nil ||
+			opts.Limit < 1 || opts.Limit > 100 {
+			u.WriteAPIResponse(
+				w,
+				http.StatusBadRequest,
+				&meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for "limit" query parameter`,
+						limitStr,
+					),
+				},
+			)
+			return
+		}
+	}
+	u.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return u.Service.List(r.Context(), opts)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (u *UserInvitationsEndpoints) delete(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	u.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return nil, u.Service.Delete(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
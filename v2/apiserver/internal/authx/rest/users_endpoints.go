@@ -42,7 +42,44 @@ func (u *UsersEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodDelete)
 }
 
+func (u *UsersEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/users",
+			Summary: "List users",
+			QueryParams: restmachinery.ListQueryParams(
+				restmachinery.LabelsQueryParam,
+			),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/users/{id}",
+			Summary: "Get a user",
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/v2/users/{id}/lock",
+			Summary: "Lock a user out of the system",
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/users/{id}/lock",
+			Summary: "Restore a locked user's access to the system",
+		},
+	}
+}
+
 func (u *UsersEndpoints) list(w http.ResponseWriter, r *http.Request) {
+	selector := authx.UsersSelector{}
+	if labelsStr := r.URL.Query().Get("labels"); labelsStr != "" {
+		var err error
+		if selector.LabelSelector, err =
+			meta.ParseLabelSelector(labelsStr); err != nil {
+			u.WriteAPIResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
 	opts := meta.ListOptions{
 		Continue: r.URL.Query().Get("continue"),
 	}
@@ -71,7 +108,7 @@ func (u *UsersEndpoints) list(w http.ResponseWriter, r *http.Request) {
 			W: w,
 			R: r,
 			EndpointLogic: func() (interface{}, error) {
-				return u.Service.List(r.Context(), opts)
+				return u.Service.List(r.Context(), selector, opts)
 			},
 			SuccessCode: http.StatusOK,
 		},
@@ -0,0 +1,359 @@
+package authx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+)
+
+// RoleDefinitionList is an ordered list of RoleDefinitions.
+type RoleDefinitionList struct {
+	// ListMeta contains list metadata.
+	meta.ListMeta `json:"metadata"`
+	// Items is a slice of RoleDefinitions.
+	Items []RoleDefinition `json:"items,omitempty"`
+}
+
+// MarshalJSON amends RoleDefinitionList instances with type metadata.
+func (r RoleDefinitionList) MarshalJSON() ([]byte, error) {
+	type Alias RoleDefinitionList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "RoleDefinitionList",
+			},
+			Alias: (Alias)(r),
+		},
+	)
+}
+
+// RoleDefinition defines a project-level Role as a bundle of Permissions. A
+// RoleDefinition's ID is the name of the Role it defines. Built-in
+// project-level Roles have fixed definitions. Custom project-level Roles are
+// defined by administrators and, once defined, may be granted for any Project
+// just like the built-in ones.
+type RoleDefinition struct {
+	// ObjectMeta encapsulates RoleDefinition metadata.
+	meta.ObjectMeta `json:"metadata" bson:",inline"`
+	// Description is a natural language description of the Role's purpose.
+	Description string `json:"description,omitempty" bson:"description,omitempty"` // nolint: lll
+	// Permissions enumerates the Permissions granted by the Role.
+	Permissions []Permission `json:"permissions" bson:"permissions"`
+	// BuiltIn indicates whether the RoleDefinition is for a built-in Role. Such
+	// RoleDefinitions cannot be modified or deleted.
+	BuiltIn bool `json:"builtIn,omitempty" bson:"-"`
+}
+
+// MarshalJSON amends RoleDefinition instances with type metadata.
+func (r RoleDefinition) MarshalJSON() ([]byte, error) {
+	type Alias RoleDefinition
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "RoleDefinition",
+			},
+			Alias: (Alias)(r),
+		},
+	)
+}
+
+// builtInRoleDefinition returns a RoleDefinition for the built-in
+// project-level Role having the specified name. The bool return value
+// indicates whether such a Role exists.
+func builtInRoleDefinition(name RoleName) (RoleDefinition, bool) {
+	permissions, ok := builtInProjectRolePermissions[name]
+	if !ok {
+		return RoleDefinition{}, false
+	}
+	return RoleDefinition{
+		ObjectMeta: meta.ObjectMeta{
+			ID: string(name),
+		},
+		Permissions: permissions,
+		BuiltIn:     true,
+	}, true
+}
+
+// isBuiltInRoleName returns a bool indicating whether the provided RoleName is
+// the name of any built-in Role, project-level or otherwise.
+func isBuiltInRoleName(name RoleName) bool {
+	switch name {
+	case RoleNameAdmin, RoleNameEventCreator, RoleNameProjectAdmin,
+		RoleNameProjectCreator, RoleNameProjectDeveloper, RoleNameProjectUser,
+		RoleNameReader, RoleNameObserver, RoleNameScheduler, RoleNameWorker:
+		return true
+	}
+	return false
+}
+
+// RoleDefinitionsService is the specialized interface for managing
+// RoleDefinitions. It's decoupled from underlying technology choices (e.g.
+// data store) to keep business logic reusable and consistent while the
+// underlying tech stack remains free to change.
+type RoleDefinitionsService interface {
+	// Create defines a new custom project-level Role. If a built-in Role or
+	// another custom Role having the same name already exists, implementations
+	// MUST return a *meta.ErrConflict error.
+	Create(context.Context, RoleDefinition) error
+	// List returns a RoleDefinitionList containing definitions for all built-in
+	// project-level Roles followed by all custom project-level Roles, ordered by
+	// name. The list is not paginated.
+	List(context.Context) (RoleDefinitionList, error)
+	// Get retrieves a single RoleDefinition specified by its identifier, which
+	// may be the name of a built-in or a custom project-level Role. If no such
+	// Role exists, implementations MUST return a *meta.ErrNotFound error.
+	Get(context.Context, string) (RoleDefinition, error)
+	// Update updates the description and Permissions of an existing custom
+	// project-level Role. If the specified Role does not exist, implementations
+	// MUST return a *meta.ErrNotFound error. If the specified Role is built-in,
+	// implementations MUST return a *meta.ErrConflict error.
+	Update(context.Context, RoleDefinition) error
+	// Delete deletes a single custom project-level Role specified by its
+	// identifier. If the specified Role does not exist, implementations MUST
+	// return a *meta.ErrNotFound error. If the specified Role is built-in or is
+	// still assigned to any principal, implementations MUST return a
+	// *meta.ErrConflict error.
+	Delete(context.Context, string) error
+}
+
+type roleDefinitionsService struct {
+	authorize            AuthorizeFn
+	roleDefinitionsStore RoleDefinitionsStore
+	rolesStore           RolesStore
+}
+
+// NewRoleDefinitionsService returns a specialized interface for managing
+// RoleDefinitions.
+func NewRoleDefinitionsService(
+	roleDefinitionsStore RoleDefinitionsStore,
+	rolesStore RolesStore,
+) RoleDefinitionsService {
+	return &roleDefinitionsService{
+		authorize:            Authorize,
+		roleDefinitionsStore: roleDefinitionsStore,
+		rolesStore:           rolesStore,
+	}
+}
+
+func (r *roleDefinitionsService) Create(
+	ctx context.Context,
+	roleDefinition RoleDefinition,
+) error {
+	if err := r.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	if isBuiltInRoleName(RoleName(roleDefinition.ID)) {
+		return &meta.ErrConflict{
+			Type: "RoleDefinition",
+			ID:   roleDefinition.ID,
+			Reason: fmt.Sprintf(
+				"A built-in role named %q already exists.",
+				roleDefinition.ID,
+			),
+		}
+	}
+	if err := validatePermissions(roleDefinition.Permissions); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	roleDefinition.Created = &now
+	roleDefinition.LastUpdated = &now
+	roleDefinition.CreatedBy = PrincipalReferenceFromContext(ctx)
+	roleDefinition.BuiltIn = false
+	if err := r.roleDefinitionsStore.Create(ctx, roleDefinition); err != nil {
+		return errors.Wrapf(
+			err,
+			"error storing new role definition %q",
+			roleDefinition.ID,
+		)
+	}
+	return nil
+}
+
+func (r *roleDefinitionsService) List(
+	ctx context.Context,
+) (RoleDefinitionList, error) {
+	roleDefinitions := RoleDefinitionList{}
+
+	if err := r.authorize(ctx, RoleReader()); err != nil {
+		return roleDefinitions, err
+	}
+
+	builtInNames := make([]string, 0, len(builtInProjectRolePermissions))
+	for name := range builtInProjectRolePermissions {
+		builtInNames = append(builtInNames, string(name))
+	}
+	sort.Strings(builtInNames)
+	for _, name := range builtInNames {
+		roleDefinition, _ := builtInRoleDefinition(RoleName(name))
+		roleDefinitions.Items = append(roleDefinitions.Items, roleDefinition)
+	}
+
+	customRoleDefinitions, err := r.roleDefinitionsStore.List(ctx)
+	if err != nil {
+		return roleDefinitions,
+			errors.Wrap(err, "error retrieving role definitions from store")
+	}
+	roleDefinitions.Items =
+		append(roleDefinitions.Items, customRoleDefinitions.Items...)
+
+	return roleDefinitions, nil
+}
+
+func (r *roleDefinitionsService) Get(
+	ctx context.Context,
+	id string,
+) (RoleDefinition, error) {
+	if err := r.authorize(ctx, RoleReader()); err != nil {
+		return RoleDefinition{}, err
+	}
+
+	if roleDefinition, ok := builtInRoleDefinition(RoleName(id)); ok {
+		return roleDefinition, nil
+	}
+	roleDefinition, err := r.roleDefinitionsStore.Get(ctx, id)
+	if err != nil {
+		return roleDefinition, errors.Wrapf(
+			err,
+			"error retrieving role definition %q from store",
+			id,
+		)
+	}
+	return roleDefinition, nil
+}
+
+func (r *roleDefinitionsService) Update(
+	ctx context.Context,
+	roleDefinition RoleDefinition,
+) error {
+	if err := r.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	if isBuiltInRoleName(RoleName(roleDefinition.ID)) {
+		return &meta.ErrConflict{
+			Type:   "RoleDefinition",
+			ID:     roleDefinition.ID,
+			Reason: "Built-in roles cannot be modified.",
+		}
+	}
+	if err := validatePermissions(roleDefinition.Permissions); err != nil {
+		return err
+	}
+
+	if err := r.roleDefinitionsStore.Update(ctx, roleDefinition); err != nil {
+		return errors.Wrapf(
+			err,
+			"error updating role definition %q in store",
+			roleDefinition.ID,
+		)
+	}
+	return nil
+}
+
+func (r *roleDefinitionsService) Delete(ctx context.Context, id string) error {
+	if err := r.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	if isBuiltInRoleName(RoleName(id)) {
+		return &meta.ErrConflict{
+			Type:   "RoleDefinition",
+			ID:     id,
+			Reason: "Built-in roles cannot be deleted.",
+		}
+	}
+
+	// Don't leave principals holding a Role that no longer means anything
+	roleAssignments, err := r.rolesStore.List(
+		ctx,
+		RoleAssignmentsSelector{
+			RoleType: RoleTypeProject,
+			Role:     RoleName(id),
+		},
+		// One assignment is enough to know how many there are in all
+		meta.ListOptions{Limit: 1},
+	)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"error retrieving assignments of role %q from store",
+			id,
+		)
+	}
+	if assigned := int64(len(roleAssignments.Items)) +
+		roleAssignments.RemainingItemCount; assigned > 0 {
+		return &meta.ErrConflict{
+			Type: "RoleDefinition",
+			ID:   id,
+			Reason: fmt.Sprintf(
+				"Role %q is still assigned to %d principal(s). Revoke all "+
+					"assignments of the role before deleting it.",
+				id,
+				assigned,
+			),
+		}
+	}
+
+	if err := r.roleDefinitionsStore.Delete(ctx, id); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing role definition %q from store",
+			id,
+		)
+	}
+	return nil
+}
+
+// validatePermissions returns a *meta.ErrBadRequest error if any of the
+// provided Permissions is unknown.
+func validatePermissions(permissions []Permission) error {
+	for _, permission := range permissions {
+		if !isKnownPermission(permission) {
+			return &meta.ErrBadRequest{
+				Reason: fmt.Sprintf("Unknown permission %q.", permission),
+			}
+		}
+	}
+	return nil
+}
+
+// RoleDefinitionsStore is an interface for components that implement
+// persistence concerns for the RoleDefinitions of custom project-level Roles.
+type RoleDefinitionsStore interface {
+	// Create persists a new RoleDefinition in the underlying data store. If a
+	// RoleDefinition having the same ID already exists, implementations MUST
+	// return a *meta.ErrConflict error.
+	Create(context.Context, RoleDefinition) error
+	// List retrieves all RoleDefinitions from the underlying data store, ordered
+	// by ID.
+	List(context.Context) (RoleDefinitionList, error)
+	// Get retrieves a single RoleDefinition from the underlying data store. If
+	// the specified RoleDefinition does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Get(context.Context, string) (RoleDefinition, error)
+	// Update updates the description and Permissions of the specified
+	// RoleDefinition in the underlying data store. If the specified
+	// RoleDefinition does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Update(context.Context, RoleDefinition) error
+	// Delete deletes the specified RoleDefinition from the underlying data
+	// store. If the specified RoleDefinition does not exist, implementations
+	// MUST return a *meta.ErrNotFound error.
+	Delete(context.Context, string) error
+}
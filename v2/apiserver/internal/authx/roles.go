@@ -1,6 +1,11 @@
 package authx
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+)
 
 // RoleName is a type whose value maps to a well-defined Brigade Role.
 type RoleName string
@@ -95,6 +100,55 @@ type RoleAssignment struct {
 	PrincipalID string `json:"principalID"`
 }
 
+// RoleAssignmentsSelector represents useful filter criteria when selecting
+// multiple RoleAssignments for API group operations like list. Each non-empty
+// field narrows the selection.
+type RoleAssignmentsSelector struct {
+	// PrincipalType specifies that only RoleAssignments for principals of this
+	// type should be selected.
+	PrincipalType PrincipalType
+	// PrincipalID specifies that only RoleAssignments for principals having
+	// this ID should be selected.
+	PrincipalID string
+	// RoleType specifies that only RoleAssignments for Roles of this type (e.g.
+	// system-level or project-level) should be selected.
+	RoleType RoleType
+	// Role specifies that only RoleAssignments for Roles having this name
+	// should be selected.
+	Role RoleName
+	// Scope specifies that only RoleAssignments for Roles having this scope
+	// should be selected.
+	Scope string
+	// IncludeGlobalScope specifies that, if Scope is non-empty,
+	// RoleAssignments for Roles having global scope should also be selected.
+	IncludeGlobalScope bool
+}
+
+// RoleAssignmentList is an ordered list of RoleAssignments.
+type RoleAssignmentList struct {
+	// ListMeta contains list metadata.
+	meta.ListMeta `json:"metadata"`
+	// Items is a slice of RoleAssignments.
+	Items []RoleAssignment `json:"items,omitempty"`
+}
+
+// MarshalJSON amends RoleAssignmentList instances with type metadata.
+func (r RoleAssignmentList) MarshalJSON() ([]byte, error) {
+	type Alias RoleAssignmentList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "RoleAssignmentList",
+			},
+			Alias: (Alias)(r),
+		},
+	)
+}
+
 // RoleAdmin returns a Role that enables a principal to manage Users,
 // ServiceAccounts, and globally scoped permissions for Users and
 // ServiceAccounts.
@@ -216,6 +270,30 @@ func RoleWorker(eventID string) Role {
 	}
 }
 
+// unionRoles returns all Roles from the provided slices, without duplicates.
+func unionRoles(roleSets ...[]Role) []Role {
+	var roles []Role
+	for _, roleSet := range roleSets {
+		for _, role := range roleSet {
+			if !containsRole(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// containsRole returns a bool indicating whether the provided Roles include
+// one exactly equal to the provided Role.
+func containsRole(roles []Role, role Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type RolesStore interface {
 	Grant(
 		ctx context.Context,
@@ -229,4 +307,24 @@ type RolesStore interface {
 		principalID string,
 		roles ...Role,
 	) error
+	// List returns a RoleAssignmentList, with its Items (RoleAssignments)
+	// grouped by principal type and ordered by principal ID, role, and scope,
+	// satisfying the criteria specified by the RoleAssignmentsSelector. A Limit
+	// of zero in the provided meta.ListOptions retrieves all RoleAssignments
+	// without pagination.
+	List(
+		context.Context,
+		RoleAssignmentsSelector,
+		meta.ListOptions,
+	) (RoleAssignmentList, error)
+	// ListForGroups returns all Roles that have been granted to any of the
+	// specified groups, without duplicates.
+	ListForGroups(ctx context.Context, groups ...string) ([]Role, error)
+	// ListForTeamMember returns all Roles that have been granted to any Team the
+	// specified principal is a member of, without duplicates.
+	ListForTeamMember(
+		ctx context.Context,
+		principalType PrincipalType,
+		principalID string,
+	) ([]Role, error)
 }
@@ -3,6 +3,7 @@ package authx
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
@@ -10,6 +11,14 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ServiceAccountsSelector represents useful filter criteria when selecting
+// multiple ServiceAccounts for API group operations like list.
+type ServiceAccountsSelector struct {
+	// LabelSelector specifies requirements that a ServiceAccount's labels must
+	// satisfy for the ServiceAccount to be selected.
+	LabelSelector meta.LabelSelector
+}
+
 // ServiceAccountList is an ordered and pageable list of ServiceAccounts.
 type ServiceAccountList struct {
 	// ListMeta contains list metadata.
@@ -45,6 +54,19 @@ type ServiceAccount struct {
 	Description string `json:"description" bson:"description"`
 	// HashedToken is a secure, one-way hash of the ServiceAccount's token.
 	HashedToken string `json:"-" bson:"hashedToken"`
+	// TokenCreated indicates when the ServiceAccount's current token was issued.
+	TokenCreated *time.Time `json:"tokenCreated,omitempty" bson:"tokenCreated,omitempty"` // nolint: lll
+	// TokenExpires indicates when the ServiceAccount's current token expires. If
+	// this field's value is nil, the token never expires.
+	TokenExpires *time.Time `json:"tokenExpires,omitempty" bson:"tokenExpires,omitempty"` // nolint: lll
+	// PreviousHashedToken is a secure, one-way hash of the token that the
+	// ServiceAccount's current token replaced. Following a token rotation, the
+	// previous token remains valid until PreviousTokenExpires so that clients
+	// can be migrated to the new token without interruption.
+	PreviousHashedToken string `json:"-" bson:"previousHashedToken,omitempty"`
+	// PreviousTokenExpires indicates when the ServiceAccount's previous token
+	// expires.
+	PreviousTokenExpires *time.Time `json:"previousTokenExpires,omitempty" bson:"previousTokenExpires,omitempty"` // nolint: lll
 	// Locked indicates when the ServiceAccount has been locked out of the system
 	// by an administrator. If this field's value is nil, the ServiceAccount is
 	// not locked.
@@ -52,12 +74,17 @@ type ServiceAccount struct {
 	// ServiceAccountRoles is a slice of Roles (both system-level and
 	// project-level) assigned to this ServiceAccount.
 	ServiceAccountRoles []Role `json:"roles,omitempty" bson:"roles,omitempty"`
+	// TeamRoles enumerates Roles the ServiceAccount holds by virtue of belonging
+	// to one or more Teams. These are never persisted with the ServiceAccount.
+	// Rather, they are evaluated whenever the ServiceAccount is retrieved so
+	// that changes to Team membership take effect immediately.
+	TeamRoles []Role `json:"teamRoles,omitempty" bson:"-"`
 }
 
 // Roles returns a slice of Roles (both system-level and project-level) assigned
-// to this ServiceAccount.
+// to this ServiceAccount, either directly or by virtue of Team membership.
 func (s *ServiceAccount) Roles() []Role {
-	return s.ServiceAccountRoles
+	return unionRoles(s.ServiceAccountRoles, s.TeamRoles)
 }
 
 // MarshalJSON amends ServiceAccount instances with type metadata.
@@ -77,6 +104,18 @@ func (s ServiceAccount) MarshalJSON() ([]byte, error) {
 	)
 }
 
+// TokenRotationOptions represents options for rotating a ServiceAccount's
+// token.
+type TokenRotationOptions struct {
+	// GracePeriod specifies, as a duration string (e.g. "24h"), how long the
+	// ServiceAccount's existing token should remain valid after the new token is
+	// issued. If unspecified, the existing token is invalidated immediately.
+	GracePeriod string `json:"gracePeriod,omitempty"`
+	// Expires optionally specifies when the new token should expire. If
+	// unspecified, the new token never expires.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
 // ServiceAccountsService is the specialized interface for managing
 // ServiceAccounts. It's decoupled from underlying technology choices (e.g. data
 // store) to keep business logic reusable and consistent while the underlying
@@ -85,8 +124,14 @@ type ServiceAccountsService interface {
 	// Create creates a new ServiceAccount. If a ServiceAccount having the same ID
 	// already exists, implementations MUST return a *meta.ErrConflict error.
 	Create(context.Context, ServiceAccount) (Token, error)
-	// List retrieves a ServiceAccountList.
-	List(context.Context, meta.ListOptions) (ServiceAccountList, error)
+	// List retrieves a ServiceAccountList, with its Items (ServiceAccounts)
+	// ordered by age, oldest first. Criteria for which ServiceAccounts should be
+	// retrieved can be specified using the ServiceAccountsSelector parameter.
+	List(
+		context.Context,
+		ServiceAccountsSelector,
+		meta.ListOptions,
+	) (ServiceAccountList, error)
 	// Get retrieves a single ServiceAccount specified by its identifier. If the
 	// specified ServiceAccount does not exist, implementations MUST return a
 	// *meta.ErrNotFound error.
@@ -105,21 +150,33 @@ type ServiceAccountsService interface {
 	// If the specified ServiceAccount does not exist, implementations MUST return
 	// a *meta.ErrNotFound error.
 	Unlock(context.Context, string) (Token, error)
+	// RotateToken issues a new Token for a single ServiceAccount specified by its
+	// identifier. Per the provided TokenRotationOptions, the ServiceAccount's
+	// existing token may remain valid for a grace period. If the specified
+	// ServiceAccount does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	RotateToken(context.Context, string, TokenRotationOptions) (Token, error)
 }
 
 type serviceAccountsService struct {
 	authorize            AuthorizeFn
 	serviceAccountsStore ServiceAccountsStore
+	rolesStore           RolesStore
+	tokenHasher          crypto.TokenHasher
 }
 
 // NewServiceAccountsService returns a specialized interface for managing
 // ServiceAccounts.
 func NewServiceAccountsService(
 	serviceAccountsStore ServiceAccountsStore,
+	rolesStore RolesStore,
+	tokenHasher crypto.TokenHasher,
 ) ServiceAccountsService {
 	return &serviceAccountsService{
 		authorize:            Authorize,
 		serviceAccountsStore: serviceAccountsStore,
+		rolesStore:           rolesStore,
+		tokenHasher:          tokenHasher,
 	}
 }
 
@@ -132,11 +189,22 @@ func (s *serviceAccountsService) Create(
 	}
 
 	token := Token{
-		Value: crypto.NewToken(256),
+		Value: crypto.NewTypedToken(crypto.TokenTypeServiceAccount),
 	}
 	now := time.Now()
 	serviceAccount.Created = &now
-	serviceAccount.HashedToken = crypto.ShortSHA("", token.Value)
+	serviceAccount.LastUpdated = &now
+	serviceAccount.CreatedBy = PrincipalReferenceFromContext(ctx)
+	if serviceAccount.TokenExpires != nil &&
+		!serviceAccount.TokenExpires.After(now) {
+		return Token{}, &meta.ErrBadRequest{
+			Reason: "Token expiry must be in the future.",
+		}
+	}
+	serviceAccount.HashedToken = s.tokenHasher.Hash(token.Value)
+	serviceAccount.TokenCreated = &now
+	serviceAccount.PreviousHashedToken = ""
+	serviceAccount.PreviousTokenExpires = nil
 	if err := s.serviceAccountsStore.Create(ctx, serviceAccount); err != nil {
 		return token, errors.Wrapf(
 			err,
@@ -149,6 +217,7 @@ func (s *serviceAccountsService) Create(
 
 func (s *serviceAccountsService) List(
 	ctx context.Context,
+	selector ServiceAccountsSelector,
 	opts meta.ListOptions,
 ) (ServiceAccountList, error) {
 	if err := s.authorize(ctx, RoleReader()); err != nil {
@@ -158,7 +227,7 @@ func (s *serviceAccountsService) List(
 	if opts.Limit == 0 {
 		opts.Limit = 20
 	}
-	serviceAccounts, err := s.serviceAccountsStore.List(ctx, opts)
+	serviceAccounts, err := s.serviceAccountsStore.List(ctx, selector, opts)
 	if err != nil {
 		return serviceAccounts,
 			errors.Wrap(err, "error retrieving service accounts from store")
@@ -182,6 +251,17 @@ func (s *serviceAccountsService) Get(
 			id,
 		)
 	}
+	if serviceAccount.TeamRoles, err = s.rolesStore.ListForTeamMember(
+		ctx,
+		PrincipalTypeServiceAccount,
+		id,
+	); err != nil {
+		return serviceAccount, errors.Wrapf(
+			err,
+			"error retrieving roles for teams of service account %q from store",
+			id,
+		)
+	}
 	return serviceAccount, nil
 }
 
@@ -193,9 +273,32 @@ func (s *serviceAccountsService) GetByToken(
 	// No authz requirements here because this is is never invoked at the explicit
 	// request of an end user; rather it is invoked only by the system itself.
 
-	serviceAccount, err := s.serviceAccountsStore.GetByHashedToken(
-		ctx,
-		crypto.ShortSHA("", token),
+	var serviceAccount ServiceAccount
+	hashedToken, err := crypto.LookupByHashedToken(
+		s.tokenHasher,
+		token,
+		func(hashedToken string) (err error) {
+			serviceAccount, err =
+				s.serviceAccountsStore.GetByHashedToken(ctx, hashedToken)
+			return err
+		},
+		func(legacyHashedToken, hashedToken string) error {
+			if err := s.serviceAccountsStore.RehashToken(
+				ctx,
+				legacyHashedToken,
+				hashedToken,
+			); err != nil {
+				return errors.Wrapf(
+					err,
+					"error rehashing token for service account %q in store",
+					serviceAccount.ID,
+				)
+			}
+			if serviceAccount.HashedToken == legacyHashedToken {
+				serviceAccount.HashedToken = hashedToken
+			}
+			return nil
+		},
 	)
 	if err != nil {
 		return serviceAccount, errors.Wrap(
@@ -203,6 +306,31 @@ func (s *serviceAccountsService) GetByToken(
 			"error retrieving service account from store by hashed token",
 		)
 	}
+	expires := serviceAccount.TokenExpires
+	if hashedToken != serviceAccount.HashedToken {
+		// The token matched the ServiceAccount's previous token
+		expires = serviceAccount.PreviousTokenExpires
+	}
+	if expires != nil && time.Now().After(*expires) {
+		return serviceAccount, &meta.ErrAuthentication{
+			Reason: fmt.Sprintf(
+				"Supplied token for service account %q expired at %s.",
+				serviceAccount.ID,
+				expires.UTC().Format(time.RFC3339),
+			),
+		}
+	}
+	if serviceAccount.TeamRoles, err = s.rolesStore.ListForTeamMember(
+		ctx,
+		PrincipalTypeServiceAccount,
+		serviceAccount.ID,
+	); err != nil {
+		return serviceAccount, errors.Wrapf(
+			err,
+			"error retrieving roles for teams of service account %q from store",
+			serviceAccount.ID,
+		)
+	}
 	return serviceAccount, nil
 }
 
@@ -230,12 +358,12 @@ func (s *serviceAccountsService) Unlock(
 	}
 
 	newToken := Token{
-		Value: crypto.NewToken(256),
+		Value: crypto.NewTypedToken(crypto.TokenTypeServiceAccount),
 	}
 	if err := s.serviceAccountsStore.Unlock(
 		ctx,
 		id,
-		crypto.ShortSHA("", newToken.Value),
+		s.tokenHasher.Hash(newToken.Value),
 	); err != nil {
 		return newToken, errors.Wrapf(
 			err,
@@ -246,6 +374,88 @@ func (s *serviceAccountsService) Unlock(
 	return newToken, nil
 }
 
+func (s *serviceAccountsService) RotateToken(
+	ctx context.Context,
+	id string,
+	opts TokenRotationOptions,
+) (Token, error) {
+	if err := s.authorize(ctx, RoleAdmin()); err != nil {
+		return Token{}, err
+	}
+
+	var gracePeriod time.Duration
+	if opts.GracePeriod != "" {
+		var err error
+		if gracePeriod, err = time.ParseDuration(opts.GracePeriod); err != nil ||
+			gracePeriod < 0 {
+			return Token{}, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf(
+					"Grace period %q is not a valid, non-negative duration.",
+					opts.GracePeriod,
+				),
+			}
+		}
+	}
+	now := time.Now()
+	if opts.Expires != nil && !opts.Expires.After(now) {
+		return Token{}, &meta.ErrBadRequest{
+			Reason: "Token expiry must be in the future.",
+		}
+	}
+
+	serviceAccount, err := s.serviceAccountsStore.Get(ctx, id)
+	if err != nil {
+		return Token{}, errors.Wrapf(
+			err,
+			"error retrieving service account %q from store",
+			id,
+		)
+	}
+	if serviceAccount.Locked != nil {
+		return Token{}, &meta.ErrConflict{
+			Type: "ServiceAccount",
+			ID:   id,
+			Reason: fmt.Sprintf(
+				"Service account %q is locked. Unlock it to obtain a new token.",
+				id,
+			),
+		}
+	}
+	expectedHashedToken := serviceAccount.HashedToken
+
+	newToken := Token{
+		Value: crypto.NewTypedToken(crypto.TokenTypeServiceAccount),
+	}
+	serviceAccount.PreviousHashedToken = ""
+	serviceAccount.PreviousTokenExpires = nil
+	if gracePeriod > 0 {
+		previousTokenExpires := now.Add(gracePeriod)
+		// A grace period never extends the life of the existing token
+		if serviceAccount.TokenExpires != nil &&
+			serviceAccount.TokenExpires.Before(previousTokenExpires) {
+			previousTokenExpires = *serviceAccount.TokenExpires
+		}
+		serviceAccount.PreviousHashedToken = serviceAccount.HashedToken
+		serviceAccount.PreviousTokenExpires = &previousTokenExpires
+	}
+	serviceAccount.HashedToken = s.tokenHasher.Hash(newToken.Value)
+	serviceAccount.TokenCreated = &now
+	serviceAccount.TokenExpires = opts.Expires
+
+	if err = s.serviceAccountsStore.UpdateToken(
+		ctx,
+		serviceAccount,
+		expectedHashedToken,
+	); err != nil {
+		return Token{}, errors.Wrapf(
+			err,
+			"error updating token for service account %q in the store",
+			id,
+		)
+	}
+	return newToken, nil
+}
+
 // ServiceAccountsStore is an interface for components that implement
 // ServiceAccount persistence concerns.
 type ServiceAccountsStore interface {
@@ -254,8 +464,12 @@ type ServiceAccountsStore interface {
 	// return a *meta.ErrConflict error.
 	Create(context.Context, ServiceAccount) error
 	// List retrieves a ServiceAccountList from the underlying data store, with
-	// its Items (ServiceAccounts) ordered by ID.
-	List(context.Context, meta.ListOptions) (ServiceAccountList, error)
+	// its Items (ServiceAccounts) ordered by age, oldest first.
+	List(
+		context.Context,
+		ServiceAccountsSelector,
+		meta.ListOptions,
+	) (ServiceAccountList, error)
 	// Get retrieves a single ServiceAccount from the underlying data store. If
 	// the specified ServiceAccount does not exist, implementations MUST return
 	// a *meta.ErrNotFound error.
@@ -276,4 +490,25 @@ type ServiceAccountsStore interface {
 	// existing token. If the specified ServiceAccount does not exist,
 	// implementations MUST return a *meta.ErrNotFound error.
 	Unlock(ctx context.Context, id string, newHashedToken string) error
+	// UpdateToken updates the token-related fields (HashedToken, TokenCreated,
+	// TokenExpires, PreviousHashedToken, and PreviousTokenExpires) of the
+	// provided ServiceAccount in the underlying data store, but only if the
+	// stored ServiceAccount's hashed token still matches the expected hashed
+	// token. If the specified ServiceAccount does not exist, implementations
+	// MUST return a *meta.ErrNotFound error. If the stored hashed token does not
+	// match, implementations MUST return a *meta.ErrConflict error.
+	UpdateToken(
+		ctx context.Context,
+		serviceAccount ServiceAccount,
+		expectedHashedToken string,
+	) error
+	// RehashToken replaces the specified legacy hash of a ServiceAccount's
+	// current or previous token with a new hash of the same token in the
+	// underlying data store. If no ServiceAccount has a token with the specified
+	// legacy hash, this is a no-op.
+	RehashToken(
+		ctx context.Context,
+		legacyHashedToken string,
+		hashedToken string,
+	) error
 }
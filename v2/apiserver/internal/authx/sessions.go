@@ -3,11 +3,13 @@ package authx
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/oidc"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
-	"github.com/coreos/go-oidc"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"golang.org/x/oauth2"
@@ -34,10 +36,15 @@ type OIDCAuthDetails struct {
 	// Token is an opaque bearer token issued by Brigade to correlate a User with
 	// a Session. It remains unactivated (useless) until the OIDC authentication
 	// workflow is successfully completed. Clients may expect that that the token
-	// expires (at an interval determined by a system administrator) and, for
-	// simplicity, is NOT refreshable. When the token has expired, it
-	// re-authentication is required.
+	// expires (at an interval determined by a system administrator).
 	Token string `json:"token"`
+	// RefreshToken is an opaque token issued by Brigade that, once the OIDC
+	// authentication workflow is successfully completed, can be exchanged for a
+	// new Token (and a new RefreshToken) without repeating the OIDC
+	// authentication workflow. Refresh tokens also expire (at a longer interval
+	// determined by a system administrator), after which re-authentication is
+	// required.
+	RefreshToken string `json:"refreshToken"`
 }
 
 // MarshalJSON amends OIDCAuthDetails instances with type metadata.
@@ -57,20 +64,73 @@ func (o OIDCAuthDetails) MarshalJSON() ([]byte, error) {
 	)
 }
 
+// SessionsSelector represents useful filter criteria when selecting multiple
+// Sessions for API group operations like list.
+type SessionsSelector struct {
+	// UserID specifies that only Sessions belonging to the User having this ID
+	// should be selected. If unspecified, only Sessions belonging to the
+	// principal making the request are selected, unless AllUsers is true.
+	UserID string
+	// AllUsers specifies that the Sessions of all Users (and the root user)
+	// should be selected.
+	AllUsers bool
+}
+
+// SessionList is an ordered and pageable list of Sessions.
+type SessionList struct {
+	// ListMeta contains list metadata.
+	meta.ListMeta `json:"metadata"`
+	// Items is a slice of Sessions.
+	Items []Session `json:"items,omitempty"`
+}
+
+// MarshalJSON amends SessionList instances with type metadata.
+func (s SessionList) MarshalJSON() ([]byte, error) {
+	type Alias SessionList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "SessionList",
+			},
+			Alias: (Alias)(s),
+		},
+	)
+}
+
 type Session struct {
-	meta.TypeMeta     `json:",inline" bson:",inline"`
-	meta.ObjectMeta   `json:"metadata" bson:",inline"`
-	Root              bool       `json:"root" bson:"root"`
-	UserID            string     `json:"userID" bson:"userID"`
-	HashedOAuth2State string     `json:"-" bson:"hashedOAuth2State"`
-	HashedToken       string     `json:"-" bson:"hashedToken"`
-	Authenticated     *time.Time `json:"authenticated" bson:"authenticated"`
-	Expires           *time.Time `json:"expires" bson:"expires"`
+	meta.TypeMeta       `json:",inline" bson:",inline"`
+	meta.ObjectMeta     `json:"metadata" bson:",inline"`
+	Root                bool       `json:"root" bson:"root"`
+	UserID              string     `json:"userID" bson:"userID"`
+	HashedOAuth2State   string     `json:"-" bson:"hashedOAuth2State"`
+	HashedToken         string     `json:"-" bson:"hashedToken"`
+	HashedRefreshToken  string     `json:"-" bson:"hashedRefreshToken,omitempty"`
+	Authenticated       *time.Time `json:"authenticated" bson:"authenticated"`
+	Expires             *time.Time `json:"expires" bson:"expires"`
+	RefreshTokenExpires *time.Time `json:"refreshTokenExpires,omitempty" bson:"refreshTokenExpires,omitempty"` // nolint: lll
+	// Current indicates whether this is the Session that was used to
+	// authenticate the request that retrieved it. It is never persisted.
+	Current bool `json:"current,omitempty" bson:"-"`
 }
 
-func NewRootSession(token string) Session {
+// NewRootSession returns a new, authenticated Session for the root user. The
+// Session's token expires after the specified TTL and its refresh token
+// expires after the specified refresh token TTL. Tokens are hashed using the
+// provided TokenHasher.
+func NewRootSession(
+	tokenHasher crypto.TokenHasher,
+	token string,
+	refreshToken string,
+	ttl time.Duration,
+	refreshTokenTTL time.Duration,
+) Session {
 	now := time.Now()
-	expiryTime := now.Add(time.Hour)
+	expiryTime := now.Add(ttl)
+	refreshTokenExpiryTime := now.Add(refreshTokenTTL)
 	return Session{
 		TypeMeta: meta.TypeMeta{
 			APIVersion: meta.APIVersion,
@@ -79,14 +139,25 @@ func NewRootSession(token string) Session {
 		ObjectMeta: meta.ObjectMeta{
 			ID: uuid.NewV4().String(),
 		},
-		Root:          true,
-		HashedToken:   crypto.ShortSHA("", token),
-		Authenticated: &now,
-		Expires:       &expiryTime,
+		Root:                true,
+		HashedToken:         tokenHasher.Hash(token),
+		HashedRefreshToken:  tokenHasher.Hash(refreshToken),
+		Authenticated:       &now,
+		Expires:             &expiryTime,
+		RefreshTokenExpires: &refreshTokenExpiryTime,
 	}
 }
 
-func NewUserSession(oauth2State, token string) Session {
+// NewUserSession returns a new, as-yet unauthenticated Session for a User. The
+// Session's token and refresh token are useless until the Session is
+// authenticated. The OAuth2 state and tokens are hashed using the provided
+// TokenHasher.
+func NewUserSession(
+	tokenHasher crypto.TokenHasher,
+	oauth2State string,
+	token string,
+	refreshToken string,
+) Session {
 	return Session{
 		TypeMeta: meta.TypeMeta{
 			APIVersion: meta.APIVersion,
@@ -95,8 +166,9 @@ func NewUserSession(oauth2State, token string) Session {
 		ObjectMeta: meta.ObjectMeta{
 			ID: uuid.NewV4().String(),
 		},
-		HashedOAuth2State: crypto.ShortSHA("", oauth2State),
-		HashedToken:       crypto.ShortSHA("", token),
+		HashedOAuth2State:  tokenHasher.Hash(oauth2State),
+		HashedToken:        tokenHasher.Hash(token),
+		HashedRefreshToken: tokenHasher.Hash(refreshToken),
 	}
 }
 
@@ -124,9 +196,9 @@ func SessionIDFromContext(ctx context.Context) string {
 type SessionsService interface {
 	// CreateRootSession creates a Session for the root user (if enabled by th
 	// system administrator) and returns a Token with a short expiry period
-	// (determined by a system administrator). If the specified username is not
-	// "root" or the specified password is incorrect, implementations MUST return
-	// a *meta.ErrAuthentication error.
+	// (determined by a system administrator), along with a refresh token. If the
+	// specified username is not "root" or the specified password is incorrect,
+	// implementations MUST return a *meta.ErrAuthentication error.
 	CreateRootSession(
 		ctx context.Context,
 		username string,
@@ -144,8 +216,11 @@ type SessionsService interface {
 	// user information. This information can be used to correlate the as-yet
 	// anonymous Session to an existing User. If the User is previously unknown to
 	// Brigade, one is seamlessly created (with read-only permissions) form the
-	// information provided by the identity provider. Finally, the Session's token
-	// is activated.
+	// information provided by the identity provider, provided the configured
+	// UserProvisioningPolicy permits it. If it does not, implementations MUST
+	// return a *meta.ErrAuthentication error. Either way, the User's group
+	// memberships are updated to reflect those asserted by the identity
+	// provider. Finally, the Session's token is activated.
 	Authenticate(
 		ctx context.Context,
 		oauth2State string,
@@ -155,6 +230,29 @@ type SessionsService interface {
 	// Session is found or is found but is expired, implementations MUST return a
 	// *meta.ErrAuthentication error.
 	GetByToken(ctx context.Context, token string) (Session, error)
+	// Refresh exchanges the provided refresh token for a new Token (along with a
+	// new refresh token) for the same Session. The exchanged refresh token and
+	// the Session's existing Token are invalidated. If no authenticated Session
+	// has the provided refresh token or if that refresh token has expired,
+	// implementations MUST return a *meta.ErrAuthentication error.
+	Refresh(ctx context.Context, refreshToken string) (Token, error)
+	// List retrieves a SessionList, with its Items (Sessions) ordered by age,
+	// oldest first. Only authenticated Sessions that have not yet expired and
+	// can still be refreshed are included. Criteria for which Sessions should be
+	// retrieved can be specified using the SessionsSelector parameter. Listing
+	// Sessions that do not belong to the principal making the request requires
+	// the Admin role.
+	List(
+		context.Context,
+		SessionsSelector,
+		meta.ListOptions,
+	) (SessionList, error)
+	// Revoke deletes the Session specified by its identifier, rendering its
+	// token and refresh token useless. Revoking a Session that does not belong to
+	// the principal making the request requires the Admin role. If the specified
+	// Session does not exist, implementations MUST return a *meta.ErrNotFound
+	// error.
+	Revoke(ctx context.Context, id string) error
 	// Delete deletes the specified Session.
 	Delete(ctx context.Context, id string) error
 }
@@ -163,28 +261,43 @@ type sessionsService struct {
 	authorize              AuthorizeFn
 	sessionsStore          SessionsStore
 	usersStore             UsersStore
+	userInvitationsStore   UserInvitationsStore
 	rootUserEnabled        bool
 	hashedRootUserPassword string
 	oauth2Config           *oauth2.Config
-	oidcTokenVerifier      *oidc.IDTokenVerifier
+	oidcIdentityVerifier   oidc.IdentityVerifier
+	sessionTTL             time.Duration
+	refreshTokenTTL        time.Duration
+	config                 Config
+	tokenHasher            crypto.TokenHasher
 }
 
 func NewSessionsService(
 	sessionsStore SessionsStore,
 	usersStore UsersStore,
+	userInvitationsStore UserInvitationsStore,
 	rootUserEnabled bool,
 	hashedRootUserPassword string,
 	oauth2Config *oauth2.Config,
-	oidcTokenVerifier *oidc.IDTokenVerifier,
+	oidcIdentityVerifier oidc.IdentityVerifier,
+	sessionTTL time.Duration,
+	refreshTokenTTL time.Duration,
+	config Config,
+	tokenHasher crypto.TokenHasher,
 ) SessionsService {
 	return &sessionsService{
 		authorize:              Authorize,
 		sessionsStore:          sessionsStore,
 		usersStore:             usersStore,
+		userInvitationsStore:   userInvitationsStore,
 		rootUserEnabled:        rootUserEnabled,
 		hashedRootUserPassword: hashedRootUserPassword,
 		oauth2Config:           oauth2Config,
-		oidcTokenVerifier:      oidcTokenVerifier,
+		oidcIdentityVerifier:   oidcIdentityVerifier,
+		sessionTTL:             sessionTTL,
+		refreshTokenTTL:        refreshTokenTTL,
+		config:                 config,
+		tokenHasher:            tokenHasher,
 	}
 }
 
@@ -194,7 +307,7 @@ func (s *sessionsService) CreateRootSession(
 	password string,
 ) (Token, error) {
 	token := Token{
-		Value: crypto.NewToken(256),
+		Value: crypto.NewTypedToken(crypto.TokenTypeSession),
 	}
 	if !s.rootUserEnabled {
 		return token, &meta.ErrNotSupported{
@@ -208,7 +321,14 @@ func (s *sessionsService) CreateRootSession(
 			Reason: "Could not authenticate request using the supplied credentials.",
 		}
 	}
-	session := NewRootSession(token.Value)
+	token.RefreshToken = crypto.NewTypedToken(crypto.TokenTypeRefresh)
+	session := NewRootSession(
+		s.tokenHasher,
+		token.Value,
+		token.RefreshToken,
+		s.sessionTTL,
+		s.refreshTokenTTL,
+	)
 	now := time.Now()
 	session.Created = &now
 	if err := s.sessionsStore.Create(ctx, session); err != nil {
@@ -225,12 +345,15 @@ func (s *sessionsService) CreateUserSession(
 	ctx context.Context,
 ) (OIDCAuthDetails, error) {
 	oidcAuthDetails := OIDCAuthDetails{
-		OAuth2State: crypto.NewToken(30),
-		Token:       crypto.NewToken(256),
+		OAuth2State:  crypto.NewToken(30),
+		Token:        crypto.NewTypedToken(crypto.TokenTypeSession),
+		RefreshToken: crypto.NewTypedToken(crypto.TokenTypeRefresh),
 	}
 	session := NewUserSession(
+		s.tokenHasher,
 		oidcAuthDetails.OAuth2State,
 		oidcAuthDetails.Token,
+		oidcAuthDetails.RefreshToken,
 	)
 	now := time.Now()
 	session.Created = &now
@@ -252,7 +375,7 @@ func (s *sessionsService) Authenticate(
 	oauth2State string,
 	oidcCode string,
 ) error {
-	if s.oauth2Config == nil || s.oidcTokenVerifier == nil {
+	if s.oauth2Config == nil || s.oidcIdentityVerifier == nil {
 		return &meta.ErrNotSupported{
 			Details: "Authentication using OpenID Connect is not supported by this " +
 				"server.",
@@ -260,7 +383,7 @@ func (s *sessionsService) Authenticate(
 	}
 	session, err := s.sessionsStore.GetByHashedOAuth2State(
 		ctx,
-		crypto.ShortSHA("", oauth2State),
+		s.tokenHasher.Hash(oauth2State),
 	)
 	if err != nil {
 		return errors.Wrap(
@@ -281,29 +404,27 @@ func (s *sessionsService) Authenticate(
 			"OAuth2 token, did not include an OpenID Connect identity token",
 		)
 	}
-	idToken, err := s.oidcTokenVerifier.Verify(ctx, rawIDToken)
+	identity, err := s.oidcIdentityVerifier.Verify(ctx, rawIDToken)
 	if err != nil {
-		return errors.Wrap(err, "error verifying OpenID Connect identity token")
-	}
-	claims := struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
-	}{}
-	if err = idToken.Claims(&claims); err != nil {
-		return errors.Wrap(
-			err,
-			"error decoding OpenID Connect identity token claims",
-		)
+		return err
 	}
-	user, err := s.usersStore.Get(ctx, claims.Email)
+	user, err := s.usersStore.Get(ctx, identity.Email)
 	if err != nil {
 		if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
-			// User wasn't found. That's ok. We'll create one.
+			// User wasn't found. That's ok. We'll create one if policy permits it.
+			var invited bool
+			if invited, err = s.checkProvisioningPolicy(ctx, identity); err != nil {
+				return err
+			}
+			now := time.Now()
 			user = User{
 				ObjectMeta: meta.ObjectMeta{
-					ID: claims.Email,
+					ID:          identity.Email,
+					Created:     &now,
+					LastUpdated: &now,
 				},
-				Name: claims.Name,
+				Name:   identity.Name,
+				Groups: identity.Groups,
 			}
 
 			// User 0 gets a bunch of roles automatically
@@ -323,16 +444,46 @@ func (s *sessionsService) Authenticate(
 			if err = s.usersStore.Create(ctx, user); err != nil {
 				return errors.Wrapf(err, "error storing new user %q", user.ID)
 			}
+
+			if invited {
+				// The invitation has served its purpose
+				if err = s.userInvitationsStore.Delete(
+					ctx,
+					strings.ToLower(identity.Email),
+				); err != nil {
+					return errors.Wrapf(
+						err,
+						"error removing user invitation %q from store",
+						strings.ToLower(identity.Email),
+					)
+				}
+			}
 		} else {
 			// It was something else that went wrong when searching for the user.
 			return err
 		}
+	} else {
+		// Group membership is managed by the identity provider, so record whatever
+		// it tells us each time the User logs in.
+		if err = s.usersStore.UpdateGroups(
+			ctx,
+			user.ID,
+			identity.Groups,
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error updating groups for user %q in store",
+				user.ID,
+			)
+		}
 	}
+	now := time.Now()
 	if err := s.sessionsStore.Authenticate(
 		ctx,
 		session.ID,
 		user.ID,
-		time.Now().Add(time.Hour),
+		now.Add(s.sessionTTL),
+		now.Add(s.refreshTokenTTL),
 	); err != nil {
 		return errors.Wrapf(
 			err,
@@ -343,13 +494,80 @@ func (s *sessionsService) Authenticate(
 	return nil
 }
 
+// checkProvisioningPolicy returns a *meta.ErrAuthentication error if the
+// configured UserProvisioningPolicy does not permit the provided identity,
+// previously unknown to Brigade, to be provisioned as a new User. Otherwise, it
+// returns a bool indicating whether the identity was permitted by virtue of a
+// UserInvitation.
+func (s *sessionsService) checkProvisioningPolicy(
+	ctx context.Context,
+	identity oidc.Identity,
+) (bool, error) {
+	switch s.config.UserProvisioningPolicy {
+	case UserProvisioningPolicyOpen:
+		return false, nil
+	case UserProvisioningPolicyDisabled:
+		return false, &meta.ErrAuthentication{
+			Reason: fmt.Sprintf(
+				"User %q is unknown to Brigade and this server does not provision "+
+					"new users. Please ask an administrator for access.",
+				identity.Email,
+			),
+		}
+	}
+
+	// If we get to here, the policy is UserProvisioningPolicyRestricted
+
+	if identity.Email == "" {
+		return false, &meta.ErrAuthentication{
+			Reason: "The identity provider did not supply an email address, so " +
+				"Brigade cannot determine whether to provision a new user.",
+		}
+	}
+	if identity.EmailVerified != nil && !*identity.EmailVerified {
+		return false, &meta.ErrAuthentication{
+			Reason: fmt.Sprintf(
+				"The identity provider has not verified email address %q. Please "+
+					"verify it with the identity provider and try again.",
+				identity.Email,
+			),
+		}
+	}
+
+	email := strings.ToLower(identity.Email)
+	if _, err := s.userInvitationsStore.Get(ctx, email); err == nil {
+		return true, nil
+	} else if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+		return false, errors.Wrapf(
+			err,
+			"error retrieving user invitation %q from store",
+			email,
+		)
+	}
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, allowedDomain := range s.config.AllowedEmailDomains {
+		if domain == allowedDomain {
+			return false, nil
+		}
+	}
+
+	return false, &meta.ErrAuthentication{
+		Reason: fmt.Sprintf(
+			"User %q is unknown to Brigade and has not been invited. Please ask an "+
+				"administrator for an invitation.",
+			identity.Email,
+		),
+	}
+}
+
 func (s *sessionsService) GetByOAuth2State(
 	ctx context.Context,
 	oauth2State string,
 ) (Session, error) {
 	session, err := s.sessionsStore.GetByHashedOAuth2State(
 		ctx,
-		crypto.ShortSHA("", oauth2State),
+		s.tokenHasher.Hash(oauth2State),
 	)
 	if err != nil {
 		return session, errors.Wrap(
@@ -364,9 +582,21 @@ func (s *sessionsService) GetByToken(
 	ctx context.Context,
 	token string,
 ) (Session, error) {
-	session, err := s.sessionsStore.GetByHashedToken(
-		ctx,
-		crypto.ShortSHA("", token),
+	var session Session
+	_, err := crypto.LookupByHashedToken(
+		s.tokenHasher,
+		token,
+		func(hashedToken string) (err error) {
+			session, err = s.sessionsStore.GetByHashedToken(ctx, hashedToken)
+			return err
+		},
+		func(legacyHashedToken, hashedToken string) error {
+			return errors.Wrapf(
+				s.sessionsStore.RehashToken(ctx, legacyHashedToken, hashedToken),
+				"error rehashing token for session %q in store",
+				session.ID,
+			)
+		},
 	)
 	if err != nil {
 		return session, errors.Wrap(
@@ -377,6 +607,181 @@ func (s *sessionsService) GetByToken(
 	return session, nil
 }
 
+func (s *sessionsService) Refresh(
+	ctx context.Context,
+	refreshToken string,
+) (Token, error) {
+
+	// No authz requirements here because possession of a valid refresh token is
+	// itself the proof of authentication.
+
+	authErr := &meta.ErrAuthentication{
+		Reason: "Supplied refresh token is invalid or has expired. Please log " +
+			"in again.",
+	}
+	// There's no need to replace a legacy hash of the refresh token, since the
+	// refresh below replaces it anyway.
+	var session Session
+	hashedRefreshToken, err := crypto.LookupByHashedToken(
+		s.tokenHasher,
+		refreshToken,
+		func(hashedRefreshToken string) (err error) {
+			session, err =
+				s.sessionsStore.GetByHashedRefreshToken(ctx, hashedRefreshToken)
+			return err
+		},
+		nil,
+	)
+	if err != nil {
+		if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
+			return Token{}, authErr
+		}
+		return Token{}, errors.Wrap(
+			err,
+			"error retrieving session from store by hashed refresh token",
+		)
+	}
+	now := time.Now()
+	if session.Authenticated == nil || session.RefreshTokenExpires == nil ||
+		now.After(*session.RefreshTokenExpires) {
+		return Token{}, authErr
+	}
+	if session.Root && !s.rootUserEnabled {
+		return Token{}, &meta.ErrAuthentication{
+			Reason: "Supplied refresh token was for an established root session, " +
+				"but authentication using root credentials is no longer supported " +
+				"by this server.",
+		}
+	}
+
+	token := Token{
+		Value:        crypto.NewTypedToken(crypto.TokenTypeSession),
+		RefreshToken: crypto.NewTypedToken(crypto.TokenTypeRefresh),
+	}
+	expires := now.Add(s.sessionTTL)
+	// A refreshed token never outlives the refresh token it was exchanged for
+	if expires.After(*session.RefreshTokenExpires) {
+		expires = *session.RefreshTokenExpires
+	}
+	session.HashedToken = s.tokenHasher.Hash(token.Value)
+	session.HashedRefreshToken = s.tokenHasher.Hash(token.RefreshToken)
+	session.Expires = &expires
+	if err = s.sessionsStore.Refresh(
+		ctx,
+		session,
+		hashedRefreshToken,
+	); err != nil {
+		if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
+			// The refresh token was used concurrently by someone else
+			return Token{}, authErr
+		}
+		return Token{}, errors.Wrapf(
+			err,
+			"error refreshing session %q in store",
+			session.ID,
+		)
+	}
+	return token, nil
+}
+
+func (s *sessionsService) List(
+	ctx context.Context,
+	selector SessionsSelector,
+	opts meta.ListOptions,
+) (SessionList, error) {
+	if err := s.authorizeForSessionsOf(ctx, selector); err != nil {
+		return SessionList{}, err
+	}
+
+	if !selector.AllUsers && selector.UserID == "" {
+		switch p := PincipalFromContext(ctx).(type) {
+		case *User:
+			selector.UserID = p.ID
+		case *root:
+			// Root sessions have no user ID
+		default:
+			return SessionList{}, &meta.ErrBadRequest{
+				Reason: "Only users have sessions. Specify a user whose sessions " +
+					"should be listed.",
+			}
+		}
+	}
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+	sessions, err := s.sessionsStore.List(ctx, selector, opts)
+	if err != nil {
+		return sessions, errors.Wrap(err, "error retrieving sessions from store")
+	}
+	currentSessionID := SessionIDFromContext(ctx)
+	for i := range sessions.Items {
+		sessions.Items[i].Current = sessions.Items[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *sessionsService) Revoke(ctx context.Context, id string) error {
+	owned, err := s.ownsSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		if err = s.authorize(ctx, RoleAdmin()); err != nil {
+			return err
+		}
+	}
+	if err = s.sessionsStore.Delete(ctx, id); err != nil {
+		return errors.Wrapf(err, "error removing session %q from store", id)
+	}
+	return nil
+}
+
+// ownsSession returns a bool indicating whether the specified Session is the
+// current Session or belongs to the User associated with the provided context.
+// A Session that does not exist is not owned by anyone, so callers that don't
+// own a Session cannot determine whether it exists without being authorized to
+// manage everyone's Sessions.
+func (s *sessionsService) ownsSession(
+	ctx context.Context,
+	id string,
+) (bool, error) {
+	if id == SessionIDFromContext(ctx) {
+		return true, nil
+	}
+	user, ok := PincipalFromContext(ctx).(*User)
+	if !ok {
+		return false, nil
+	}
+	session, err := s.sessionsStore.Get(ctx, id)
+	if err != nil {
+		if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
+			return false, nil
+		}
+		return false,
+			errors.Wrapf(err, "error retrieving session %q from store", id)
+	}
+	return session.UserID != "" && session.UserID == user.ID, nil
+}
+
+// authorizeForSessionsOf requires the Admin role unless the provided
+// SessionsSelector selects only Sessions belonging to the principal associated
+// with the provided context.
+func (s *sessionsService) authorizeForSessionsOf(
+	ctx context.Context,
+	selector SessionsSelector,
+) error {
+	if !selector.AllUsers {
+		if selector.UserID == "" {
+			return nil
+		}
+		if user, ok := PincipalFromContext(ctx).(*User); ok &&
+			user.ID == selector.UserID {
+			return nil
+		}
+	}
+	return s.authorize(ctx, RoleAdmin())
+}
+
 func (s *sessionsService) Delete(ctx context.Context, id string) error {
 	if err := s.sessionsStore.Delete(ctx, id); err != nil {
 		return errors.Wrapf(err, "error removing session %q from store", id)
@@ -386,13 +791,48 @@ func (s *sessionsService) Delete(ctx context.Context, id string) error {
 
 type SessionsStore interface {
 	Create(context.Context, Session) error
+	// List retrieves a SessionList from the underlying data store, with its Items
+	// (Sessions) ordered by age, oldest first. Only authenticated Sessions that
+	// can still be used or refreshed are included.
+	List(
+		context.Context,
+		SessionsSelector,
+		meta.ListOptions,
+	) (SessionList, error)
+	// Get retrieves a single Session from the underlying data store. If the
+	// specified Session does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Get(context.Context, string) (Session, error)
 	GetByHashedOAuth2State(context.Context, string) (Session, error)
 	GetByHashedToken(context.Context, string) (Session, error)
+	// GetByHashedRefreshToken retrieves a single Session having the provided
+	// hashed refresh token from the underlying data store. If no such Session
+	// exists, implementations MUST return a *meta.ErrNotFound error.
+	GetByHashedRefreshToken(context.Context, string) (Session, error)
+	// RehashToken replaces the specified legacy hash of a Session's token with a
+	// new hash of the same token in the underlying data store. If no Session has
+	// a token with the specified legacy hash, this is a no-op.
+	RehashToken(
+		ctx context.Context,
+		legacyHashedToken string,
+		hashedToken string,
+	) error
 	Authenticate(
 		ctx context.Context,
 		sessionID string,
 		userID string,
 		expires time.Time,
+		refreshTokenExpires time.Time,
+	) error
+	// Refresh updates the HashedToken, HashedRefreshToken, and Expires fields of
+	// the provided Session in the underlying data store, but only if the stored
+	// Session's hashed refresh token still matches the expected hashed refresh
+	// token. If no such Session exists, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Refresh(
+		ctx context.Context,
+		session Session,
+		expectedHashedRefreshToken string,
 	) error
 	Delete(context.Context, string) error
 }
@@ -0,0 +1,301 @@
+package authx
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+)
+
+// TeamList is an ordered and pageable list of Teams.
+type TeamList struct {
+	// ListMeta contains list metadata.
+	meta.ListMeta `json:"metadata"`
+	// Items is a slice of Teams.
+	Items []Team `json:"items,omitempty"`
+}
+
+// MarshalJSON amends TeamList instances with type metadata.
+func (t TeamList) MarshalJSON() ([]byte, error) {
+	type Alias TeamList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "TeamList",
+			},
+			Alias: (Alias)(t),
+		},
+	)
+}
+
+// Team represents a named set of Users and ServiceAccounts. A Team may be
+// granted system-level and project-level Roles just like any other principal.
+// Those Roles are held by all of the Team's members for as long as they remain
+// members.
+type Team struct {
+	// ObjectMeta encapsulates Team metadata.
+	meta.ObjectMeta `json:"metadata" bson:",inline"`
+	// Description is a natural language description of the Team.
+	Description string `json:"description,omitempty" bson:"description,omitempty"` // nolint: lll
+	// Members enumerates the Users and ServiceAccounts that belong to the Team.
+	Members []TeamMember `json:"members,omitempty" bson:"members,omitempty"`
+	// TeamRoles is a slice of Roles (both system-level and project-level)
+	// assigned to this Team.
+	TeamRoles []Role `json:"roles,omitempty" bson:"roles,omitempty"`
+}
+
+// MarshalJSON amends Team instances with type metadata.
+func (t Team) MarshalJSON() ([]byte, error) {
+	type Alias Team
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "Team",
+			},
+			Alias: (Alias)(t),
+		},
+	)
+}
+
+// TeamMember references a User or ServiceAccount that belongs to a Team.
+type TeamMember struct {
+	// PrincipalType qualifies what kind of principal is referenced by the
+	// PrincipalID field. Only Users and ServiceAccounts may be Team members.
+	PrincipalType PrincipalType `json:"principalType" bson:"principalType"`
+	// PrincipalID references a principal.
+	PrincipalID string `json:"principalID" bson:"principalID"`
+}
+
+// TeamsService is the specialized interface for managing Teams. It's
+// decoupled from underlying technology choices (e.g. data store) to keep
+// business logic reusable and consistent while the underlying tech stack
+// remains free to change.
+type TeamsService interface {
+	// Create creates a new Team. If a Team with the specified identifier already
+	// exists, implementations MUST return a *meta.ErrConflict error. If any of
+	// the specified members does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Create(context.Context, Team) error
+	// List returns a TeamList, with its Items (Teams) ordered by age, oldest
+	// first.
+	List(context.Context, meta.ListOptions) (TeamList, error)
+	// Get retrieves a single Team specified by its identifier. If the specified
+	// Team does not exist, implementations MUST return a *meta.ErrNotFound
+	// error.
+	Get(context.Context, string) (Team, error)
+	// AddMember adds a User or ServiceAccount to the specified Team. If either
+	// the Team or the principal does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	AddMember(context.Context, string, TeamMember) error
+	// RemoveMember removes a User or ServiceAccount from the specified Team. If
+	// the Team does not exist, implementations MUST return a *meta.ErrNotFound
+	// error.
+	RemoveMember(context.Context, string, TeamMember) error
+	// Delete deletes a single Team specified by its identifier, along with all
+	// Roles assigned to it. If the specified Team does not exist,
+	// implementations MUST return a *meta.ErrNotFound error.
+	Delete(context.Context, string) error
+}
+
+type teamsService struct {
+	authorize            AuthorizeFn
+	teamsStore           TeamsStore
+	usersStore           UsersStore
+	serviceAccountsStore ServiceAccountsStore
+}
+
+// NewTeamsService returns a specialized interface for managing Teams.
+func NewTeamsService(
+	teamsStore TeamsStore,
+	usersStore UsersStore,
+	serviceAccountsStore ServiceAccountsStore,
+) TeamsService {
+	return &teamsService{
+		authorize:            Authorize,
+		teamsStore:           teamsStore,
+		usersStore:           usersStore,
+		serviceAccountsStore: serviceAccountsStore,
+	}
+}
+
+func (t *teamsService) Create(ctx context.Context, team Team) error {
+	if err := t.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	for _, member := range team.Members {
+		if err := t.checkMember(ctx, member); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	team.Created = &now
+	team.LastUpdated = &now
+	team.CreatedBy = PrincipalReferenceFromContext(ctx)
+	// Roles are granted to a Team only after it has been created
+	team.TeamRoles = nil
+	if err := t.teamsStore.Create(ctx, team); err != nil {
+		return errors.Wrapf(err, "error storing new team %q", team.ID)
+	}
+	return nil
+}
+
+func (t *teamsService) List(
+	ctx context.Context,
+	opts meta.ListOptions,
+) (TeamList, error) {
+	if err := t.authorize(ctx, RoleReader()); err != nil {
+		return TeamList{}, err
+	}
+
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+	teams, err := t.teamsStore.List(ctx, opts)
+	if err != nil {
+		return teams, errors.Wrap(err, "error retrieving teams from store")
+	}
+	return teams, nil
+}
+
+func (t *teamsService) Get(ctx context.Context, id string) (Team, error) {
+	if err := t.authorize(ctx, RoleReader()); err != nil {
+		return Team{}, err
+	}
+
+	team, err := t.teamsStore.Get(ctx, id)
+	if err != nil {
+		return team, errors.Wrapf(err, "error retrieving team %q from store", id)
+	}
+	return team, nil
+}
+
+func (t *teamsService) AddMember(
+	ctx context.Context,
+	id string,
+	member TeamMember,
+) error {
+	if err := t.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	if err := t.checkMember(ctx, member); err != nil {
+		return err
+	}
+
+	if err := t.teamsStore.AddMember(ctx, id, member); err != nil {
+		return errors.Wrapf(
+			err,
+			"error adding %s %q to team %q in store",
+			member.PrincipalType,
+			member.PrincipalID,
+			id,
+		)
+	}
+	return nil
+}
+
+func (t *teamsService) RemoveMember(
+	ctx context.Context,
+	id string,
+	member TeamMember,
+) error {
+	if err := t.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	if err := t.teamsStore.RemoveMember(ctx, id, member); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing %s %q from team %q in store",
+			member.PrincipalType,
+			member.PrincipalID,
+			id,
+		)
+	}
+	return nil
+}
+
+func (t *teamsService) Delete(ctx context.Context, id string) error {
+	if err := t.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	if err := t.teamsStore.Delete(ctx, id); err != nil {
+		return errors.Wrapf(err, "error removing team %q from store", id)
+	}
+	return nil
+}
+
+// checkMember returns an error if the provided TeamMember does not reference
+// an existing User or ServiceAccount.
+func (t *teamsService) checkMember(
+	ctx context.Context,
+	member TeamMember,
+) error {
+	switch member.PrincipalType {
+	case PrincipalTypeUser:
+		if _, err := t.usersStore.Get(ctx, member.PrincipalID); err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving user %q from store",
+				member.PrincipalID,
+			)
+		}
+	case PrincipalTypeServiceAccount:
+		if _, err :=
+			t.serviceAccountsStore.Get(ctx, member.PrincipalID); err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving service account %q from store",
+				member.PrincipalID,
+			)
+		}
+	default:
+		return &meta.ErrBadRequest{
+			Reason: "Only users and service accounts may be team members.",
+		}
+	}
+	return nil
+}
+
+// TeamsStore is an interface for components that implement Team persistence
+// concerns.
+type TeamsStore interface {
+	// Create persists a new Team in the underlying data store. If a Team having
+	// the same ID already exists, implementations MUST return a
+	// *meta.ErrConflict error.
+	Create(context.Context, Team) error
+	// List retrieves a TeamList from the underlying data store, with its Items
+	// (Teams) ordered by age, oldest first.
+	List(context.Context, meta.ListOptions) (TeamList, error)
+	// Get retrieves a single Team from the underlying data store. If the
+	// specified Team does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Get(context.Context, string) (Team, error)
+	// AddMember adds the specified TeamMember to the specified Team in the
+	// underlying data store. Adding an existing member is a no-op. If the
+	// specified Team does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	AddMember(context.Context, string, TeamMember) error
+	// RemoveMember removes the specified TeamMember from the specified Team in
+	// the underlying data store. Removing a non-member is a no-op. If the
+	// specified Team does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	RemoveMember(context.Context, string, TeamMember) error
+	// Delete deletes the specified Team from the underlying data store. If the
+	// specified Team does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Delete(context.Context, string) error
+}
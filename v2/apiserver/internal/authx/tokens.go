@@ -10,6 +10,9 @@ import (
 // API.
 type Token struct {
 	Value string `json:"value" bson:"value"`
+	// RefreshToken is an opaque token that can be exchanged for a new Token. It
+	// is only issued alongside Tokens for Sessions.
+	RefreshToken string `json:"refreshToken,omitempty" bson:"-"`
 }
 
 // MarshalJSON amends Token instances with type metadata.
@@ -0,0 +1,200 @@
+package authx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+)
+
+// UserInvitationList is an ordered and pageable list of UserInvitations.
+type UserInvitationList struct {
+	// ListMeta contains list metadata.
+	meta.ListMeta `json:"metadata"`
+	// Items is a slice of UserInvitations.
+	Items []UserInvitation `json:"items,omitempty"`
+}
+
+// MarshalJSON amends UserInvitationList instances with type metadata.
+func (u UserInvitationList) MarshalJSON() ([]byte, error) {
+	type Alias UserInvitationList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "UserInvitationList",
+			},
+			Alias: (Alias)(u),
+		},
+	)
+}
+
+// UserInvitation represents an explicit invitation for the owner of an email
+// address to become a Brigade User. Under a restrictive
+// UserProvisioningPolicy, an identity vouched for by an OpenID Connect identity
+// provider is provisioned as a new User if it has been invited. Once the
+// invited User has logged in, the UserInvitation is consumed. A
+// UserInvitation's ID is the invited email address.
+type UserInvitation struct {
+	// ObjectMeta encapsulates UserInvitation metadata.
+	meta.ObjectMeta `json:"metadata" bson:",inline"`
+}
+
+// MarshalJSON amends UserInvitation instances with type metadata.
+func (u UserInvitation) MarshalJSON() ([]byte, error) {
+	type Alias UserInvitation
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "UserInvitation",
+			},
+			Alias: (Alias)(u),
+		},
+	)
+}
+
+// UserInvitationsService is the specialized interface for managing
+// UserInvitations. It's decoupled from underlying technology choices (e.g.
+// data store) to keep business logic reusable and consistent while the
+// underlying tech stack remains free to change.
+type UserInvitationsService interface {
+	// Create creates a new UserInvitation. If a UserInvitation for the same
+	// email address already exists, or a User having that email address as an
+	// identifier already exists, implementations MUST return a
+	// *meta.ErrConflict error.
+	Create(context.Context, UserInvitation) error
+	// List retrieves a UserInvitationList, with its Items (UserInvitations)
+	// ordered by age, oldest first.
+	List(context.Context, meta.ListOptions) (UserInvitationList, error)
+	// Delete deletes a single UserInvitation specified by its identifier. If no
+	// such UserInvitation exists, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Delete(context.Context, string) error
+}
+
+type userInvitationsService struct {
+	authorize            AuthorizeFn
+	userInvitationsStore UserInvitationsStore
+	usersStore           UsersStore
+}
+
+// NewUserInvitationsService returns a specialized interface for managing
+// UserInvitations.
+func NewUserInvitationsService(
+	userInvitationsStore UserInvitationsStore,
+	usersStore UsersStore,
+) UserInvitationsService {
+	return &userInvitationsService{
+		authorize:            Authorize,
+		userInvitationsStore: userInvitationsStore,
+		usersStore:           usersStore,
+	}
+}
+
+func (u *userInvitationsService) Create(
+	ctx context.Context,
+	userInvitation UserInvitation,
+) error {
+	if err := u.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	// Identity providers are inconsistent about the case of email addresses
+	userInvitation.ID = strings.ToLower(userInvitation.ID)
+
+	if _, err := u.usersStore.Get(ctx, userInvitation.ID); err == nil {
+		return &meta.ErrConflict{
+			Type: "UserInvitation",
+			ID:   userInvitation.ID,
+			Reason: fmt.Sprintf(
+				"A user with the ID %q already exists.",
+				userInvitation.ID,
+			),
+		}
+	} else if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+		return errors.Wrapf(
+			err,
+			"error retrieving user %q from store",
+			userInvitation.ID,
+		)
+	}
+
+	now := time.Now()
+	userInvitation.Created = &now
+	userInvitation.LastUpdated = &now
+	userInvitation.CreatedBy = PrincipalReferenceFromContext(ctx)
+	if err := u.userInvitationsStore.Create(ctx, userInvitation); err != nil {
+		return errors.Wrapf(
+			err,
+			"error storing new user invitation %q",
+			userInvitation.ID,
+		)
+	}
+	return nil
+}
+
+func (u *userInvitationsService) List(
+	ctx context.Context,
+	opts meta.ListOptions,
+) (UserInvitationList, error) {
+	if err := u.authorize(ctx, RoleAdmin()); err != nil {
+		return UserInvitationList{}, err
+	}
+
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+	userInvitations, err := u.userInvitationsStore.List(ctx, opts)
+	if err != nil {
+		return userInvitations,
+			errors.Wrap(err, "error retrieving user invitations from store")
+	}
+	return userInvitations, nil
+}
+
+func (u *userInvitationsService) Delete(ctx context.Context, id string) error {
+	if err := u.authorize(ctx, RoleAdmin()); err != nil {
+		return err
+	}
+
+	id = strings.ToLower(id)
+	if err := u.userInvitationsStore.Delete(ctx, id); err != nil {
+		return errors.Wrapf(
+			err,
+			"error removing user invitation %q from store",
+			id,
+		)
+	}
+	return nil
+}
+
+// UserInvitationsStore is an interface for components that implement
+// UserInvitation persistence concerns.
+type UserInvitationsStore interface {
+	// Create persists a new UserInvitation in the underlying data store. If a
+	// UserInvitation having the same ID already exists, implementations MUST
+	// return a *meta.ErrConflict error.
+	Create(context.Context, UserInvitation) error
+	// List retrieves a UserInvitationList from the underlying data store, with
+	// its Items (UserInvitations) ordered by age, oldest first.
+	List(context.Context, meta.ListOptions) (UserInvitationList, error)
+	// Get retrieves a single UserInvitation from the underlying data store. If
+	// the specified UserInvitation does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Get(context.Context, string) (UserInvitation, error)
+	// Delete deletes the specified UserInvitation from the underlying data
+	// store. If the specified UserInvitation does not exist, implementations
+	// MUST return a *meta.ErrNotFound error.
+	Delete(context.Context, string) error
+}
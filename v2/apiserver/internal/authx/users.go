@@ -14,10 +14,26 @@ type User struct {
 	Name            string     `json:"name" bson:"name"`
 	Locked          *time.Time `json:"locked" bson:"locked"`
 	UserRoles       []Role     `json:"roles,omitempty" bson:"roles,omitempty"`
+	// Groups enumerates the groups that the OpenID Connect identity provider
+	// said the User belonged to when the User last logged in. Since this is
+	// only refreshed by logging in, the Roles it confers are honored only for
+	// Sessions, which cannot be refreshed beyond the refresh token TTL that
+	// began at login, and never for PersonalAccessTokens.
+	Groups []string `json:"groups,omitempty" bson:"groups,omitempty"`
+	// GroupRoles enumerates Roles the User holds by virtue of belonging to one
+	// or more Groups. These are never persisted with the User. Rather, they are
+	// evaluated whenever the User is retrieved so that changes to the Roles held
+	// by a group take effect immediately.
+	GroupRoles []Role `json:"groupRoles,omitempty" bson:"-"`
+	// TeamRoles enumerates Roles the User holds by virtue of belonging to one or
+	// more Teams. Like GroupRoles, these are never persisted with the User.
+	TeamRoles []Role `json:"teamRoles,omitempty" bson:"-"`
 }
 
+// Roles returns all Roles held by the User, whether they were granted to the
+// User directly or to a group or Team the User belongs to.
 func (u *User) Roles() []Role {
-	return u.UserRoles
+	return unionRoles(u.UserRoles, u.GroupRoles, u.TeamRoles)
 }
 
 func (u User) MarshalJSON() ([]byte, error) {
@@ -36,6 +52,14 @@ func (u User) MarshalJSON() ([]byte, error) {
 	)
 }
 
+// UsersSelector represents useful filter criteria when selecting multiple
+// Users for API group operations like list.
+type UsersSelector struct {
+	// LabelSelector specifies requirements that a User's labels must satisfy for
+	// the User to be selected.
+	LabelSelector meta.LabelSelector
+}
+
 // UserList is an ordered and pageable list of Users.
 type UserList struct {
 	// ListMeta contains list metadata.
@@ -65,9 +89,13 @@ func (u UserList) MarshalJSON() ([]byte, error) {
 // reusable and consistent while the underlying tech stack remains free to
 // change.
 type UsersService interface {
-	// List returns a UserList.
-	List(context.Context, meta.ListOptions) (UserList, error)
-	// Get retrieves a single User specified by their identifier.
+	// List returns a UserList, with its Items (Users) ordered by age, oldest
+	// first. Criteria for which Users should be retrieved can be specified using
+	// the UsersSelector parameter.
+	List(context.Context, UsersSelector, meta.ListOptions) (UserList, error)
+	// Get retrieves a single User specified by their identifier. The User's
+	// GroupRoles field is populated with all Roles held by the groups the User
+	// belongs to.
 	Get(context.Context, string) (User, error)
 
 	// Lock removes access to the API for a single User specified by their
@@ -81,18 +109,24 @@ type UsersService interface {
 type usersService struct {
 	authorize  AuthorizeFn
 	usersStore UsersStore
+	rolesStore RolesStore
 }
 
 // NewUsersService returns a specialized interface for managing Users.
-func NewUsersService(usersStore UsersStore) UsersService {
+func NewUsersService(
+	usersStore UsersStore,
+	rolesStore RolesStore,
+) UsersService {
 	return &usersService{
 		authorize:  Authorize,
 		usersStore: usersStore,
+		rolesStore: rolesStore,
 	}
 }
 
 func (u *usersService) List(
 	ctx context.Context,
+	selector UsersSelector,
 	opts meta.ListOptions,
 ) (UserList, error) {
 	if err := u.authorize(ctx, RoleReader()); err != nil {
@@ -102,7 +136,7 @@ func (u *usersService) List(
 	if opts.Limit == 0 {
 		opts.Limit = 20
 	}
-	users, err := u.usersStore.List(ctx, opts)
+	users, err := u.usersStore.List(ctx, selector, opts)
 	if err != nil {
 		return users, errors.Wrap(err, "error retrieving users from store")
 	}
@@ -122,6 +156,24 @@ func (u *usersService) Get(ctx context.Context, id string) (User, error) {
 			id,
 		)
 	}
+	if len(user.Groups) > 0 {
+		if user.GroupRoles, err =
+			u.rolesStore.ListForGroups(ctx, user.Groups...); err != nil {
+			return user, errors.Wrapf(
+				err,
+				"error retrieving roles for groups of user %q from store",
+				id,
+			)
+		}
+	}
+	if user.TeamRoles, err =
+		u.rolesStore.ListForTeamMember(ctx, PrincipalTypeUser, id); err != nil {
+		return user, errors.Wrapf(
+			err,
+			"error retrieving roles for teams of user %q from store",
+			id,
+		)
+	}
 	return user, nil
 }
 
@@ -150,8 +202,10 @@ func (u *usersService) Unlock(ctx context.Context, id string) error {
 type UsersStore interface {
 	Create(context.Context, User) error
 	Count(context.Context) (int64, error)
-	List(context.Context, meta.ListOptions) (UserList, error)
+	List(context.Context, UsersSelector, meta.ListOptions) (UserList, error)
 	Get(context.Context, string) (User, error)
+	// UpdateGroups replaces the groups that the specified User belongs to.
+	UpdateGroups(ctx context.Context, id string, groups []string) error
 	Lock(context.Context, string) error
 	Unlock(context.Context, string) error
 }
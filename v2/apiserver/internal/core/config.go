@@ -1,7 +1,10 @@
 package core
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
 )
 
 const envconfigPrefix = "API_SERVER"
@@ -11,13 +14,25 @@ type Config struct {
 	DefaultWorkerImage           string          `envconfig:"DEFAULT_WORKER_IMAGE"`             // nolint: lll
 	DefaultWorkerImagePullPolicy ImagePullPolicy `envconfig:"DEFAULT_WORKER_IMAGE_PULL_POLICY"` // nolint: lll
 	WorkspaceStorageClass        string          `envconfig:"WORKSPACE_STORAGE_CLASS"`          // nolint: lll
+	EventRetentionInterval       time.Duration   `envconfig:"EVENT_RETENTION_INTERVAL"`         // nolint: lll
 }
 
 func NewConfigWithDefaults() Config {
-	return Config{}
+	return Config{
+		EventRetentionInterval: 10 * time.Minute,
+	}
 }
 
 func GetConfigFromEnvironment() (Config, error) {
 	c := NewConfigWithDefaults()
-	return c, envconfig.Process(envconfigPrefix, &c)
+	if err := envconfig.Process(envconfigPrefix, &c); err != nil {
+		return c, err
+	}
+	if c.EventRetentionInterval <= 0 {
+		return c, errors.New(
+			"the value of the EVENT_RETENTION_INTERVAL environment variable must " +
+				"be a positive duration",
+		)
+	}
+	return c, nil
 }
@@ -0,0 +1,359 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+)
+
+// EventRetentionReason represents the reason an Event is due to be deleted in
+// accordance with its Project's EventRetentionPolicy.
+type EventRetentionReason string
+
+const (
+	// EventRetentionReasonMaxAge represents that an Event is older than its
+	// Project's EventRetentionPolicy permits.
+	EventRetentionReasonMaxAge EventRetentionReason = "MAX_AGE"
+	// EventRetentionReasonMaxCount represents that an Event is not among the
+	// most recent Events its Project's EventRetentionPolicy permits to be
+	// retained.
+	EventRetentionReasonMaxCount EventRetentionReason = "MAX_COUNT"
+)
+
+// EventRetentionPolicy specifies when a Project's Events should be
+// automatically deleted. Only Events whose Workers have reached a terminal
+// phase are ever subject to deletion. Deleting an Event also purges its logs,
+// except where the log store does not permit it. Notably, the logging agent
+// stores logs in a capped MongoDB collection by default and, prior to MongoDB
+// 5.0, documents cannot be deleted from capped collections. Logs then age out
+// of the collection only as it reaches its maximum size.
+type EventRetentionPolicy struct {
+	// MaxAgeSeconds, if greater than zero, specifies the age, in seconds, beyond
+	// which Events should be deleted.
+	MaxAgeSeconds int64 `json:"maxAgeSeconds,omitempty" bson:"maxAgeSeconds,omitempty"` // nolint: lll
+	// MaxCount, if greater than zero, specifies how many of the most recent
+	// Events should be retained. Older Events are deleted.
+	MaxCount int64 `json:"maxCount,omitempty" bson:"maxCount,omitempty"`
+	// KeepLast specifies, per terminal WorkerPhase, a number of the most recent
+	// Events in that phase that should be retained even if MaxAgeSeconds or
+	// MaxCount would otherwise call for their deletion. This is useful, for
+	// instance, for retaining recent failures for further investigation.
+	KeepLast map[WorkerPhase]int64 `json:"keepLast,omitempty" bson:"keepLast,omitempty"` // nolint: lll
+}
+
+// EventRetentionReport enumerates the Events that are due to be (or have been)
+// deleted in accordance with a Project's EventRetentionPolicy.
+type EventRetentionReport struct {
+	// ProjectID identifies the Project to which the report pertains.
+	ProjectID string `json:"projectID"`
+	// DryRun indicates whether the Events enumerated by the report are merely
+	// due to be deleted (true) or have actually been deleted (false).
+	DryRun bool `json:"dryRun"`
+	// Items enumerates the Events.
+	Items []EventRetentionReportItem `json:"items"`
+}
+
+// MarshalJSON amends EventRetentionReport instances with type metadata.
+func (e EventRetentionReport) MarshalJSON() ([]byte, error) {
+	type Alias EventRetentionReport
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "EventRetentionReport",
+			},
+			Alias: (Alias)(e),
+		},
+	)
+}
+
+// EventRetentionReportItem describes a single Event enumerated by an
+// EventRetentionReport.
+type EventRetentionReportItem struct {
+	// EventID is the ID of the Event.
+	EventID string `json:"eventID"`
+	// Created indicates the time at which the Event was created.
+	Created *time.Time `json:"created,omitempty"`
+	// WorkerPhase is the phase of the Event's Worker.
+	WorkerPhase WorkerPhase `json:"workerPhase"`
+	// Reason indicates why the Event is due to be deleted.
+	Reason EventRetentionReason `json:"reason"`
+}
+
+// EventRetentionService is the specialized interface for enforcing Projects'
+// EventRetentionPolicies. It's decoupled from underlying technology choices
+// (e.g. data store, message bus, etc.) to keep business logic reusable and
+// consistent while the underlying tech stack remains free to change.
+type EventRetentionService interface {
+	// Preview returns an EventRetentionReport enumerating the specified
+	// Project's Events that are due to be deleted in accordance with the
+	// Project's EventRetentionPolicy, without deleting them. If the specified
+	// Project does not exist, implementations MUST return a *meta.ErrNotFound
+	// error.
+	Preview(ctx context.Context, projectID string) (EventRetentionReport, error)
+	// Enforce deletes the specified Project's Events that are due to be deleted
+	// in accordance with the Project's EventRetentionPolicy and returns an
+	// EventRetentionReport enumerating them. If the specified Project does not
+	// exist, implementations MUST return a *meta.ErrNotFound error.
+	Enforce(ctx context.Context, projectID string) (EventRetentionReport, error)
+	// Run periodically enforces the EventRetentionPolicies of all Projects
+	// until the provided context is canceled. When multiple API server
+	// processes do this concurrently, implementations MUST ensure only one of
+	// them performs each periodic sweep.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type eventRetentionService struct {
+	authorize        authx.AuthorizeFn
+	projectAuthorize authx.ProjectAuthorizeFn
+	projectsStore    ProjectsStore
+	eventsStore      EventsStore
+	eventsService    EventsService
+	leaseStore       EventRetentionLeaseStore
+	// leaseHolder uniquely identifies this process as the holder of the lease
+	// on periodic enforcement.
+	leaseHolder string
+}
+
+// NewEventRetentionService returns a specialized interface for enforcing
+// Projects' EventRetentionPolicies.
+func NewEventRetentionService(
+	projectsStore ProjectsStore,
+	eventsStore EventsStore,
+	eventsService EventsService,
+	leaseStore EventRetentionLeaseStore,
+	projectAuthorize authx.ProjectAuthorizeFn,
+) EventRetentionService {
+	return &eventRetentionService{
+		authorize:        authx.Authorize,
+		projectAuthorize: projectAuthorize,
+		projectsStore:    projectsStore,
+		eventsStore:      eventsStore,
+		eventsService:    eventsService,
+		leaseStore:       leaseStore,
+		leaseHolder:      crypto.NewToken(20),
+	}
+}
+
+func (e *eventRetentionService) Preview(
+	ctx context.Context,
+	projectID string,
+) (EventRetentionReport, error) {
+	if err := e.projectAuthorize(
+		ctx,
+		projectID,
+		authx.PermissionEventRetentionPreview,
+	); err != nil {
+		return EventRetentionReport{}, err
+	}
+	return e.getReport(ctx, projectID)
+}
+
+func (e *eventRetentionService) Enforce(
+	ctx context.Context,
+	projectID string,
+) (EventRetentionReport, error) {
+	if err := e.projectAuthorize(
+		ctx,
+		projectID,
+		authx.PermissionEventRetentionEnforce,
+	); err != nil {
+		return EventRetentionReport{}, err
+	}
+	report, err := e.getReport(ctx, projectID)
+	if err != nil {
+		return report, err
+	}
+	report.DryRun = false
+	// Events are deleted using the same path as any other Event deletion so that
+	// their Workers and Jobs are removed from the substrate and their logs are
+	// purged.
+	for _, item := range report.Items {
+		if err = e.eventsService.Delete(ctx, item.EventID); err != nil {
+			if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
+				continue // Already deleted by someone else
+			}
+			return report, errors.Wrapf(
+				err,
+				"error deleting event %q in accordance with project %q retention "+
+					"policy",
+				item.EventID,
+				projectID,
+			)
+		}
+	}
+	return report, nil
+}
+
+func (e *eventRetentionService) Run(
+	ctx context.Context,
+	interval time.Duration,
+) {
+	// There is no principal behind this background process, so it acts with
+	// root privileges.
+	ctx = authx.ContextWithPrincipal(ctx, authx.Root)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// Every API server process ticks, but only the holder of the lease
+			// sweeps. The lease lasts one interval and is renewed by its holder on
+			// every tick, so if the holder goes away, another process takes over
+			// within an interval.
+			acquired, err :=
+				e.leaseStore.AcquireLease(ctx, e.leaseHolder, interval)
+			if err != nil {
+				log.Println(
+					errors.Wrap(err, "error acquiring event retention lease"),
+				)
+			} else if acquired {
+				e.enforceAll(ctx)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// enforceAll enforces the EventRetentionPolicies of all Projects that have
+// one. Errors are logged rather than returned so that a problem with one
+// Project does not prevent enforcement for the others.
+func (e *eventRetentionService) enforceAll(ctx context.Context) {
+	opts := meta.ListOptions{Limit: 100}
+	for {
+		projects, err := e.projectsStore.List(ctx, ProjectsSelector{}, opts)
+		if err != nil {
+			log.Println(
+				errors.Wrap(err, "error listing projects for retention enforcement"),
+			)
+			return
+		}
+		for _, project := range projects.Items {
+			if project.Spec.EventRetention == nil {
+				continue
+			}
+			report, err := e.Enforce(ctx, project.ID)
+			if err != nil {
+				log.Println(err)
+			}
+			if len(report.Items) > 0 {
+				log.Printf(
+					"deleted %d event(s) in accordance with project %q retention policy",
+					len(report.Items),
+					project.ID,
+				)
+			}
+		}
+		if projects.Continue == "" {
+			return
+		}
+		opts.Continue = projects.Continue
+	}
+}
+
+// getReport determines which of the specified Project's Events are due to be
+// deleted in accordance with its EventRetentionPolicy.
+func (e *eventRetentionService) getReport(
+	ctx context.Context,
+	projectID string,
+) (EventRetentionReport, error) {
+	report := EventRetentionReport{
+		ProjectID: projectID,
+		DryRun:    true,
+		Items:     []EventRetentionReportItem{},
+	}
+
+	project, err := e.projectsStore.Get(ctx, projectID)
+	if err != nil {
+		return report, errors.Wrapf(
+			err,
+			"error retrieving project %q from store",
+			projectID,
+		)
+	}
+	policy := project.Spec.EventRetention
+	if policy == nil ||
+		(policy.MaxAgeSeconds <= 0 && policy.MaxCount <= 0) {
+		return report, nil
+	}
+
+	var maxAgeCutoff time.Time
+	if policy.MaxAgeSeconds > 0 {
+		maxAgeCutoff =
+			time.Now().Add(-time.Duration(policy.MaxAgeSeconds) * time.Second)
+	}
+
+	// Walk through all of the Project's terminal Events, newest first.
+	var count int64
+	phaseCounts := map[WorkerPhase]int64{}
+	selector := EventsSelector{
+		ProjectID:    projectID,
+		WorkerPhases: WorkerPhasesTerminal(),
+	}
+	opts := meta.ListOptions{Limit: 100}
+	for {
+		events, err := e.eventsStore.List(ctx, selector, opts)
+		if err != nil {
+			return report, errors.Wrapf(
+				err,
+				"error listing events for project %q",
+				projectID,
+			)
+		}
+		for _, event := range events.Items {
+			count++
+			phase := event.Worker.Status.Phase
+			phaseCounts[phase]++
+			if phaseCounts[phase] <= policy.KeepLast[phase] {
+				continue
+			}
+			var reason EventRetentionReason
+			if policy.MaxCount > 0 && count > policy.MaxCount {
+				reason = EventRetentionReasonMaxCount
+			} else if policy.MaxAgeSeconds > 0 && event.Created != nil &&
+				event.Created.Before(maxAgeCutoff) {
+				reason = EventRetentionReasonMaxAge
+			} else {
+				continue
+			}
+			report.Items = append(
+				report.Items,
+				EventRetentionReportItem{
+					EventID:     event.ID,
+					Created:     event.Created,
+					WorkerPhase: phase,
+					Reason:      reason,
+				},
+			)
+		}
+		if events.Continue == "" {
+			return report, nil
+		}
+		opts.Continue = events.Continue
+	}
+}
+
+// EventRetentionLeaseStore is an interface for components that grant an
+// exclusive, time-limited lease on the periodic enforcement of all Projects'
+// EventRetentionPolicies.
+type EventRetentionLeaseStore interface {
+	// AcquireLease attempts, without blocking, to acquire or renew the lease on
+	// behalf of the specified holder for the specified length of time. The bool
+	// returned indicates whether the lease was acquired. Implementations MUST
+	// NOT grant the lease to one holder while it is held by another.
+	AcquireLease(
+		ctx context.Context,
+		holder string,
+		ttl time.Duration,
+	) (bool, error)
+}
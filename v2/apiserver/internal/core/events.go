@@ -118,6 +118,24 @@ type EventsSelector struct {
 	// WorkerPhases specifies that Events with their Worker's in any of the
 	// indicated phases should be selected.
 	WorkerPhases []WorkerPhase
+	// Source specifies that only Events from the indicated source should be
+	// selected.
+	Source string
+	// Type specifies that only Events of the indicated type should be selected.
+	Type string
+	// LabelSelector specifies requirements that an Event's labels must satisfy
+	// for the Event to be selected.
+	LabelSelector meta.LabelSelector
+	// CreatedAfter specifies that only Events created at or after the indicated
+	// time should be selected.
+	CreatedAfter *time.Time
+	// CreatedBefore specifies that only Events created before the indicated time
+	// should be selected.
+	CreatedBefore *time.Time
+	// TitleSearch specifies text that an Event's ShortTitle or LongTitle must
+	// contain for the Event to be selected. Matching is performed on whole
+	// words.
+	TitleSearch string
 }
 
 // EventList is an ordered and pageable list of Events.
@@ -244,23 +262,32 @@ type EventsService interface {
 }
 
 type eventsService struct {
-	authorize     authx.AuthorizeFn
-	projectsStore ProjectsStore
-	eventsStore   EventsStore
-	substrate     Substrate
+	authorize        authx.AuthorizeFn
+	projectAuthorize authx.ProjectAuthorizeFn
+	projectsStore    ProjectsStore
+	eventsStore      EventsStore
+	logsStore        LogsStore
+	substrate        Substrate
+	tokenHasher      crypto.TokenHasher
 }
 
 // NewEventsService returns a specialized interface for managing Events.
 func NewEventsService(
 	projectsStore ProjectsStore,
 	eventsStore EventsStore,
+	logsStore LogsStore,
 	substrate Substrate,
+	projectAuthorize authx.ProjectAuthorizeFn,
+	tokenHasher crypto.TokenHasher,
 ) EventsService {
 	return &eventsService{
-		authorize:     authx.Authorize,
-		projectsStore: projectsStore,
-		eventsStore:   eventsStore,
-		substrate:     substrate,
+		authorize:        authx.Authorize,
+		projectAuthorize: projectAuthorize,
+		projectsStore:    projectsStore,
+		eventsStore:      eventsStore,
+		logsStore:        logsStore,
+		substrate:        substrate,
+		tokenHasher:      tokenHasher,
 	}
 }
 
@@ -278,10 +305,19 @@ func (e *eventsService) Create(
 		); err != nil {
 			return events, err
 		}
-	} else {
-		if err := e.authorize(
+	} else if err := e.projectAuthorize(
+		ctx,
+		event.ProjectID,
+		authx.PermissionEventsCreate,
+	); err != nil {
+		// Gateways may also create Events for a specific Project, but only
+		// principals that actually hold the EVENT_CREATOR Role are considered
+		// gateways. Anyone else gets the project-level authorization error.
+		if !holdsEventCreatorRole(ctx) {
+			return events, err
+		}
+		if err = e.authorize(
 			ctx,
-			authx.RoleProjectUser(event.ProjectID),
 			authx.RoleEventCreator(event.Source),
 		); err != nil {
 			return events, err
@@ -290,6 +326,8 @@ func (e *eventsService) Create(
 
 	now := time.Now()
 	event.Created = &now
+	event.LastUpdated = &now
+	event.CreatedBy = authx.PrincipalReferenceFromContext(ctx)
 
 	// If no project ID is specified, we use other criteria to locate projects
 	// that are subscribed to this event. We iterate over all of those and create
@@ -365,7 +403,7 @@ func (e *eventsService) Create(
 		workerSpec.ConfigFilesDirectory = "."
 	}
 
-	token := crypto.NewToken(256)
+	token := crypto.NewTypedToken(crypto.TokenTypeWorker)
 
 	event.Worker = Worker{
 		Spec: workerSpec,
@@ -373,7 +411,7 @@ func (e *eventsService) Create(
 			Phase: WorkerPhasePending,
 		},
 		Token:       token,
-		HashedToken: crypto.ShortSHA("", token),
+		HashedToken: e.tokenHasher.Hash(token),
 	}
 
 	// Amend the Event with substrate-specific details before we persist.
@@ -454,13 +492,38 @@ func (e *eventsService) GetByWorkerToken(
 	// No authz is required here because this is only ever called by the system
 	// itself.
 
-	event, err := e.eventsStore.GetByHashedWorkerToken(
-		ctx,
-		crypto.ShortSHA("", workerToken),
+	var event Event
+	_, err := crypto.LookupByHashedToken(
+		e.tokenHasher,
+		workerToken,
+		func(hashedWorkerToken string) (err error) {
+			event, err =
+				e.eventsStore.GetByHashedWorkerToken(ctx, hashedWorkerToken)
+			return err
+		},
+		func(legacyHashedWorkerToken, hashedWorkerToken string) error {
+			return errors.Wrapf(
+				e.eventsStore.RehashWorkerToken(
+					ctx,
+					legacyHashedWorkerToken,
+					hashedWorkerToken,
+				),
+				"error rehashing worker token for event %q in store",
+				event.ID,
+			)
+		},
 	)
 	if err != nil {
 		return event, errors.Wrap(err, "error retrieving event from store")
 	}
+	// Tokens are revoked when Workers reach a terminal phase, but don't rely on
+	// that alone. e.g. Workers that finished before revocation was introduced
+	// still have tokens on record.
+	if event.Worker.Status.Phase.IsTerminal() {
+		return Event{}, &meta.ErrNotFound{
+			Type: "Event",
+		}
+	}
 	return event, nil
 }
 
@@ -470,9 +533,10 @@ func (e *eventsService) Cancel(ctx context.Context, id string) error {
 		return errors.Wrapf(err, "error retrieving event %q from store", id)
 	}
 
-	if err = e.authorize(
+	if err = e.projectAuthorize(
 		ctx,
-		authx.RoleProjectUser(event.ProjectID),
+		event.ProjectID,
+		authx.PermissionEventsCancel,
 	); err != nil {
 		return err
 	}
@@ -515,9 +579,10 @@ func (e *eventsService) CancelMany(
 		}
 	}
 
-	if err := e.authorize(
+	if err := e.projectAuthorize(
 		ctx,
-		authx.RoleProjectUser(selector.ProjectID),
+		selector.ProjectID,
+		authx.PermissionEventsCancel,
 	); err != nil {
 		return CancelManyEventsResult{}, err
 	}
@@ -576,9 +641,10 @@ func (e *eventsService) Delete(ctx context.Context, id string) error {
 		return errors.Wrapf(err, "error retrieving event %q from store", id)
 	}
 
-	if err = e.authorize(
+	if err = e.projectAuthorize(
 		ctx,
-		authx.RoleProjectUser(event.ProjectID),
+		event.ProjectID,
+		authx.PermissionEventsDelete,
 	); err != nil {
 		return err
 	}
@@ -596,15 +662,7 @@ func (e *eventsService) Delete(ctx context.Context, id string) error {
 		return errors.Wrapf(err, "error deleting event %q from store", id)
 	}
 
-	if err = e.substrate.DeleteWorkerAndJobs(ctx, project, event); err != nil {
-		return errors.Wrapf(
-			err,
-			"error deleting event %q worker and jobs from the substrate",
-			id,
-		)
-	}
-
-	return nil
+	return e.cleanUp(ctx, project, event)
 }
 
 func (e *eventsService) DeleteMany(
@@ -621,9 +679,10 @@ func (e *eventsService) DeleteMany(
 		}
 	}
 
-	if err := e.authorize(
+	if err := e.projectAuthorize(
 		ctx,
-		authx.RoleProjectUser(selector.ProjectID),
+		selector.ProjectID,
+		authx.PermissionEventsDelete,
 	); err != nil {
 		return DeleteManyEventsResult{}, err
 	}
@@ -657,18 +716,12 @@ func (e *eventsService) DeleteMany(
 	// efficient way to do this?
 	go func() {
 		for _, event := range events.Items {
-			if err := e.substrate.DeleteWorkerAndJobs(
+			if err := e.cleanUp(
 				context.Background(), // Deliberately not using request context
 				project,
 				event,
 			); err != nil {
-				log.Println(
-					errors.Wrapf(
-						err,
-						"error deleting event %q worker and jobs from the substrate",
-						event.ID,
-					),
-				)
+				log.Println(err)
 			}
 		}
 	}()
@@ -676,6 +729,30 @@ func (e *eventsService) DeleteMany(
 	return result, nil
 }
 
+// cleanUp deletes everything pertaining to a deleted Event that lives outside
+// the EventsStore-- i.e. its Worker and Jobs on the substrate and its logs.
+// Purging logs is best-effort. A failure to do so is logged, but doesn't
+// prevent the Event's deletion.
+func (e *eventsService) cleanUp(
+	ctx context.Context,
+	project Project,
+	event Event,
+) error {
+	if err := e.substrate.DeleteWorkerAndJobs(ctx, project, event); err != nil {
+		return errors.Wrapf(
+			err,
+			"error deleting event %q worker and jobs from the substrate",
+			event.ID,
+		)
+	}
+	if err := e.logsStore.DeleteEventLogs(ctx, project, event); err != nil {
+		log.Println(
+			errors.Wrapf(err, "error deleting event %q logs from store", event.ID),
+		)
+	}
+	return nil
+}
+
 // EventsStore is an interface for components that implement Event persistence
 // concerns.
 type EventsStore interface {
@@ -699,6 +776,14 @@ type EventsStore interface {
 	// the underlying data store. If no such Event exists, implementations MUST
 	// return a *meta.ErrNotFound error.
 	GetByHashedWorkerToken(context.Context, string) (Event, error)
+	// RehashWorkerToken replaces the specified legacy hash of a Worker's token
+	// with a new hash of the same token in the underlying data store. If no
+	// Worker has a token with the specified legacy hash, this is a no-op.
+	RehashWorkerToken(
+		ctx context.Context,
+		legacyHashedWorkerToken string,
+		hashedWorkerToken string,
+	) error
 	// Cancel updates the specified Event in the underlying data store to reflect
 	// that it has been canceled. Implementations MAY assume the Event's existence
 	// has been pre-confirmed by the caller. Implementations MUST only cancel
@@ -725,3 +810,16 @@ type EventsStore interface {
 		EventsSelector,
 	) (EventList, error)
 }
+
+// holdsEventCreatorRole returns true if the principal associated with the
+// provided Context explicitly holds the EVENT_CREATOR Role, regardless of the
+// source it is scoped to.
+func holdsEventCreatorRole(ctx context.Context) bool {
+	for _, role := range authx.PincipalFromContext(ctx).Roles() {
+		if role.Type == authx.RoleTypeSystem &&
+			role.Name == authx.RoleNameEventCreator {
+			return true
+		}
+	}
+	return false
+}
@@ -226,6 +226,18 @@ func (j *jobsService) Create(
 	if err != nil {
 		return errors.Wrapf(err, "error retrieving event %q from store", eventID)
 	}
+	if event.Worker.Status.Phase.IsTerminal() {
+		return &meta.ErrConflict{
+			Type: "Event",
+			ID:   eventID,
+			Reason: fmt.Sprintf(
+				"Event %q worker is in a terminal phase (%s); new jobs cannot be "+
+					"created.",
+				eventID,
+				event.Worker.Status.Phase,
+			),
+		}
+	}
 	if _, ok := event.Worker.Jobs[jobName]; ok {
 		return &meta.ErrConflict{
 			Type: "Job",
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+type mockEventsStore struct {
+	EventsStore
+	GetFn func(context.Context, string) (Event, error)
+}
+
+func (m *mockEventsStore) Get(ctx context.Context, id string) (Event, error) {
+	return m.GetFn(ctx, id)
+}
+
+func TestJobsServiceCreateWithWorkerInTerminalPhase(t *testing.T) {
+	const testEventID = "tunguska"
+	for _, phase := range WorkerPhasesTerminal() {
+		t.Run(string(phase), func(t *testing.T) {
+			// No other stores are defined because the job should be rejected before
+			// any of them are consulted
+			svc := &jobsService{
+				authorize: authx.AlwaysAuthorize,
+				eventsStore: &mockEventsStore{
+					GetFn: func(_ context.Context, id string) (Event, error) {
+						require.Equal(t, testEventID, id)
+						return Event{
+							ObjectMeta: meta.ObjectMeta{
+								ID: testEventID,
+							},
+							Worker: Worker{
+								Status: WorkerStatus{
+									Phase: phase,
+								},
+							},
+						}, nil
+					},
+				},
+			}
+			err := svc.Create(context.Background(), testEventID, "italian", Job{})
+			require.Error(t, err)
+			conflictErr, ok := err.(*meta.ErrConflict)
+			require.True(t, ok)
+			require.Equal(t, "Event", conflictErr.Type)
+			require.Equal(t, testEventID, conflictErr.ID)
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -38,12 +39,37 @@ func (l *logsStore) StreamLogs(
 		podName = fmt.Sprintf("job-%s-%s", event.ID, strings.ToLower(selector.Job))
 	}
 
+	podLogOpts := &v1.PodLogOptions{
+		Container: selector.Container,
+		// We always ask for timestamps because we need them for evaluating
+		// opts.Until. If the client didn't ask for them, they're stripped from each
+		// LogEntry before it is sent.
+		Timestamps: true,
+	}
+	var afterTime time.Time
+	var afterOrdinal int
+	if opts.After != "" {
+		var err error
+		if afterTime, afterOrdinal, err =
+			core.ParseTimeBasedLogEntryID(opts.After); err != nil {
+			return nil, err
+		}
+		// SinceTime has a granularity of one second, so we ask for slightly more
+		// than we need and skip over what the client has already received.
+		resumeTime := afterTime.Truncate(time.Second)
+		if opts.Since == nil || opts.Since.Before(resumeTime) {
+			opts.Since = &resumeTime
+		}
+	} else if opts.Tail > 0 {
+		podLogOpts.TailLines = &opts.Tail
+	}
+	if opts.Since != nil {
+		podLogOpts.SinceTime = &metav1.Time{Time: *opts.Since}
+	}
+
 	req := l.kubeClient.CoreV1().Pods(project.Kubernetes.Namespace).GetLogs(
 		podName,
-		&v1.PodLogOptions{
-			Container:  selector.Container,
-			Timestamps: true,
-		},
+		podLogOpts,
 	)
 	podLogs, err := req.Stream(ctx)
 	if err != nil {
@@ -61,6 +87,9 @@ func (l *logsStore) StreamLogs(
 		defer podLogs.Close()
 		defer close(logEntryCh)
 		buffer := bufio.NewReader(podLogs)
+		// Used for assigning IDs to each LogEntry
+		var lastTime time.Time
+		var ordinal int
 		for {
 			logEntry := core.LogEntry{}
 			logLine, err := buffer.ReadString('\n')
@@ -81,6 +110,28 @@ func (l *logsStore) StreamLogs(
 			} else {
 				logEntry.Message = logLine
 			}
+			if logEntry.Time != nil {
+				if logEntry.Time.Equal(lastTime) {
+					ordinal++
+				} else {
+					lastTime = *logEntry.Time
+					ordinal = 0
+				}
+				logEntry.ID = core.NewTimeBasedLogEntryID(lastTime, ordinal)
+				if opts.After != "" && (lastTime.Before(afterTime) ||
+					(lastTime.Equal(afterTime) && ordinal <= afterOrdinal)) {
+					// The client has already received this one
+					continue
+				}
+			}
+			if opts.Until != nil && logEntry.Time != nil &&
+				logEntry.Time.After(*opts.Until) {
+				// Everything from here on out falls outside the requested window.
+				return
+			}
+			if !opts.Timestamps {
+				logEntry.Time = nil
+			}
 			select {
 			case logEntryCh <- logEntry:
 			case <-ctx.Done():
@@ -100,9 +151,29 @@ func (l *logsStore) StreamLogs(
 			// of a stream, in which case both warm and cold storage could both
 			// disconnect when the end of a stream is reached and they would still be
 			// consistent with one another.
-			<-ctx.Done()
+			//
+			// If the client specified an end to the window of logs it is interested
+			// in, however, there's no point in waiting beyond that.
+			if opts.Until == nil {
+				<-ctx.Done()
+				return
+			}
+			select {
+			case <-time.After(time.Until(*opts.Until)):
+			case <-ctx.Done():
+			}
 		}
 	}()
 
 	return logEntryCh, nil
 }
+
+// DeleteEventLogs is a no-op. Logs held by Kubernetes are deleted along with
+// the Pods they belong to when the Substrate cleans up after an Event.
+func (l *logsStore) DeleteEventLogs(
+	context.Context,
+	core.Project,
+	core.Event,
+) error {
+	return nil
+}
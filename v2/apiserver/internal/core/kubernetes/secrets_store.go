@@ -76,6 +76,57 @@ func (s *secretsStore) List(
 	return secrets, nil
 }
 
+func (s *secretsStore) GetValues(
+	ctx context.Context,
+	project core.Project,
+) ([]string, error) {
+	k8sSecret, err := s.kubeClient.CoreV1().Secrets(
+		project.Kubernetes.Namespace,
+	).Get(ctx, "project-secrets", metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error retrieving secret \"project-secrets\" in namespace %q",
+			project.Kubernetes.Namespace,
+		)
+	}
+	values := make([]string, 0, len(k8sSecret.Data))
+	for _, value := range k8sSecret.Data {
+		values = append(values, string(value))
+	}
+	return values, nil
+}
+
+func (s *secretsStore) GetAll(
+	ctx context.Context,
+	project core.Project,
+) ([]core.Secret, error) {
+	k8sSecret, err := s.kubeClient.CoreV1().Secrets(
+		project.Kubernetes.Namespace,
+	).Get(ctx, "project-secrets", metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error retrieving secret \"project-secrets\" in namespace %q",
+			project.Kubernetes.Namespace,
+		)
+	}
+	secrets := core.SecretList{
+		Items: make([]core.Secret, 0, len(k8sSecret.Data)),
+	}
+	for key, value := range k8sSecret.Data {
+		secrets.Items = append(
+			secrets.Items,
+			core.Secret{
+				Key:   key,
+				Value: string(value),
+			},
+		)
+	}
+	sort.Sort(secrets)
+	return secrets.Items, nil
+}
+
 func (s *secretsStore) Set(
 	ctx context.Context,
 	project core.Project,
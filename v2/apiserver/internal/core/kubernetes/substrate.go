@@ -15,6 +15,7 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -58,6 +59,10 @@ func (s *substrate) CreateProject(
 	ctx context.Context,
 	project core.Project,
 ) error {
+	// Note: Resources that already exist are tolerated so that this operation is
+	// idempotent. This permits a Project's substrate resources to be recreated,
+	// for instance, when restoring from a system backup.
+
 	// Create the Project's Kubernetes namespace
 	if _, err := s.kubeClient.CoreV1().Namespaces().Create(
 		ctx,
@@ -67,7 +72,7 @@ func (s *substrate) CreateProject(
 			},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating namespace %q for project %q",
@@ -86,7 +91,7 @@ func (s *substrate) CreateProject(
 			Rules: []rbacv1.PolicyRule{},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating role \"workers\" in namespace %q",
@@ -105,7 +110,7 @@ func (s *substrate) CreateProject(
 			},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating service account \"workers\" in namespace %q",
@@ -136,7 +141,7 @@ func (s *substrate) CreateProject(
 			},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating role binding \"workers\" in namespace %q",
@@ -154,7 +159,7 @@ func (s *substrate) CreateProject(
 			Rules: []rbacv1.PolicyRule{},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating role \"jobs\" in namespace %q",
@@ -173,7 +178,7 @@ func (s *substrate) CreateProject(
 			},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating service account \"jobs\" in namespace %q",
@@ -204,7 +209,7 @@ func (s *substrate) CreateProject(
 			},
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating role binding \"jobs\" in namespace %q",
@@ -230,7 +235,7 @@ func (s *substrate) CreateProject(
 			Type: myk8s.SecretTypeProjectSecrets,
 		},
 		metav1.CreateOptions{},
-	); err != nil {
+	); err != nil && !k8sErrors.IsAlreadyExists(err) {
 		return errors.Wrapf(
 			err,
 			"error creating secret \"project-secrets\" in namespace %q",
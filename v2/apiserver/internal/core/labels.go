@@ -1,7 +1,7 @@
 package core
 
 import (
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
 )
 
@@ -11,49 +11,19 @@ import (
 type Labels map[string]string
 
 // MarshalBSONValue implements custom BSON marshaling for the Labels type.
-// Labels is, essentially, a map[string]string, but when marshaled to BSON,
-// it must be represented as follows because Mongo can index this more easily,
-// making for faster queries:
-//
-// [
-//   { "key": "key0", "value": "value0" },
-//   { "key": "key1", "value": "value1" },
-//   ...
-//   { "key": "keyN", "value": "valueN" }
-// ]
+// Labels are represented in BSON in the same manner as meta.Labels.
 func (l Labels) MarshalBSONValue() (bsontype.Type, []byte, error) {
-	ms := make([]bson.M, len(l))
-	var i int
-	for k, v := range l {
-		ms[i] = bson.M{
-			"key":   k,
-			"value": v,
-		}
-		i++
-	}
-	return bson.MarshalValue(ms)
+	return meta.Labels(l).MarshalBSONValue()
 }
 
 // UnmarshalBSONValue implements custom BSON unmarshaling for the Labels
-// type. Labels is, essentially, a map[string]string, but when marshaled to
-// BSON, it is represented as follows because Mongo can index this more easily,
-// making for faster queries:
-//
-// [
-//   { "key": "key0", "value": "value0" },
-//   { "key": "key1", "value": "value1" },
-//   ...
-//   { "key": "keyN", "value": "valueN" }
-// ]
-func (l Labels) UnmarshalBSONValue(_ bsontype.Type, bytes []byte) error {
-	labels := bson.M{}
-	if err := bson.Unmarshal(bytes, &labels); err != nil {
+// type. Labels are represented in BSON in the same manner as meta.Labels.
+func (l Labels) UnmarshalBSONValue(bsonType bsontype.Type, bytes []byte) error {
+	labels := meta.Labels{}
+	if err := labels.UnmarshalBSONValue(bsonType, bytes); err != nil {
 		return err
 	}
-	for _, label := range labels {
-		m := label.(bson.M)
-		k := m["key"].(string)
-		v := m["value"].(string)
+	for k, v := range labels {
 		l[k] = v
 	}
 	return nil
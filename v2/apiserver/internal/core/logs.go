@@ -3,13 +3,31 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/redaction"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 )
 
+const (
+	// defaultRedactionMask is the string with which occurrences of Secret values
+	// in logs are replaced, unless a Project specifies otherwise.
+	defaultRedactionMask = "***"
+	// logsReorderWindow is how long a multiplexed log stream waits on a quiet
+	// container before presuming no earlier log entries are forthcoming from it.
+	logsReorderWindow = time.Second
+	// logsJobDiscoveryInterval is how often a followed, multiplexed log stream
+	// checks for newly spawned Jobs.
+	logsJobDiscoveryInterval = 5 * time.Second
+)
+
 // LogsSelector represents useful criteria for selecting logs for streaming from
 // a specific container of a Worker or Job.
 type LogsSelector struct {
@@ -20,6 +38,10 @@ type LogsSelector struct {
 	// whose logs are being retrieved. If left blank, a container with the same
 	// name as the Worker or Job is assumed.
 	Container string
+	// All indicates that logs from ALL containers of the Worker and every Job
+	// it has spawned should be multiplexed into a single stream, interleaved by
+	// timestamp. When set, the Job and Container fields are ignored.
+	All bool
 }
 
 // LogStreamOptions represents useful options for streaming logs from a specific
@@ -30,6 +52,27 @@ type LogStreamOptions struct {
 	// until closed by the client (true), continuing to send new lines as they
 	// become available.
 	Follow bool `json:"follow"`
+	// Tail, if greater than zero, limits the stream to (at most) the specified
+	// number of most recent lines of logs available at the time the stream is
+	// opened. Lines that become available thereafter are still sent if Follow
+	// is true.
+	Tail int64 `json:"tail,omitempty"`
+	// Since, if non-nil, excludes from the stream any lines of logs written
+	// before the specified time.
+	Since *time.Time `json:"since,omitempty"`
+	// Until, if non-nil, excludes from the stream any lines of logs written
+	// after the specified time. If Follow is also true, the stream will be
+	// concluded once that time has elapsed.
+	Until *time.Time `json:"until,omitempty"`
+	// Timestamps indicates whether each LogEntry sent over the stream should
+	// include the time its line of logs was written.
+	Timestamps bool `json:"timestamps"`
+	// After, if non-empty, is the ID of the last LogEntry a client received
+	// before its stream was interrupted. The stream resumes with the LogEntry
+	// that follows it. Tail is ignored when After is specified. Multiplexed
+	// streams (see LogsSelector) cannot be resumed and After MUST be empty when
+	// one is requested.
+	After string `json:"after,omitempty"`
 }
 
 // LogEntry represents one line of output from an OCI container.
@@ -38,6 +81,25 @@ type LogEntry struct {
 	Time *time.Time `json:"time,omitempty" bson:"time,omitempty"`
 	// Message is a single line of log output from an OCI container.
 	Message string `json:"message,omitempty" bson:"log,omitempty"`
+	// ID is an opaque identifier for the LogEntry that can be used to resume an
+	// interrupted stream from the LogEntry that follows it. Its format is
+	// specific to the LogsStore from which the LogEntry was retrieved. It is not
+	// included in the JSON representation of a LogEntry since it's conveyed to
+	// clients by other means.
+	ID string `json:"-" bson:"-"`
+	// Source identifies the container that wrote the line. It is only populated
+	// for log entries sent over a stream that multiplexes logs from multiple
+	// containers.
+	Source *LogEntrySource `json:"source,omitempty" bson:"-"`
+}
+
+// LogEntrySource identifies a specific container of a Worker or Job.
+type LogEntrySource struct {
+	// Job is the name of the Job the container belongs to. If blank, the
+	// container belongs to the Worker.
+	Job string `json:"job,omitempty"`
+	// Container is the name of the container.
+	Container string `json:"container,omitempty"`
 }
 
 // MarshalJSON amends LogEntry instances with type metadata so that clients do
@@ -65,37 +127,52 @@ func (l LogEntry) MarshalJSON() ([]byte, error) {
 type LogsService interface {
 	// Stream returns a channel over which logs for an Event's Worker, or using
 	// the LogsSelector parameter, a Job spawned by that Worker (or specific
-	// container thereof), are streamed. If the specified Event, Job, or Container
-	// thereof does not exist, implementations MUST return a *meta.ErrNotFound
-	// error.
+	// container thereof), are streamed. The LogsSelector parameter may also be
+	// used to request logs from all of the Worker's and Jobs' containers,
+	// multiplexed into a single stream. Unless the Project has opted out,
+	// occurrences of the values of any of the Project's Secrets are masked. If
+	// the specified Event, Job, or Container thereof does not exist,
+	// implementations MUST return a *meta.ErrNotFound error.
 	Stream(
 		ctx context.Context,
 		eventID string,
 		selector LogsSelector,
 		opts LogStreamOptions,
 	) (<-chan LogEntry, error)
+	// Bundle returns a LogsBundle containing all available logs from all
+	// containers of the specified Event's Worker and every Job it has spawned.
+	// Secret values are masked just as they are by Stream. If the specified
+	// Event does not exist, implementations MUST return a
+	// *meta.ErrNotFound error.
+	Bundle(ctx context.Context, eventID string) (LogsBundle, error)
 }
 
 type logsService struct {
-	authorize     authx.AuthorizeFn
-	projectsStore ProjectsStore
-	eventsStore   EventsStore
-	warmLogsStore LogsStore
-	coolLogsStore LogsStore
+	authorize        authx.AuthorizeFn
+	projectAuthorize authx.ProjectAuthorizeFn
+	projectsStore    ProjectsStore
+	eventsStore      EventsStore
+	secretsStore     SecretsStore
+	warmLogsStore    LogsStore
+	coolLogsStore    LogsStore
 }
 
 func NewLogsService(
 	projectsStore ProjectsStore,
 	eventsStore EventsStore,
+	secretsStore SecretsStore,
 	warmLogsStore LogsStore,
 	coolLogsStore LogsStore,
+	projectAuthorize authx.ProjectAuthorizeFn,
 ) LogsService {
 	return &logsService{
-		authorize:     authx.Authorize,
-		projectsStore: projectsStore,
-		eventsStore:   eventsStore,
-		warmLogsStore: warmLogsStore,
-		coolLogsStore: coolLogsStore,
+		authorize:        authx.Authorize,
+		projectAuthorize: projectAuthorize,
+		projectsStore:    projectsStore,
+		eventsStore:      eventsStore,
+		secretsStore:     secretsStore,
+		warmLogsStore:    warmLogsStore,
+		coolLogsStore:    coolLogsStore,
 	}
 }
 
@@ -105,15 +182,34 @@ func (l *logsService) Stream(
 	selector LogsSelector,
 	opts LogStreamOptions,
 ) (<-chan LogEntry, error) {
+	if opts.Tail < 0 {
+		return nil, &meta.ErrBadRequest{
+			Reason: "Tail must not be negative.",
+		}
+	}
+	if opts.Since != nil && opts.Until != nil && opts.Since.After(*opts.Until) {
+		return nil, &meta.ErrBadRequest{
+			Reason: "Since must not be after Until.",
+		}
+	}
+	if selector.All && opts.After != "" {
+		// Entries of a multiplexed stream carry no IDs, so a client can only be
+		// attempting to resume one using an ID obtained from some other stream.
+		return nil, &meta.ErrBadRequest{
+			Reason: "Multiplexed log streams cannot be resumed.",
+		}
+	}
+
 	event, err := l.eventsStore.Get(ctx, eventID)
 	if err != nil {
 		return nil,
 			errors.Wrapf(err, "error retrieving event %q from store", eventID)
 	}
 
-	if err = l.authorize(
+	if err = l.projectAuthorize(
 		ctx,
-		authx.RoleProjectUser(event.ProjectID),
+		event.ProjectID,
+		authx.PermissionLogsGet,
 	); err != nil {
 		return nil, err
 	}
@@ -128,12 +224,327 @@ func (l *logsService) Stream(
 			)
 	}
 
-	// Try warm logs first and fall back on cooler logs if necessary
+	redactor, err := l.getRedactor(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	if selector.All {
+		return l.streamAll(ctx, project, event, opts, redactor), nil
+	}
+
+	return l.streamLogs(ctx, project, event, selector, opts, redactor)
+}
+
+// getRedactor returns a redaction.Redactor that masks the values of the
+// specified Project's Secrets, or nil if the Project has opted out of secret
+// redaction. Logs in the cool logs store were already redacted by the logging
+// agent as they were stored, but logs are redacted again as they're served
+// because warm logs are not and because a Secret may have been set since.
+func (l *logsService) getRedactor(
+	ctx context.Context,
+	project Project,
+) (redaction.Redactor, error) {
+	mask := defaultRedactionMask
+	if project.Spec.Logs != nil {
+		if project.Spec.Logs.DisableSecretRedaction {
+			return nil, nil
+		}
+		if project.Spec.Logs.RedactionMask != "" {
+			mask = project.Spec.Logs.RedactionMask
+		}
+	}
+	secretValues, err := l.secretsStore.GetValues(ctx, project)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error retrieving secret values for project %q from store",
+			project.ID,
+		)
+	}
+	// Logs are redacted one line at a time, so multi-line values (e.g. PEM
+	// encoded keys) would never be matched in their entirety
+	return redaction.NewRedactor(redaction.SplitLines(secretValues), mask), nil
+}
+
+// streamLogs opens a log stream for a single container, trying warm logs first
+// and falling back on cooler logs if necessary. If a non-nil redaction.Redactor
+// is provided, it is applied to every LogEntry before it is sent.
+func (l *logsService) streamLogs(
+	ctx context.Context,
+	project Project,
+	event Event,
+	selector LogsSelector,
+	opts LogStreamOptions,
+	redactor redaction.Redactor,
+) (<-chan LogEntry, error) {
 	logCh, err := l.warmLogsStore.StreamLogs(ctx, project, event, selector, opts)
 	if err != nil {
 		logCh, err = l.coolLogsStore.StreamLogs(ctx, project, event, selector, opts)
 	}
-	return logCh, err
+	if err != nil || redactor == nil {
+		return logCh, err
+	}
+	redactedLogCh := make(chan LogEntry)
+	go func() {
+		defer close(redactedLogCh)
+		for logEntry := range logCh {
+			logEntry.Message = redactor.Redact(logEntry.Message)
+			select {
+			case redactedLogCh <- logEntry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return redactedLogCh, nil
+}
+
+// streamAll multiplexes logs from all containers of the specified Event's
+// Worker and all of its Jobs into a single stream. Log entries are tagged with
+// their source and interleaved by timestamp. When following, Jobs spawned by
+// the Worker after the stream was opened are discovered and included, and the
+// stream ends once the Worker has reached a terminal phase and every container
+// has been streamed in full. Any Tail option is applied to each container
+// individually.
+func (l *logsService) streamAll(
+	ctx context.Context,
+	project Project,
+	event Event,
+	opts LogStreamOptions,
+	redactor redaction.Redactor,
+) <-chan LogEntry {
+	// Timestamps are required for interleaving. If the client didn't ask for
+	// them, they're stripped from each LogEntry before it is sent.
+	sourceOpts := opts
+	sourceOpts.Timestamps = true
+
+	type sourceEntry struct {
+		index int
+		entry LogEntry
+		done  bool
+	}
+	type source struct {
+		queue        []LogEntry
+		lastActivity time.Time
+		done         bool
+	}
+
+	inCh := make(chan sourceEntry)
+	var sources []*source
+	started := map[LogEntrySource]struct{}{}
+
+	// startSources opens a stream for every container of the Worker and every
+	// Job that has progressed beyond the PENDING phase and isn't already being
+	// streamed. Containers whose streams cannot (yet) be opened are retried the
+	// next time this is called.
+	startSources := func(event Event) {
+		for _, selector := range containerLogsSelectors(event) {
+			src := LogEntrySource{Job: selector.Job, Container: selector.Container}
+			if _, ok := started[src]; ok {
+				continue
+			}
+			logCh, err := l.streamLogs(
+				ctx,
+				project,
+				event,
+				selector,
+				sourceOpts,
+				redactor,
+			)
+			if err != nil {
+				continue
+			}
+			started[src] = struct{}{}
+			index := len(sources)
+			sources = append(sources, &source{lastActivity: time.Now()})
+			go func() {
+				for logEntry := range logCh {
+					if logEntry.Time == nil {
+						now := time.Now()
+						logEntry.Time = &now
+					}
+					logEntry.Source = &src
+					// IDs from individual containers' streams are meaningless in the
+					// context of the multiplexed stream. Without them, SSE clients will
+					// not attempt to resume the stream with a Last-Event-ID.
+					logEntry.ID = ""
+					select {
+					case inCh <- sourceEntry{index: index, entry: logEntry}:
+					case <-ctx.Done():
+						return
+					}
+				}
+				select {
+				case inCh <- sourceEntry{index: index, done: true}:
+				case <-ctx.Done():
+				}
+			}()
+		}
+	}
+
+	logEntryCh := make(chan LogEntry)
+	go func() {
+		defer close(logEntryCh)
+
+		startSources(event)
+
+		// send emits, in chronological order, every queued log entry that can be
+		// emitted without risk of a more recent entry having already been sent.
+		// This is the case only while every source that is still open either has
+		// queued entries or has been idle for long enough that it is presumed to
+		// have caught up. If flush is true, all queued entries are emitted.
+		send := func(flush bool) bool {
+			for {
+				var next *source
+				for _, src := range sources {
+					if len(src.queue) == 0 {
+						if !flush && !src.done &&
+							time.Since(src.lastActivity) < logsReorderWindow {
+							return true
+						}
+						continue
+					}
+					if next == nil || src.queue[0].Time.Before(*next.queue[0].Time) {
+						next = src
+					}
+				}
+				if next == nil {
+					return true
+				}
+				logEntry := next.queue[0]
+				next.queue = next.queue[1:]
+				if !opts.Timestamps {
+					logEntry.Time = nil
+				}
+				select {
+				case logEntryCh <- logEntry:
+				case <-ctx.Done():
+					return false
+				}
+			}
+		}
+
+		ticker := time.NewTicker(logsReorderWindow / 4)
+		defer ticker.Stop()
+		// workerDone is only ever set when following; it records that the most
+		// recent refresh of the Event found its Worker in a terminal phase, after
+		// which no new sources can appear.
+		var workerDone bool
+		var refreshCh <-chan time.Time
+		if opts.Follow {
+			refreshTicker := time.NewTicker(logsJobDiscoveryInterval)
+			defer refreshTicker.Stop()
+			refreshCh = refreshTicker.C
+		}
+		for {
+			select {
+			case in := <-inCh:
+				src := sources[in.index]
+				src.lastActivity = time.Now()
+				if in.done {
+					src.done = true
+				} else {
+					src.queue = append(src.queue, in.entry)
+				}
+			case <-ticker.C:
+			case <-refreshCh:
+				refreshedEvent, err := l.eventsStore.Get(ctx, event.ID)
+				if err != nil {
+					log.Println(
+						errors.Wrapf(err, "error refreshing event %q", event.ID),
+					)
+				} else {
+					startSources(refreshedEvent)
+					workerDone = refreshedEvent.Worker.Status.Phase.IsTerminal()
+				}
+			case <-ctx.Done():
+				return
+			}
+			allDone := true
+			for _, src := range sources {
+				if !src.done {
+					allDone = false
+					break
+				}
+			}
+			if allDone && (!opts.Follow || workerDone) {
+				send(true)
+				return
+			}
+			if !send(false) {
+				return
+			}
+		}
+	}()
+
+	return logEntryCh
+}
+
+// NewTimeBasedLogEntryID returns a LogEntry ID derived from the time the line
+// was written and the number of lines preceding it that were written at
+// precisely the same time. This is useful to LogsStore implementations whose
+// underlying storage does not natively assign IDs to lines of logs.
+func NewTimeBasedLogEntryID(t time.Time, ordinal int) string {
+	return fmt.Sprintf("%d-%d", t.UnixNano(), ordinal)
+}
+
+// ParseTimeBasedLogEntryID parses a LogEntry ID created using the
+// NewTimeBasedLogEntryID function, returning the time and ordinal from which
+// it was derived.
+func ParseTimeBasedLogEntryID(id string) (time.Time, int, error) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, errors.Errorf("invalid log entry ID %q", id)
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errors.Errorf("invalid log entry ID %q", id)
+	}
+	ordinal, err := strconv.Atoi(parts[1])
+	if err != nil || ordinal < 0 {
+		return time.Time{}, 0, errors.Errorf("invalid log entry ID %q", id)
+	}
+	return time.Unix(0, nanos).UTC(), ordinal, nil
+}
+
+// containerLogsSelectors returns a LogsSelector for every container of the
+// provided Event's Worker and of every Job it has spawned, excluding any that
+// have not yet progressed beyond the PENDING phase. The Worker is always listed
+// first, followed by Jobs and their containers, sorted by name.
+func containerLogsSelectors(event Event) []LogsSelector {
+	selectors := []LogsSelector{}
+	if event.Worker.Status.Phase != WorkerPhasePending {
+		selectors = append(selectors, LogsSelector{Container: "worker"})
+	}
+	jobNames := make([]string, 0, len(event.Worker.Jobs))
+	for jobName := range event.Worker.Jobs {
+		jobNames = append(jobNames, jobName)
+	}
+	sort.Strings(jobNames)
+	for _, jobName := range jobNames {
+		job := event.Worker.Jobs[jobName]
+		if job.Status == nil || job.Status.Phase == "" ||
+			job.Status.Phase == JobPhasePending {
+			continue
+		}
+		selectors = append(
+			selectors,
+			LogsSelector{Job: jobName, Container: jobName},
+		)
+		sidecarNames := make([]string, 0, len(job.Spec.SidecarContainers))
+		for sidecarName := range job.Spec.SidecarContainers {
+			sidecarNames = append(sidecarNames, sidecarName)
+		}
+		sort.Strings(sidecarNames)
+		for _, sidecarName := range sidecarNames {
+			selectors = append(
+				selectors,
+				LogsSelector{Job: jobName, Container: sidecarName},
+			)
+		}
+	}
+	return selectors
 }
 
 // LogsStore is an interface for components that implement Log persistence
@@ -151,4 +562,9 @@ type LogsStore interface {
 		selector LogsSelector,
 		opts LogStreamOptions,
 	) (<-chan LogEntry, error)
+	// DeleteEventLogs deletes all logs pertaining to the specified Event's
+	// Worker and Jobs. Implementations whose underlying store does not permit
+	// deletion (e.g. a capped MongoDB collection prior to MongoDB 5.0) MUST
+	// leave the logs to age out and return no error.
+	DeleteEventLogs(ctx context.Context, project Project, event Event) error
 }
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/redaction"
+	"github.com/pkg/errors"
+)
+
+// LogsBundleManifest describes the contents of a LogsBundle.
+type LogsBundleManifest struct {
+	// Created indicates the time at which the LogsBundle was created.
+	Created time.Time `json:"created"`
+	// Event is the Event whose logs are contained in the LogsBundle. This
+	// includes the status and timing of its Worker and every Job.
+	Event Event `json:"event"`
+	// Files enumerates the log files contained in the LogsBundle.
+	Files []LogsBundleFile `json:"files"`
+}
+
+// LogsBundleFile describes a single log file contained in a LogsBundle.
+type LogsBundleFile struct {
+	// Path is the path to the file within the LogsBundle.
+	Path string `json:"path"`
+	// Source identifies the container whose logs are contained in the file.
+	Source LogEntrySource `json:"source"`
+	// Lines is the number of lines of logs contained in the file.
+	Lines int `json:"lines"`
+}
+
+// LogsBundle is a gzipped tarball containing all available logs for an Event,
+// with one file per container of the Event's Worker and of every Job it has
+// spawned, and a JSON manifest.
+type LogsBundle interface {
+	// Filename returns a suggested filename for the LogsBundle.
+	Filename() string
+	// Stream writes the LogsBundle to the provided io.Writer. Logs are retrieved
+	// as the LogsBundle is written.
+	Stream(ctx context.Context, w io.Writer) error
+}
+
+type logsBundle struct {
+	logsService *logsService
+	project     Project
+	event       Event
+	redactor    redaction.Redactor
+}
+
+func (l *logsService) Bundle(
+	ctx context.Context,
+	eventID string,
+) (LogsBundle, error) {
+	event, err := l.eventsStore.Get(ctx, eventID)
+	if err != nil {
+		return nil,
+			errors.Wrapf(err, "error retrieving event %q from store", eventID)
+	}
+
+	if err = l.projectAuthorize(
+		ctx,
+		event.ProjectID,
+		authx.PermissionLogsGet,
+	); err != nil {
+		return nil, err
+	}
+
+	project, err := l.projectsStore.Get(ctx, event.ProjectID)
+	if err != nil {
+		return nil,
+			errors.Wrapf(
+				err,
+				"error retrieving project %q from store",
+				event.ProjectID,
+			)
+	}
+
+	redactor, err := l.getRedactor(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logsBundle{
+		logsService: l,
+		project:     project,
+		event:       event,
+		redactor:    redactor,
+	}, nil
+}
+
+func (l *logsBundle) Filename() string {
+	return fmt.Sprintf("%s-logs.tar.gz", l.event.ID)
+}
+
+func (l *logsBundle) Stream(ctx context.Context, w io.Writer) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifest := LogsBundleManifest{
+		Created: time.Now().UTC(),
+		Event:   l.event,
+		Files:   []LogsBundleFile{},
+	}
+
+	for _, selector := range containerLogsSelectors(l.event) {
+		file := LogsBundleFile{
+			Source: LogEntrySource{
+				Job:       selector.Job,
+				Container: selector.Container,
+			},
+		}
+		if selector.Job == "" {
+			file.Path = path.Join("worker", fmt.Sprintf("%s.log", selector.Container))
+		} else {
+			file.Path = path.Join(
+				"jobs",
+				selector.Job,
+				fmt.Sprintf("%s.log", selector.Container),
+			)
+		}
+		logEntryCh, err := l.logsService.streamLogs(
+			ctx,
+			l.project,
+			l.event,
+			selector,
+			LogStreamOptions{Timestamps: true},
+			l.redactor,
+		)
+		if err != nil {
+			// No logs are available for this container from any tier. Omit it.
+			continue
+		}
+		// Tar headers must specify the size of the file that follows, so each
+		// file's contents are buffered before being written.
+		buf := &bytes.Buffer{}
+		for logEntry := range logEntryCh {
+			if logEntry.Time != nil {
+				fmt.Fprintf(
+					buf,
+					"%s %s\n",
+					logEntry.Time.UTC().Format(time.RFC3339Nano),
+					logEntry.Message,
+				)
+			} else {
+				fmt.Fprintln(buf, logEntry.Message)
+			}
+			file.Lines++
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = writeTarFile(
+			tarWriter,
+			file.Path,
+			manifest.Created,
+			buf.Bytes(),
+		); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error marshaling logs bundle manifest")
+	}
+	if err = writeTarFile(
+		tarWriter,
+		"manifest.json",
+		manifest.Created,
+		manifestBytes,
+	); err != nil {
+		return err
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return errors.Wrap(err, "error closing tar writer")
+	}
+	return errors.Wrap(gzipWriter.Close(), "error closing gzip writer")
+}
+
+// writeTarFile writes a single, regular file having the provided name,
+// modification time, and contents to the provided tar.Writer.
+func writeTarFile(
+	tarWriter *tar.Writer,
+	name string,
+	modTime time.Time,
+	contents []byte,
+) error {
+	if err := tarWriter.WriteHeader(
+		&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+			ModTime:  modTime,
+		},
+	); err != nil {
+		return errors.Wrapf(err, "error writing tar header for %q", name)
+	}
+	_, err := tarWriter.Write(contents)
+	return errors.Wrapf(err, "error writing %q to tar", name)
+}
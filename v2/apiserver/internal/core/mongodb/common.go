@@ -1,5 +0,0 @@
-package mongodb
-
-import "time"
-
-const createIndexTimeout = 5 * time.Second
@@ -0,0 +1,42 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// eventRetentionLockID is the ID of the lock that represents the lease on
+// periodic enforcement of Projects' EventRetentionPolicies.
+const eventRetentionLockID = "event-retention-lease"
+
+type eventRetentionLeaseStore struct {
+	database *mongo.Database
+}
+
+// NewEventRetentionLeaseStore returns a MongoDB-based implementation of the
+// core.EventRetentionLeaseStore interface.
+func NewEventRetentionLeaseStore(
+	database *mongo.Database,
+) core.EventRetentionLeaseStore {
+	return &eventRetentionLeaseStore{
+		database: database,
+	}
+}
+
+func (e *eventRetentionLeaseStore) AcquireLease(
+	ctx context.Context,
+	holder string,
+	ttl time.Duration,
+) (bool, error) {
+	return mongodb.TryAcquireLock(
+		ctx,
+		e.database,
+		eventRetentionLockID,
+		holder,
+		ttl,
+	)
+}
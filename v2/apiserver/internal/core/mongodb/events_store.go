@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,38 +19,7 @@ type eventsStore struct {
 }
 
 func NewEventsStore(database *mongo.Database) (core.EventsStore, error) {
-	ctx, cancel :=
-		context.WithTimeout(context.Background(), createIndexTimeout)
-	defer cancel()
-	unique := true
 	collection := database.Collection("events")
-	if _, err := collection.Indexes().CreateMany(
-		ctx,
-		[]mongo.IndexModel{
-			{
-				Keys: bson.M{
-					"id": 1,
-				},
-				Options: &options.IndexOptions{
-					Unique: &unique,
-				},
-			},
-			// This facilitates sorting by event creation date/time
-			{
-				Keys: bson.M{
-					"created": -1,
-				},
-			},
-			// This facilitates quickly selecting all events for a given project
-			{
-				Keys: bson.M{
-					"projectID": 1,
-				},
-			},
-		},
-	); err != nil {
-		return nil, errors.Wrap(err, "error adding indexes to events collection")
-	}
 	return &eventsStore{
 		collection: collection,
 	}, nil
@@ -80,19 +50,32 @@ func (e *eventsStore) List(
 	if selector.ProjectID != "" {
 		criteria["projectID"] = selector.ProjectID
 	}
+	applySelectorFilters(criteria, selector)
+
+	findCriteria := criteria
 	if opts.Continue != "" {
-		continueTime, err :=
-			time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", opts.Continue)
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
 		if err != nil {
-			return events, errors.Wrap(err, "error parsing continue time")
+			return events, err
+		}
+		findCriteria = bson.M{
+			"$and": []bson.M{
+				criteria,
+				mongodb.KeysetCriteria(continueCreated, continueID, true),
+			},
 		}
-		criteria["created"] = bson.M{"$lt": continueTime}
 	}
 
 	findOptions := options.Find()
-	findOptions.SetSort(bson.M{"created": -1})
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: -1},
+			{Key: "id", Value: -1},
+		},
+	)
 	findOptions.SetLimit(opts.Limit)
-	cur, err := e.collection.Find(ctx, criteria, findOptions)
+	cur, err := e.collection.Find(ctx, findCriteria, findOptions)
 	if err != nil {
 		return events, errors.Wrap(err, "error finding events")
 	}
@@ -101,14 +84,22 @@ func (e *eventsStore) List(
 	}
 
 	if int64(len(events.Items)) == opts.Limit {
-		continueTime := events.Items[opts.Limit-1].Created
-		criteria["created"] = bson.M{"$lt": continueTime}
-		remaining, err := e.collection.CountDocuments(ctx, criteria)
+		lastEvent := events.Items[opts.Limit-1]
+		remaining, err := e.collection.CountDocuments(
+			ctx,
+			bson.M{
+				"$and": []bson.M{
+					criteria,
+					mongodb.KeysetCriteria(lastEvent.Created, lastEvent.ID, true),
+				},
+			},
+		)
 		if err != nil {
 			return events, errors.Wrap(err, "error counting remaining events")
 		}
 		if remaining > 0 {
-			events.Continue = continueTime.String()
+			events.Continue =
+				mongodb.EncodeContinueToken(lastEvent.Created, lastEvent.ID)
 			events.RemainingItemCount = remaining
 		}
 	}
@@ -162,6 +153,25 @@ func (e *eventsStore) GetByHashedWorkerToken(
 	return event, nil
 }
 
+func (e *eventsStore) RehashWorkerToken(
+	ctx context.Context,
+	legacyHashedWorkerToken string,
+	hashedWorkerToken string,
+) error {
+	if _, err := e.collection.UpdateOne(
+		ctx,
+		bson.M{"worker.hashedToken": legacyHashedWorkerToken},
+		bson.M{
+			"$set": bson.M{
+				"worker.hashedToken": hashedWorkerToken,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "error updating worker token hash")
+	}
+	return nil
+}
+
 func (e *eventsStore) Cancel(ctx context.Context, id string) error {
 	if _, err := e.Get(ctx, id); err != nil {
 		return err
@@ -177,6 +187,9 @@ func (e *eventsStore) Cancel(ctx context.Context, id string) error {
 				"canceled":            time.Now(),
 				"worker.status.phase": core.WorkerPhaseCanceled,
 			},
+			"$unset": bson.M{
+				"worker.hashedToken": "",
+			},
 		},
 	)
 	if err != nil {
@@ -197,6 +210,9 @@ func (e *eventsStore) Cancel(ctx context.Context, id string) error {
 				"canceled":            time.Now(),
 				"worker.status.phase": core.WorkerPhaseAborted,
 			},
+			"$unset": bson.M{
+				"worker.hashedToken": "",
+			},
 		},
 	)
 	if err != nil {
@@ -249,6 +265,7 @@ func (e *eventsStore) CancelMany(
 	criteria := bson.M{
 		"projectID": selector.ProjectID,
 	}
+	applySelectorFilters(criteria, selector)
 
 	if cancelPending {
 		criteria["worker.status.phase"] = core.WorkerPhasePending
@@ -260,6 +277,9 @@ func (e *eventsStore) CancelMany(
 					"canceled":            cancellationTime,
 					"worker.status.phase": core.WorkerPhaseCanceled,
 				},
+				"$unset": bson.M{
+					"worker.hashedToken": "",
+				},
 			},
 		); err != nil {
 			return events, errors.Wrap(err, "error updating events")
@@ -276,6 +296,9 @@ func (e *eventsStore) CancelMany(
 					"canceled":            cancellationTime,
 					"worker.status.phase": core.WorkerPhaseAborted,
 				},
+				"$unset": bson.M{
+					"worker.hashedToken": "",
+				},
 			},
 		); err != nil {
 			return events, errors.Wrap(err, "error updating events")
@@ -339,6 +362,7 @@ func (e *eventsStore) DeleteMany(
 			"$exists": false,
 		},
 	}
+	applySelectorFilters(criteria, selector)
 	if _, err := e.collection.UpdateMany(
 		ctx,
 		criteria,
@@ -376,3 +400,33 @@ func (e *eventsStore) DeleteMany(
 
 	return events, nil
 }
+
+// applySelectorFilters amends the provided query criteria with conditions
+// derived from the optional filters of the provided core.EventsSelector.
+func applySelectorFilters(criteria bson.M, selector core.EventsSelector) {
+	if selector.Source != "" {
+		criteria["source"] = selector.Source
+	}
+	if selector.Type != "" {
+		criteria["type"] = selector.Type
+	}
+	if labelCriteria := mongodb.LabelSelectorCriteria(
+		"labels",
+		selector.LabelSelector,
+	); len(labelCriteria) > 0 {
+		criteria["$and"] = labelCriteria
+	}
+	if selector.CreatedAfter != nil || selector.CreatedBefore != nil {
+		createdCriteria := bson.M{}
+		if selector.CreatedAfter != nil {
+			createdCriteria["$gte"] = *selector.CreatedAfter
+		}
+		if selector.CreatedBefore != nil {
+			createdCriteria["$lt"] = *selector.CreatedBefore
+		}
+		criteria["created"] = createdCriteria
+	}
+	if selector.TitleSearch != "" {
+		criteria["$text"] = bson.M{"$search": selector.TitleSearch}
+	}
+}
@@ -2,18 +2,30 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// namespaceNotFoundErrCode is the code of the error MongoDB returns when a
+// command references a collection that does not exist.
+const namespaceNotFoundErrCode = 26
+
 type logsStore struct {
 	collection *mongo.Collection
+	// purgeable caches whether documents can be deleted from the collection. It
+	// remains nil until that has been determined.
+	purgeable   *bool
+	purgeableMu sync.Mutex
 }
 
 func NewLogsStore(database *mongo.Database) core.LogsStore {
@@ -30,12 +42,71 @@ func (l *logsStore) StreamLogs(
 	opts core.LogStreamOptions,
 ) (<-chan core.LogEntry, error) {
 	criteria := l.criteriaFromSelector(event.ID, selector)
+	if opts.Since != nil || opts.Until != nil {
+		timeCriteria := bson.M{}
+		if opts.Since != nil {
+			timeCriteria["$gte"] = *opts.Since
+		}
+		if opts.Until != nil {
+			timeCriteria["$lte"] = *opts.Until
+		}
+		criteria["time"] = timeCriteria
+	}
+
+	// afterTime and skip are used only when resuming from a time-based ID. skip
+	// is the number of entries bearing precisely afterTime that were already
+	// sent.
+	var afterTime time.Time
+	var skip int
+	if opts.After != "" {
+		// IDs of log entries retrieved from this store are ObjectIDs, but we'll
+		// also make a best effort at resuming streams that were initially served
+		// from a warmer store whose IDs are time-based.
+		if afterID, err := primitive.ObjectIDFromHex(opts.After); err == nil {
+			criteria["_id"] = bson.M{"$gt": afterID}
+		} else {
+			var afterOrdinal int
+			if afterTime, afterOrdinal, err =
+				core.ParseTimeBasedLogEntryID(opts.After); err != nil {
+				return nil, &meta.ErrBadRequest{
+					Reason: fmt.Sprintf("Invalid log entry ID %q.", opts.After),
+				}
+			}
+			// MongoDB stores times with millisecond precision
+			afterTime = afterTime.Truncate(time.Millisecond)
+			skip = afterOrdinal + 1
+			timeCriteria, ok := criteria["time"].(bson.M)
+			if !ok {
+				timeCriteria = bson.M{}
+				criteria["time"] = timeCriteria
+			}
+			// Several entries may share the time of the last one sent, so resume
+			// from that time, inclusive, and skip as many of them as were sent.
+			if since, ok := timeCriteria["$gte"].(time.Time); !ok ||
+				afterTime.After(since) {
+				timeCriteria["$gte"] = afterTime
+			}
+		}
+	}
+
+	findOpts := options.Find().SetCursorType(options.Tailable)
+	if opts.After == "" && opts.Tail > 0 {
+		// Tailable cursors cannot be sorted, but documents in a capped collection
+		// are always returned in insertion order, so we can obtain the last N
+		// matching documents by skipping over everything that precedes them.
+		count, err := l.collection.CountDocuments(ctx, criteria)
+		if err != nil {
+			return nil, errors.Wrap(err, "error counting log entries")
+		}
+		if count > opts.Tail {
+			findOpts.SetSkip(count - opts.Tail)
+		}
+	}
 
 	logEntryCh := make(chan core.LogEntry)
 	go func() {
 		defer close(logEntryCh)
 
-		cursorType := options.Tailable
 		var cur *mongo.Cursor
 		var err error
 		// Any attempt to open a cursor that initially retrieves nothing will yield
@@ -43,11 +114,7 @@ func (l *logsStore) StreamLogs(
 		// need to retry this until we get a "live" cursor or the context is
 		// canceled.
 		for {
-			cur, err = l.collection.Find(
-				ctx,
-				criteria,
-				&options.FindOptions{CursorType: &cursorType},
-			)
+			cur, err = l.collection.Find(ctx, criteria, findOpts)
 			if err != nil {
 				log.Println(
 					errors.Wrap(err, "error getting cursor for logs collection"),
@@ -58,8 +125,9 @@ func (l *logsStore) StreamLogs(
 				// We got a live cursor.
 				break
 			}
-			if !opts.Follow {
-				// If we're not following, just return. We're done.
+			if !opts.Follow || l.windowElapsed(opts) {
+				// If we're not following or the window of logs we're interested in has
+				// closed, just return. We're done.
 				return
 			}
 			select {
@@ -73,8 +141,9 @@ func (l *logsStore) StreamLogs(
 		for {
 			available = cur.TryNext(ctx)
 			if !available {
-				if !opts.Follow {
-					// If we're not following, just return. We're done.
+				if !opts.Follow || l.windowElapsed(opts) {
+					// If we're not following or the window of logs we're interested in
+					// has closed, just return. We're done.
 					return
 				}
 				select {
@@ -84,14 +153,26 @@ func (l *logsStore) StreamLogs(
 					return
 				}
 			}
-			logEntry := core.LogEntry{}
-			err = cur.Decode(&logEntry)
+			doc := struct {
+				ID            primitive.ObjectID `bson:"_id"`
+				core.LogEntry `bson:",inline"`
+			}{}
+			err = cur.Decode(&doc)
 			if err != nil {
 				log.Println(
 					errors.Wrapf(err, "error decoding log entry from collection"),
 				)
 				return
 			}
+			logEntry := doc.LogEntry
+			if skip > 0 && logEntry.Time != nil && logEntry.Time.Equal(afterTime) {
+				skip--
+				continue
+			}
+			logEntry.ID = doc.ID.Hex()
+			if !opts.Timestamps {
+				logEntry.Time = nil
+			}
 
 			select {
 			case logEntryCh <- logEntry:
@@ -104,6 +185,70 @@ func (l *logsStore) StreamLogs(
 	return logEntryCh, nil
 }
 
+func (l *logsStore) DeleteEventLogs(
+	ctx context.Context,
+	_ core.Project,
+	event core.Event,
+) error {
+	purgeable, err := l.isPurgeable(ctx)
+	if err != nil {
+		return err
+	}
+	if !purgeable {
+		// The logs will age out of the collection as it reaches its maximum size
+		return nil
+	}
+	if _, err := l.collection.DeleteMany(
+		ctx,
+		bson.M{"event": event.ID},
+	); err != nil {
+		return errors.Wrapf(err, "error deleting logs for event %q", event.ID)
+	}
+	return nil
+}
+
+// isPurgeable returns a bool indicating whether documents can be deleted from
+// the logs collection. Prior to MongoDB 5.0, documents cannot be deleted from
+// capped collections, which the logging agent creates by default.
+func (l *logsStore) isPurgeable(ctx context.Context) (bool, error) {
+	l.purgeableMu.Lock()
+	defer l.purgeableMu.Unlock()
+	if l.purgeable != nil {
+		return *l.purgeable, nil
+	}
+	database := l.collection.Database()
+	collStats := struct {
+		Capped bool `bson:"capped"`
+	}{}
+	if err := database.RunCommand(
+		ctx,
+		bson.D{{Key: "collStats", Value: l.collection.Name()}},
+	).Decode(&collStats); err != nil {
+		if cmdErr, ok := err.(mongo.CommandError); ok &&
+			cmdErr.Code == namespaceNotFoundErrCode {
+			// Nothing has been logged yet, so there's nothing to delete
+			return false, nil
+		}
+		return false, errors.Wrap(err, "error retrieving logs collection stats")
+	}
+	purgeable := !collStats.Capped
+	if collStats.Capped {
+		buildInfo := struct {
+			VersionArray []int32 `bson:"versionArray"`
+		}{}
+		if err := database.RunCommand(
+			ctx,
+			bson.D{{Key: "buildInfo", Value: 1}},
+		).Decode(&buildInfo); err != nil {
+			return false, errors.Wrap(err, "error retrieving database build info")
+		}
+		purgeable =
+			len(buildInfo.VersionArray) > 0 && buildInfo.VersionArray[0] >= 5
+	}
+	l.purgeable = &purgeable
+	return purgeable, nil
+}
+
 func (l *logsStore) criteriaFromSelector(
 	eventID string,
 	selector core.LogsSelector,
@@ -134,3 +279,10 @@ func (l *logsStore) criteriaFromSelector(
 
 	return criteria
 }
+
+// windowElapsed returns a bool indicating whether the end of the window of
+// logs specified by the provided LogStreamOptions lies in the past. Once it
+// does, there is no point in waiting for additional logs to become available.
+func (l *logsStore) windowElapsed(opts core.LogStreamOptions) bool {
+	return opts.Until != nil && time.Now().After(*opts.Until)
+}
@@ -3,9 +3,9 @@ package mongodb
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/mongodb"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,29 +19,7 @@ type projectsStore struct {
 }
 
 func NewProjectsStore(database *mongo.Database) (core.ProjectsStore, error) {
-	ctx, cancel :=
-		context.WithTimeout(context.Background(), createIndexTimeout)
-	defer cancel()
-	unique := true
 	collection := database.Collection("projects")
-	if _, err := collection.Indexes().CreateMany(
-		ctx,
-		[]mongo.IndexModel{
-			{
-				Keys: bson.M{
-					"id": 1,
-				},
-				Options: &options.IndexOptions{
-					Unique: &unique,
-				},
-			},
-		},
-	); err != nil {
-		return nil, errors.Wrap(
-			err,
-			"error adding indexes to projects collection",
-		)
-	}
 	return &projectsStore{
 		collection:       collection,
 		eventsCollection: database.Collection("events"),
@@ -73,19 +51,43 @@ func (p *projectsStore) Create(
 
 func (p *projectsStore) List(
 	ctx context.Context,
+	selector core.ProjectsSelector,
 	opts meta.ListOptions,
 ) (core.ProjectList, error) {
 	projects := core.ProjectList{}
 
 	criteria := bson.M{}
+	if labelCriteria := mongodb.LabelSelectorCriteria(
+		"metadataLabels",
+		selector.LabelSelector,
+	); len(labelCriteria) > 0 {
+		criteria["$and"] = labelCriteria
+	}
+
+	findCriteria := criteria
 	if opts.Continue != "" {
-		criteria["id"] = bson.M{"$gt": opts.Continue}
+		continueCreated, continueID, err :=
+			mongodb.ParseContinueToken(opts.Continue)
+		if err != nil {
+			return projects, err
+		}
+		findCriteria = bson.M{
+			"$and": []bson.M{
+				criteria,
+				mongodb.KeysetCriteria(continueCreated, continueID, false),
+			},
+		}
 	}
 
 	findOptions := options.Find()
-	findOptions.SetSort(bson.M{"id": 1})
+	findOptions.SetSort(
+		bson.D{
+			{Key: "created", Value: 1},
+			{Key: "id", Value: 1},
+		},
+	)
 	findOptions.SetLimit(opts.Limit)
-	cur, err := p.collection.Find(ctx, criteria, findOptions)
+	cur, err := p.collection.Find(ctx, findCriteria, findOptions)
 	if err != nil {
 		return projects, errors.Wrap(err, "error finding projects")
 	}
@@ -94,14 +96,22 @@ func (p *projectsStore) List(
 	}
 
 	if int64(len(projects.Items)) == opts.Limit {
-		continueID := projects.Items[opts.Limit-1].ID
-		criteria["id"] = bson.M{"$gt": continueID}
-		remaining, err := p.collection.CountDocuments(ctx, criteria)
+		lastItem := projects.Items[opts.Limit-1]
+		remaining, err := p.collection.CountDocuments(
+			ctx,
+			bson.M{
+				"$and": []bson.M{
+					criteria,
+					mongodb.KeysetCriteria(lastItem.Created, lastItem.ID, false),
+				},
+			},
+		)
 		if err != nil {
 			return projects, errors.Wrap(err, "error counting remaining projects")
 		}
 		if remaining > 0 {
-			projects.Continue = continueID
+			projects.Continue =
+				mongodb.EncodeContinueToken(lastItem.Created, lastItem.ID)
 			projects.RemainingItemCount = remaining
 		}
 	}
@@ -179,29 +189,60 @@ func (p *projectsStore) Get(
 
 func (p *projectsStore) Update(
 	ctx context.Context, project core.Project,
-) error {
-	res, err := p.collection.UpdateOne(
+) (core.Project, error) {
+	criteria := bson.M{
+		"id": project.ID,
+	}
+	if project.ResourceVersion != 0 {
+		criteria["resourceVersion"] = project.ResourceVersion
+	}
+	res := p.collection.FindOneAndUpdate(
 		ctx,
-		bson.M{
-			"id": project.ID,
-		},
+		criteria,
 		bson.M{
 			"$set": bson.M{
-				"lastUpdated": time.Now(),
-				"spec":        project.Spec,
+				"metadataLabels":      project.Labels,
+				"metadataAnnotations": project.Annotations,
+				"lastUpdated":         project.LastUpdated,
+				"description":         project.Description,
+				"spec":                project.Spec,
+			},
+			"$inc": bson.M{
+				"resourceVersion": 1,
 			},
 		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
 	)
-	if err != nil {
-		return errors.Wrapf(err, "error replacing project %q", project.ID)
-	}
-	if res.MatchedCount == 0 {
-		return &meta.ErrNotFound{
+	if res.Err() == mongo.ErrNoDocuments {
+		if project.ResourceVersion == 0 {
+			return project, &meta.ErrNotFound{
+				Type: "Project",
+				ID:   project.ID,
+			}
+		}
+		// Figure out whether the project doesn't exist or exists at a different
+		// version.
+		if _, err := p.Get(ctx, project.ID); err != nil {
+			return project, err
+		}
+		return project, &meta.ErrConflict{
 			Type: "Project",
 			ID:   project.ID,
+			Reason: fmt.Sprintf(
+				"Project %q has been modified since version %d was retrieved.",
+				project.ID,
+				project.ResourceVersion,
+			),
 		}
 	}
-	return nil
+	if res.Err() != nil {
+		return project,
+			errors.Wrapf(res.Err(), "error updating project %q", project.ID)
+	}
+	if err := res.Decode(&project); err != nil {
+		return project, errors.Wrapf(err, "error decoding project %q", project.ID)
+	}
+	return project, nil
 }
 
 func (p *projectsStore) Delete(ctx context.Context, id string) error {
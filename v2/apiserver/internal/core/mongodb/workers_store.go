@@ -55,14 +55,22 @@ func (w *workersStore) UpdateStatus(
 	eventID string,
 	status core.WorkerStatus,
 ) error {
+	update := bson.M{
+		"$set": bson.M{
+			"worker.status": status,
+		},
+	}
+	// Revoke the token of a Worker that is done with it in the same update, so
+	// there is never a moment when a finished Worker's token remains usable
+	if status.Phase.IsTerminal() {
+		update["$unset"] = bson.M{
+			"worker.hashedToken": "",
+		}
+	}
 	res, err := w.eventsCollection.UpdateOne(
 		ctx,
 		bson.M{"id": eventID},
-		bson.M{
-			"$set": bson.M{
-				"worker.status": status,
-			},
-		},
+		update,
 	)
 	if err != nil {
 		return errors.Wrapf(
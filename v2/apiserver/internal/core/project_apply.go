@@ -0,0 +1,268 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+)
+
+// ProjectApplyAction represents the outcome of applying a Project definition.
+type ProjectApplyAction string
+
+const (
+	// ProjectApplyActionCreated represents the outcome wherein a Project did not
+	// previously exist and was created.
+	ProjectApplyActionCreated ProjectApplyAction = "CREATED"
+	// ProjectApplyActionUnchanged represents the outcome wherein a Project
+	// already existed and matched the applied definition.
+	ProjectApplyActionUnchanged ProjectApplyAction = "UNCHANGED"
+	// ProjectApplyActionUpdated represents the outcome wherein a Project already
+	// existed and was updated to match the applied definition.
+	ProjectApplyActionUpdated ProjectApplyAction = "UPDATED"
+)
+
+// ProjectApplyResult describes the outcome of applying a Project definition.
+type ProjectApplyResult struct {
+	// Action indicates what was (or, in the case of a dry run, what would have
+	// been) done to bring the Project in line with the applied definition.
+	Action ProjectApplyAction `json:"action"`
+	// DryRun indicates whether the apply operation was a dry run. If so, no
+	// changes were persisted.
+	DryRun bool `json:"dryRun"`
+	// Changes enumerates differences between the existing Project and the
+	// applied definition. It is empty if the Project did not previously exist.
+	Changes []ProjectChange `json:"changes,omitempty"`
+	// Project is the Project as it exists (or, in the case of a dry run, as it
+	// would exist) following the apply operation.
+	Project Project `json:"project"`
+}
+
+// MarshalJSON amends ProjectApplyResult instances with type metadata.
+func (p ProjectApplyResult) MarshalJSON() ([]byte, error) {
+	type Alias ProjectApplyResult
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "ProjectApplyResult",
+			},
+			Alias: (Alias)(p),
+		},
+	)
+}
+
+// ProjectApplyOptions represents useful, optional settings for applying a
+// Project definition.
+type ProjectApplyOptions struct {
+	// DryRun indicates that changes should be computed and reported, but not
+	// persisted.
+	DryRun bool
+	// OwningTeam, if specified, identifies a Team that is to own the Project if
+	// it does not already exist and is created. It has no effect on an existing
+	// Project. See ProjectCreateOptions.
+	OwningTeam string
+}
+
+// ProjectChange describes a single difference between an existing Project and
+// an applied Project definition.
+type ProjectChange struct {
+	// Path is the path to the field that differs, e.g.
+	// spec.eventSubscriptions[0].types.
+	Path string `json:"path"`
+	// Old is the field's existing value. It is omitted if the field is being
+	// added.
+	Old interface{} `json:"old,omitempty"`
+	// New is the field's applied value. It is omitted if the field is being
+	// removed.
+	New interface{} `json:"new,omitempty"`
+}
+
+func (p *projectsService) Apply(
+	ctx context.Context,
+	project Project,
+	opts ProjectApplyOptions,
+) (ProjectApplyResult, error) {
+	result := ProjectApplyResult{
+		DryRun: opts.DryRun,
+	}
+
+	// Authorize before consulting the store so that unauthorized callers cannot
+	// learn whether the Project exists. Callers permitted to update the Project
+	// get past this point, as do callers permitted to create Projects, although
+	// the latter may only apply a Project that does not exist yet.
+	updateErr := p.projectAuthorize(
+		ctx,
+		project.ID,
+		authx.PermissionProjectsUpdate,
+	)
+	if updateErr != nil {
+		if err := p.authorize(ctx, authx.RoleProjectCreator()); err != nil {
+			return result, updateErr
+		}
+	}
+
+	existingProject, err := p.projectsStore.Get(ctx, project.ID)
+	if err != nil {
+		if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+			return result, errors.Wrapf(
+				err,
+				"error retrieving project %q from store",
+				project.ID,
+			)
+		}
+		result.Action = ProjectApplyActionCreated
+		if opts.DryRun {
+			if err = p.authorize(ctx, authx.RoleProjectCreator()); err != nil {
+				return result, err
+			}
+			if opts.OwningTeam != "" {
+				if err = p.checkOwningTeam(ctx, opts.OwningTeam); err != nil {
+					return result, err
+				}
+			}
+			result.Project = project
+			return result, nil
+		}
+		result.Project, err = p.Create(
+			ctx,
+			project,
+			ProjectCreateOptions{OwningTeam: opts.OwningTeam},
+		)
+		return result, err
+	}
+
+	if updateErr != nil {
+		return result, updateErr
+	}
+
+	if result.Changes, err = diffProjects(existingProject, project); err != nil {
+		return result, err
+	}
+	if len(result.Changes) == 0 {
+		result.Action = ProjectApplyActionUnchanged
+		result.Project = existingProject
+		return result, nil
+	}
+
+	result.Action = ProjectApplyActionUpdated
+	if opts.DryRun {
+		result.Project = existingProject
+		result.Project.Description = project.Description
+		result.Project.Labels = project.Labels
+		result.Project.Annotations = project.Annotations
+		result.Project.Spec = project.Spec
+		return result, nil
+	}
+	result.Project, err = p.Update(ctx, project)
+	return result, err
+}
+
+// diffProjects enumerates differences between the client-writable fields of
+// the two provided Projects.
+func diffProjects(oldProject, newProject Project) ([]ProjectChange, error) {
+	toGeneric := func(project Project) (map[string]interface{}, error) {
+		projectBytes, err := json.Marshal(
+			struct {
+				Labels      meta.Labels      `json:"labels,omitempty"`
+				Annotations meta.Annotations `json:"annotations,omitempty"`
+				Description string           `json:"description,omitempty"`
+				Spec        ProjectSpec      `json:"spec"`
+			}{
+				Labels:      project.Labels,
+				Annotations: project.Annotations,
+				Description: project.Description,
+				Spec:        project.Spec,
+			},
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error marshaling project %q", project.ID)
+		}
+		generic := map[string]interface{}{}
+		return generic, errors.Wrapf(
+			json.Unmarshal(projectBytes, &generic),
+			"error unmarshaling project %q",
+			project.ID,
+		)
+	}
+	oldGeneric, err := toGeneric(oldProject)
+	if err != nil {
+		return nil, err
+	}
+	newGeneric, err := toGeneric(newProject)
+	if err != nil {
+		return nil, err
+	}
+	changes := []ProjectChange{}
+	diffValues("", oldGeneric, newGeneric, &changes)
+	return changes, nil
+}
+
+// diffValues recursively compares two values, as obtained from unmarshaling
+// JSON into an empty interface, and appends any differences to the provided
+// slice of ProjectChanges. Objects are compared field by field and arrays
+// element by element.
+func diffValues(
+	path string,
+	oldValue interface{},
+	newValue interface{},
+	changes *[]ProjectChange,
+) {
+	switch o := oldValue.(type) {
+	case map[string]interface{}:
+		if n, ok := newValue.(map[string]interface{}); ok {
+			keys := map[string]struct{}{}
+			for k := range o {
+				keys[k] = struct{}{}
+			}
+			for k := range n {
+				keys[k] = struct{}{}
+			}
+			sortedKeys := make([]string, 0, len(keys))
+			for k := range keys {
+				sortedKeys = append(sortedKeys, k)
+			}
+			sort.Strings(sortedKeys)
+			for _, k := range sortedKeys {
+				fieldPath := k
+				if path != "" {
+					fieldPath = fmt.Sprintf("%s.%s", path, k)
+				}
+				diffValues(fieldPath, o[k], n[k], changes)
+			}
+			return
+		}
+	case []interface{}:
+		if n, ok := newValue.([]interface{}); ok {
+			for i := 0; i < len(o) || i < len(n); i++ {
+				var oldElem, newElem interface{}
+				if i < len(o) {
+					oldElem = o[i]
+				}
+				if i < len(n) {
+					newElem = n[i]
+				}
+				diffValues(fmt.Sprintf("%s[%d]", path, i), oldElem, newElem, changes)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*changes = append(
+			*changes,
+			ProjectChange{
+				Path: path,
+				Old:  oldValue,
+				New:  newValue,
+			},
+		)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 )
 
@@ -15,12 +16,25 @@ type ProjectRolesService interface {
 
 	// TODO: This needs a function for listing available project roles
 
-	// TODO: This needs a function for listing role assignments by project
+	// List returns a RoleAssignmentList, with its Items (RoleAssignments)
+	// ordered by principal type, principal ID, role, and scope, for the
+	// specified Project that satisfy the criteria specified by the
+	// RoleAssignmentsSelector. Project-level Roles granted with global scope
+	// apply to every Project and are included. The selector's RoleType and Scope
+	// are ignored. If the specified Project does not exist, implementations must
+	// return a *meta.ErrNotFound error.
+	List(
+		ctx context.Context,
+		projectID string,
+		selector authx.RoleAssignmentsSelector,
+		opts meta.ListOptions,
+	) (authx.RoleAssignmentList, error)
 
 	// Grant grants the project-level Role specified by the RoleAssignment to the
 	// principal also specified by the RoleAssignment. If either of the specified
 	// Project or specified principal does not exist, implementations must return
-	// a *meta.ErrNotFound error.
+	// a *meta.ErrNotFound error. Callers may only grant Roles whose Permissions
+	// they themselves hold for the specified Project.
 	Grant(
 		ctx context.Context,
 		projectID string,
@@ -40,10 +54,13 @@ type ProjectRolesService interface {
 
 type projectRolesService struct {
 	authorize            authx.AuthorizeFn
+	projectAuthorize     authx.ProjectAuthorizeFn
 	projectsStore        ProjectsStore
 	usersStore           authx.UsersStore
 	serviceAccountsStore authx.ServiceAccountsStore
 	rolesStore           authx.RolesStore
+	teamsStore           authx.TeamsStore
+	roleDefinitionsStore authx.RoleDefinitionsStore
 }
 
 // NewProjectRolesService returns a specialized interface for managing
@@ -53,22 +70,70 @@ func NewProjectRolesService(
 	usersStore authx.UsersStore,
 	serviceAccountsStore authx.ServiceAccountsStore,
 	rolesStore authx.RolesStore,
+	teamsStore authx.TeamsStore,
+	roleDefinitionsStore authx.RoleDefinitionsStore,
+	projectAuthorize authx.ProjectAuthorizeFn,
 ) ProjectRolesService {
 	return &projectRolesService{
 		authorize:            authx.Authorize,
+		projectAuthorize:     projectAuthorize,
 		projectsStore:        projectsStore,
 		usersStore:           usersStore,
 		serviceAccountsStore: serviceAccountsStore,
 		rolesStore:           rolesStore,
+		teamsStore:           teamsStore,
+		roleDefinitionsStore: roleDefinitionsStore,
 	}
 }
 
+func (p *projectRolesService) List(
+	ctx context.Context,
+	projectID string,
+	selector authx.RoleAssignmentsSelector,
+	opts meta.ListOptions,
+) (authx.RoleAssignmentList, error) {
+	if err := p.authorize(ctx, authx.RoleReader()); err != nil {
+		return authx.RoleAssignmentList{}, err
+	}
+
+	// Make sure the project exists
+	if _, err := p.projectsStore.Get(ctx, projectID); err != nil {
+		return authx.RoleAssignmentList{}, errors.Wrapf(
+			err,
+			"error retrieving project %q from store",
+			projectID,
+		)
+	}
+
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+
+	// Project-level Roles granted with global scope apply to this Project too
+	selector.RoleType = authx.RoleTypeProject
+	selector.Scope = projectID
+	selector.IncludeGlobalScope = true
+	roleAssignments, err := p.rolesStore.List(ctx, selector, opts)
+	if err != nil {
+		return roleAssignments, errors.Wrapf(
+			err,
+			"error retrieving role assignments for project %q from store",
+			projectID,
+		)
+	}
+	return roleAssignments, nil
+}
+
 func (p *projectRolesService) Grant(
 	ctx context.Context,
 	projectID string,
 	roleAssignment authx.RoleAssignment,
 ) error {
-	if err := p.authorize(ctx, authx.RoleProjectAdmin(projectID)); err != nil {
+	if err := p.projectAuthorize(
+		ctx,
+		projectID,
+		authx.PermissionRoleAssignmentsCreate,
+	); err != nil {
 		return err
 	}
 
@@ -82,6 +147,31 @@ func (p *projectRolesService) Grant(
 		)
 	}
 
+	// Make sure the Role exists. Custom Roles must be defined before they can
+	// be granted.
+	permissions, ok := authx.BuiltInProjectRolePermissions(roleAssignment.Role)
+	if !ok {
+		roleDefinition, err := p.roleDefinitionsStore.Get(
+			ctx,
+			string(roleAssignment.Role),
+		)
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving role definition %q from store",
+				roleAssignment.Role,
+			)
+		}
+		permissions = roleDefinition.Permissions
+	}
+
+	// Make sure the caller isn't granting Permissions they don't hold
+	// themselves. Otherwise, anyone permitted to create role assignments could
+	// escalate their own privileges.
+	if err := p.projectAuthorize(ctx, projectID, permissions...); err != nil {
+		return err
+	}
+
 	if roleAssignment.PrincipalType == authx.PrincipalTypeUser {
 		// Make sure the User exists
 		if _, err := p.usersStore.Get(ctx, roleAssignment.PrincipalID); err != nil {
@@ -101,7 +191,18 @@ func (p *projectRolesService) Grant(
 				roleAssignment.PrincipalID,
 			)
 		}
-	} else {
+	} else if roleAssignment.PrincipalType == authx.PrincipalTypeTeam {
+		// Make sure the Team exists
+		if _, err := p.teamsStore.Get(ctx, roleAssignment.PrincipalID); err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving team %q from store",
+				roleAssignment.PrincipalID,
+			)
+		}
+	} else if roleAssignment.PrincipalType != authx.PrincipalTypeGroup {
+		// Groups are managed by the OpenID Connect identity provider, so there's
+		// no way to make sure one exists. Anything else is unsupported.
 		return nil
 	}
 
@@ -134,7 +235,11 @@ func (p *projectRolesService) Revoke(
 	projectID string,
 	roleAssignment authx.RoleAssignment,
 ) error {
-	if err := p.authorize(ctx, authx.RoleProjectAdmin(projectID)); err != nil {
+	if err := p.projectAuthorize(
+		ctx,
+		projectID,
+		authx.PermissionRoleAssignmentsDelete,
+	); err != nil {
 		return err
 	}
 
@@ -167,7 +272,18 @@ func (p *projectRolesService) Revoke(
 				roleAssignment.PrincipalID,
 			)
 		}
-	} else {
+	} else if roleAssignment.PrincipalType == authx.PrincipalTypeTeam {
+		// Make sure the Team exists
+		if _, err := p.teamsStore.Get(ctx, roleAssignment.PrincipalID); err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving team %q from store",
+				roleAssignment.PrincipalID,
+			)
+		}
+	} else if roleAssignment.PrincipalType != authx.PrincipalTypeGroup {
+		// Groups are managed by the OpenID Connect identity provider, so there's
+		// no way to make sure one exists. Anything else is unsupported.
 		return nil
 	}
 
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
@@ -11,6 +12,14 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// ProjectsSelector represents useful filter criteria when selecting multiple
+// Projects for API group operations like list.
+type ProjectsSelector struct {
+	// LabelSelector specifies requirements that a Project's labels must satisfy
+	// for the Project to be selected.
+	LabelSelector meta.LabelSelector
+}
+
 // ProjectList is an ordered and pageable list of Projects.
 type ProjectList struct {
 	// ListMeta contains list metadata.
@@ -77,6 +86,25 @@ type ProjectSpec struct {
 	EventSubscriptions []EventSubscription `json:"eventSubscriptions,omitempty" bson:"eventSubscriptions,omitempty"` // nolint: lll
 	// WorkerTemplate is a prototypical WorkerSpec.
 	WorkerTemplate WorkerSpec `json:"workerTemplate" bson:"workerTemplate"`
+	// Logs specifies how logs from the Project's Workers and Jobs are handled.
+	Logs *ProjectLogsConfig `json:"logs,omitempty" bson:"logs,omitempty"`
+	// EventRetention specifies when the Project's Events should be
+	// automatically deleted. If unspecified, Events are retained indefinitely.
+	EventRetention *EventRetentionPolicy `json:"eventRetention,omitempty" bson:"eventRetention,omitempty"` // nolint: lll
+}
+
+// ProjectLogsConfig represents Project-level configuration for logs from the
+// Project's Workers and Jobs.
+type ProjectLogsConfig struct {
+	// DisableSecretRedaction indicates whether occurrences of the values of the
+	// Project's Secrets should be left intact in logs as they are streamed or
+	// archived. By default, they are masked. This has no bearing on logs at rest
+	// in the underlying log store, wherein Secret values are always masked (using
+	// "***") by the logging agent before logs are stored.
+	DisableSecretRedaction bool `json:"disableSecretRedaction,omitempty" bson:"disableSecretRedaction,omitempty"` // nolint: lll
+	// RedactionMask is the string with which occurrences of Secret values are
+	// replaced. If unspecified, "***" is used.
+	RedactionMask string `json:"redactionMask,omitempty" bson:"redactionMask,omitempty"` // nolint: lll
 }
 
 // EventSubscription defines a set of Events of interest. ProjectSpecs utilize
@@ -127,24 +155,54 @@ type KubernetesConfig struct {
 	Namespace string `json:"namespace,omitempty" bson:"namespace,omitempty"`
 }
 
+// ProjectCreateOptions represents useful, optional settings for the creation
+// of a Project.
+type ProjectCreateOptions struct {
+	// OwningTeam, if specified, identifies a Team that is to own the new
+	// Project. The owning Team, rather than the principal creating the Project,
+	// is granted the built-in project-level Roles for the Project, so access to
+	// the Project follows membership in the Team. Only members of the Team and
+	// admins may create Projects owned by it.
+	OwningTeam string
+}
+
 // ProjectsService is the specialized interface for managing Projects. It's
 // decoupled from underlying technology choices (e.g. data store, message bus,
 // etc.) to keep business logic reusable and consistent while the underlying
 // tech stack remains free to change.
 type ProjectsService interface {
 	// Create creates a new Project. If a Project with the specified identifier
-	// already exists, implementations MUST return a *meta.ErrConflict error.
-	Create(context.Context, Project) (Project, error)
-	// List returns a ProjectList, with its Items (Projects) ordered
-	// alphabetically by Project ID.
-	List(context.Context, meta.ListOptions) (ProjectList, error)
+	// already exists, implementations MUST return a *meta.ErrConflict error. If
+	// a specified owning Team does not exist, implementations MUST return a
+	// *meta.ErrBadRequest error.
+	Create(context.Context, Project, ProjectCreateOptions) (Project, error)
+	// List returns a ProjectList, with its Items (Projects) ordered by age,
+	// oldest first. Criteria for which Projects should be retrieved can be
+	// specified using the ProjectsSelector parameter.
+	List(
+		context.Context,
+		ProjectsSelector,
+		meta.ListOptions,
+	) (ProjectList, error)
 	// Get retrieves a single Project specified by its identifier. If the
 	// specified Project does not exist, implementations MUST return a
 	// *meta.ErrNotFound error.
 	Get(context.Context, string) (Project, error)
 	// Update updates an existing Project. If the specified Project does not
-	// exist, implementations MUST return a *meta.ErrNotFound error.
+	// exist, implementations MUST return a *meta.ErrNotFound error. If the
+	// specified Project's ResourceVersion is non-zero and does not match that of
+	// the existing Project, implementations MUST return a *meta.ErrConflict
+	// error.
 	Update(context.Context, Project) (Project, error)
+	// Apply creates the specified Project if it does not already exist or
+	// updates it to match the specified definition if it does. Options govern
+	// whether changes are persisted and which Team, if any, owns the Project if
+	// it is created.
+	Apply(
+		ctx context.Context,
+		project Project,
+		opts ProjectApplyOptions,
+	) (ProjectApplyResult, error)
 	// Delete deletes a single Project specified by its identifier. If the
 	// specified Project does not exist, implementations MUST return a
 	// *meta.ErrNotFound error.
@@ -153,10 +211,12 @@ type ProjectsService interface {
 
 type projectsService struct {
 	authorize            authx.AuthorizeFn
+	projectAuthorize     authx.ProjectAuthorizeFn
 	projectsStore        ProjectsStore
 	usersStore           authx.UsersStore
 	serviceAccountsStore authx.ServiceAccountsStore
 	rolesStore           authx.RolesStore
+	teamsStore           authx.TeamsStore
 	substrate            Substrate
 }
 
@@ -166,14 +226,18 @@ func NewProjectsService(
 	usersStore authx.UsersStore,
 	serviceAccountsStore authx.ServiceAccountsStore,
 	rolesStore authx.RolesStore,
+	teamsStore authx.TeamsStore,
 	substrate Substrate,
+	projectAuthorize authx.ProjectAuthorizeFn,
 ) ProjectsService {
 	return &projectsService{
 		authorize:            authx.Authorize,
+		projectAuthorize:     projectAuthorize,
 		projectsStore:        projectsStore,
 		usersStore:           usersStore,
 		serviceAccountsStore: serviceAccountsStore,
 		rolesStore:           rolesStore,
+		teamsStore:           teamsStore,
 		substrate:            substrate,
 	}
 }
@@ -181,13 +245,23 @@ func NewProjectsService(
 func (p *projectsService) Create(
 	ctx context.Context,
 	project Project,
+	opts ProjectCreateOptions,
 ) (Project, error) {
 	if err := p.authorize(ctx, authx.RoleProjectCreator()); err != nil {
 		return project, err
 	}
 
+	if opts.OwningTeam != "" {
+		if err := p.checkOwningTeam(ctx, opts.OwningTeam); err != nil {
+			return project, err
+		}
+	}
+
 	now := time.Now()
 	project.Created = &now
+	project.LastUpdated = &now
+	project.CreatedBy = authx.PrincipalReferenceFromContext(ctx)
+	project.ResourceVersion = 1
 
 	// Add substrate-specific details before we persist.
 	var err error
@@ -211,19 +285,16 @@ func (p *projectsService) Create(
 		)
 	}
 
-	// Assign roles to the principal who created the project...
-
-	principal := authx.PincipalFromContext(ctx)
+	// Assign roles to the owning team or else to the principal who created the
+	// project...
 
 	var principalType authx.PrincipalType
 	var principalID string
-	if user, ok := principal.(*authx.User); ok {
-		principalType = authx.PrincipalTypeUser
-		principalID = user.ID
-	} else if serviceAccount, ok := principal.(*authx.ServiceAccount); ok {
-		principalType = authx.PrincipalTypeServiceAccount
-		serviceAccount.ID = user.ID
-	} else {
+	if opts.OwningTeam != "" {
+		principalType = authx.PrincipalTypeTeam
+		principalID = opts.OwningTeam
+	} else if principalType, principalID =
+		principalFromContext(ctx); principalType == "" {
 		return project, nil
 	}
 
@@ -249,8 +320,50 @@ func (p *projectsService) Create(
 	return project, nil
 }
 
+// checkOwningTeam returns an error if the specified Team does not exist or if
+// the principal associated with the provided Context may not create Projects
+// owned by it. Only members of the Team and admins may do so.
+func (p *projectsService) checkOwningTeam(
+	ctx context.Context,
+	teamID string,
+) error {
+	team, err := p.teamsStore.Get(ctx, teamID)
+	if err != nil {
+		if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
+			return &meta.ErrBadRequest{
+				Reason: fmt.Sprintf("Owning team %q does not exist.", teamID),
+			}
+		}
+		return errors.Wrapf(err, "error retrieving team %q from store", teamID)
+	}
+	principalType, principalID := principalFromContext(ctx)
+	for _, member := range team.Members {
+		if member.PrincipalType == principalType &&
+			member.PrincipalID == principalID {
+			return nil
+		}
+	}
+	return p.authorize(ctx, authx.RoleAdmin())
+}
+
+// principalFromContext returns the type and ID of the User or ServiceAccount
+// associated with the provided Context. Empty values are returned for any
+// other kind of principal.
+func principalFromContext(
+	ctx context.Context,
+) (authx.PrincipalType, string) {
+	switch principal := authx.PincipalFromContext(ctx).(type) {
+	case *authx.User:
+		return authx.PrincipalTypeUser, principal.ID
+	case *authx.ServiceAccount:
+		return authx.PrincipalTypeServiceAccount, principal.ID
+	}
+	return "", ""
+}
+
 func (p *projectsService) List(
 	ctx context.Context,
+	selector ProjectsSelector,
 	opts meta.ListOptions,
 ) (ProjectList, error) {
 	if err := p.authorize(ctx, authx.RoleReader()); err != nil {
@@ -260,7 +373,7 @@ func (p *projectsService) List(
 	if opts.Limit == 0 {
 		opts.Limit = 20
 	}
-	projects, err := p.projectsStore.List(ctx, opts)
+	projects, err := p.projectsStore.List(ctx, selector, opts)
 	if err != nil {
 		return projects, errors.Wrap(err, "error retrieving projects from store")
 	}
@@ -290,9 +403,10 @@ func (p *projectsService) Update(
 	ctx context.Context,
 	updatedProject Project,
 ) (Project, error) {
-	if err := p.authorize(
+	if err := p.projectAuthorize(
 		ctx,
-		authx.RoleProjectDeveloper(updatedProject.ID),
+		updatedProject.ID,
+		authx.PermissionProjectsUpdate,
 	); err != nil {
 		return Project{}, err
 	}
@@ -307,6 +421,9 @@ func (p *projectsService) Update(
 		)
 	}
 
+	now := time.Now()
+	updatedProject.LastUpdated = &now
+
 	// Update substrate-specific details before we persist.
 	if updatedProject, err =
 		p.substrate.PreUpdateProject(ctx, oldProject, updatedProject); err != nil {
@@ -317,7 +434,8 @@ func (p *projectsService) Update(
 		)
 	}
 
-	if err = p.projectsStore.Update(ctx, updatedProject); err != nil {
+	if updatedProject, err =
+		p.projectsStore.Update(ctx, updatedProject); err != nil {
 		return updatedProject, errors.Wrapf(
 			err,
 			"error updating project %q in store",
@@ -329,7 +447,11 @@ func (p *projectsService) Update(
 }
 
 func (p *projectsService) Delete(ctx context.Context, id string) error {
-	if err := p.authorize(ctx, authx.RoleProjectAdmin(id)); err != nil {
+	if err := p.projectAuthorize(
+		ctx,
+		id,
+		authx.PermissionProjectsDelete,
+	); err != nil {
 		return err
 	}
 
@@ -357,6 +479,7 @@ type ProjectsStore interface {
 	Create(context.Context, Project) error
 	List(
 		context.Context,
+		ProjectsSelector,
 		meta.ListOptions,
 	) (ProjectList, error)
 	ListSubscribers(
@@ -364,6 +487,10 @@ type ProjectsStore interface {
 		event Event,
 	) (ProjectList, error)
 	Get(context.Context, string) (Project, error)
-	Update(context.Context, Project) error
+	// Update updates an existing Project and returns the Project as it exists
+	// following the update. If the specified Project's ResourceVersion is
+	// non-zero and does not match that of the existing Project, implementations
+	// MUST return a *meta.ErrConflict error.
+	Update(context.Context, Project) (Project, error)
 	Delete(context.Context, string) error
 }
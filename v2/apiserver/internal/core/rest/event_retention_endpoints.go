@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/gorilla/mux"
+)
+
+type EventRetentionEndpoints struct {
+	*restmachinery.BaseEndpoints
+	Service core.EventRetentionService
+}
+
+func (e *EventRetentionEndpoints) Register(router *mux.Router) {
+	// Preview event retention
+	router.HandleFunc(
+		"/v2/projects/{id}/event-retention-report",
+		e.TokenAuthFilter.Decorate(e.preview),
+	).Methods(http.MethodGet)
+}
+
+func (e *EventRetentionEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method: http.MethodGet,
+			Path:   "/v2/projects/{id}/event-retention-report",
+			Summary: "Preview which of a project's events its retention policy " +
+				"would delete",
+		},
+	}
+}
+
+func (e *EventRetentionEndpoints) preview(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	e.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return e.Service.Preview(r.Context(), mux.Vars(r)["id"])
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
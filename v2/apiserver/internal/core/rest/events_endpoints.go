@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
@@ -63,6 +64,51 @@ func (e *EventsEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodDelete)
 }
 
+func (e *EventsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/events",
+			Summary:       "Create an event",
+			RequestSchema: "event.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v2/events",
+			Summary:     "List events",
+			QueryParams: restmachinery.ListQueryParams(eventsSelectorQueryParams...),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/events/{id}",
+			Summary: "Get an event",
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/v2/events/{id}/cancellation",
+			Summary: "Cancel an event",
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v2/events/cancellations",
+			Summary:     "Cancel a collection of events",
+			QueryParams: eventsSelectorQueryParams,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/events/{id}",
+			Summary: "Delete an event",
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/v2/events",
+			Summary:     "Delete a collection of events",
+			QueryParams: eventsSelectorQueryParams,
+		},
+	}
+}
+
 func (e *EventsEndpoints) create(w http.ResponseWriter, r *http.Request) {
 	event := core.Event{}
 	e.ServeRequest(
@@ -80,14 +126,15 @@ func (e *EventsEndpoints) create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (e *EventsEndpoints) list(w http.ResponseWriter, r *http.Request) {
-	selector := core.EventsSelector{
-		ProjectID: r.URL.Query().Get("projectID"),
+	selector, err := eventsSelectorFromQuery(r)
+	if err != nil {
+		e.WriteAPIResponse(w, http.StatusBadRequest, err)
+		return
 	}
 	opts := meta.ListOptions{
 		Continue: r.URL.Query().Get("continue"),
 	}
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		var err error
 		if opts.Limit, err = strconv.ParseInt(limitStr, 10, 64); err != nil ||
 			opts.Limit < 1 || opts.Limit > 100 {
 			e.WriteAPIResponse(
@@ -104,14 +151,6 @@ func (e *EventsEndpoints) list(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	workerPhasesStr := r.URL.Query().Get("workerPhases")
-	if workerPhasesStr != "" {
-		workerPhaseStrs := strings.Split(workerPhasesStr, ",")
-		selector.WorkerPhases = make([]core.WorkerPhase, len(workerPhaseStrs))
-		for i, workerPhaseStr := range workerPhaseStrs {
-			selector.WorkerPhases[i] = core.WorkerPhase(workerPhaseStr)
-		}
-	}
 	e.ServeRequest(
 		restmachinery.InboundRequest{
 			W: w,
@@ -154,16 +193,10 @@ func (e *EventsEndpoints) cancelMany(
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
-	selector := core.EventsSelector{
-		ProjectID: r.URL.Query().Get("projectID"),
-	}
-	workerPhasesStr := r.URL.Query().Get("workerPhases")
-	if workerPhasesStr != "" {
-		workerPhaseStrs := strings.Split(workerPhasesStr, ",")
-		selector.WorkerPhases = make([]core.WorkerPhase, len(workerPhaseStrs))
-		for i, workerPhaseStr := range workerPhaseStrs {
-			selector.WorkerPhases[i] = core.WorkerPhase(workerPhaseStr)
-		}
+	selector, err := eventsSelectorFromQuery(r)
+	if err != nil {
+		e.WriteAPIResponse(w, http.StatusBadRequest, err)
+		return
 	}
 	e.ServeRequest(
 		restmachinery.InboundRequest{
@@ -191,16 +224,10 @@ func (e *EventsEndpoints) delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (e *EventsEndpoints) deleteMany(w http.ResponseWriter, r *http.Request) {
-	selector := core.EventsSelector{
-		ProjectID: r.URL.Query().Get("projectID"),
-	}
-	workerPhasesStr := r.URL.Query().Get("workerPhases")
-	if workerPhasesStr != "" {
-		workerPhaseStrs := strings.Split(workerPhasesStr, ",")
-		selector.WorkerPhases = make([]core.WorkerPhase, len(workerPhaseStrs))
-		for i, workerPhaseStr := range workerPhaseStrs {
-			selector.WorkerPhases[i] = core.WorkerPhase(workerPhaseStr)
-		}
+	selector, err := eventsSelectorFromQuery(r)
+	if err != nil {
+		e.WriteAPIResponse(w, http.StatusBadRequest, err)
+		return
 	}
 	e.ServeRequest(
 		restmachinery.InboundRequest{
@@ -213,3 +240,84 @@ func (e *EventsEndpoints) deleteMany(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 }
+
+// eventsSelectorQueryParams describes the query parameters understood by
+// eventsSelectorFromQuery.
+var eventsSelectorQueryParams = []restmachinery.QueryParam{
+	{
+		Name:        "projectID",
+		Description: "Select only events for the specified project",
+	},
+	{
+		Name: "workerPhases",
+		Description: "Select only events whose workers are in one of the " +
+			"specified, comma-delimited phases, e.g. PENDING,RUNNING",
+	},
+	{
+		Name:        "source",
+		Description: "Select only events from the specified source",
+	},
+	{
+		Name:        "type",
+		Description: "Select only events of the specified type",
+	},
+	restmachinery.LabelsQueryParam,
+	{
+		Name:        "createdAfter",
+		Description: "Select only events created after this RFC3339 time",
+	},
+	{
+		Name:        "createdBefore",
+		Description: "Select only events created before this RFC3339 time",
+	},
+	{
+		Name:        "titleSearch",
+		Description: "Select only events whose titles match these search terms",
+	},
+}
+
+// eventsSelectorFromQuery builds a core.EventsSelector from the provided
+// request's query parameters. A *meta.ErrBadRequest is returned if any of
+// those parameters are invalid.
+func eventsSelectorFromQuery(r *http.Request) (core.EventsSelector, error) {
+	query := r.URL.Query()
+	selector := core.EventsSelector{
+		ProjectID:   query.Get("projectID"),
+		Source:      query.Get("source"),
+		Type:        query.Get("type"),
+		TitleSearch: query.Get("titleSearch"),
+	}
+	if workerPhasesStr := query.Get("workerPhases"); workerPhasesStr != "" {
+		workerPhaseStrs := strings.Split(workerPhasesStr, ",")
+		selector.WorkerPhases = make([]core.WorkerPhase, len(workerPhaseStrs))
+		for i, workerPhaseStr := range workerPhaseStrs {
+			selector.WorkerPhases[i] = core.WorkerPhase(workerPhaseStr)
+		}
+	}
+	if labelsStr := query.Get("labels"); labelsStr != "" {
+		var err error
+		if selector.LabelSelector, err =
+			meta.ParseLabelSelector(labelsStr); err != nil {
+			return selector, err
+		}
+	}
+	for param, t := range map[string]**time.Time{
+		"createdAfter":  &selector.CreatedAfter,
+		"createdBefore": &selector.CreatedBefore,
+	} {
+		if tStr := query.Get(param); tStr != "" {
+			parsed, err := time.Parse(time.RFC3339, tStr)
+			if err != nil {
+				return selector, &meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for %q query parameter`,
+						tStr,
+						param,
+					),
+				}
+			}
+			*t = &parsed
+		}
+	}
+	return selector, nil
+}
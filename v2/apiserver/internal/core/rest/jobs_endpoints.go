@@ -48,6 +48,41 @@ func (j *JobsEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodPut)
 }
 
+func (j *JobsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPut,
+			Path:          "/v2/events/{eventID}/worker/jobs/{jobName}",
+			Summary:       "Create a job",
+			RequestSchema: "job.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/v2/events/{eventID}/worker/jobs/{jobName}/start",
+			Summary: "Start a job",
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/events/{eventID}/worker/jobs/{jobName}/status",
+			Summary: "Get or stream a job's status",
+			QueryParams: []restmachinery.QueryParam{
+				{
+					Name: "watch",
+					Description: "Whether to stream status updates as server-sent " +
+						"events",
+				},
+			},
+		},
+		{
+			Method:        http.MethodPut,
+			Path:          "/v2/events/{eventID}/worker/jobs/{jobName}/status",
+			Summary:       "Update a job's status",
+			RequestSchema: "job-status.json",
+		},
+	}
+}
+
 func (j *JobsEndpoints) create(w http.ResponseWriter, r *http.Request) {
 	job := core.Job{}
 	j.ServeRequest(
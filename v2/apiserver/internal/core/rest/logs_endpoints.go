@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
@@ -14,6 +15,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// logsHeartbeatInterval is how often a comment is sent over an otherwise idle
+// log stream to prevent proxies from closing the connection.
+const logsHeartbeatInterval = 15 * time.Second
+
 type LogsEndpoints struct {
 	*restmachinery.BaseEndpoints
 	Service core.LogsService
@@ -25,6 +30,65 @@ func (l *LogsEndpoints) Register(router *mux.Router) {
 		"/v2/events/{id}/logs",
 		l.TokenAuthFilter.Decorate(l.stream),
 	).Methods(http.MethodGet)
+
+	// Download logs bundle
+	router.HandleFunc(
+		"/v2/events/{id}/logs/bundle",
+		l.TokenAuthFilter.Decorate(l.bundle),
+	).Methods(http.MethodGet)
+}
+
+func (l *LogsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/events/{id}/logs",
+			Summary: "Stream an event's logs as server-sent events",
+			QueryParams: []restmachinery.QueryParam{
+				{
+					Name: "job",
+					Description: "The job whose logs should be streamed; if not " +
+						"specified, the worker's logs are streamed",
+				},
+				{
+					Name:        "container",
+					Description: "The container whose logs should be streamed",
+				},
+				{
+					Name: "all",
+					Description: "Whether to stream logs from all containers; such " +
+						"streams cannot be resumed using the Last-Event-ID header",
+				},
+				{
+					Name:        "follow",
+					Description: "Whether to continue streaming new log entries",
+				},
+				{
+					Name:        "timestamps",
+					Description: "Whether to include a timestamp with each log entry",
+				},
+				{
+					Name:        "tail",
+					Description: "The number of most recent log entries to return",
+				},
+				{
+					Name:        "since",
+					Description: "Return only log entries after this RFC3339 time",
+				},
+				{
+					Name:        "until",
+					Description: "Return only log entries before this RFC3339 time",
+				},
+			},
+			SuccessContentType: "text/event-stream",
+		},
+		{
+			Method:             http.MethodGet,
+			Path:               "/v2/events/{id}/logs/bundle",
+			Summary:            "Download a gzipped bundle of all of an event's logs",
+			SuccessContentType: "application/gzip",
+		},
+	}
 }
 
 func (l *LogsEndpoints) stream(
@@ -34,41 +98,155 @@ func (l *LogsEndpoints) stream(
 	id := mux.Vars(r)["id"]
 	// nolint: errcheck
 	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+	// nolint: errcheck
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+	// nolint: errcheck
+	timestamps, _ := strconv.ParseBool(r.URL.Query().Get("timestamps"))
 
 	selector := core.LogsSelector{
 		Job:       r.URL.Query().Get("job"),
 		Container: r.URL.Query().Get("container"),
+		All:       all,
 	}
 	opts := core.LogStreamOptions{
-		Follow: follow,
+		Follow:     follow,
+		Timestamps: timestamps,
+		// Set by SSE clients when reconnecting after a dropped connection
+		After: r.Header.Get("Last-Event-ID"),
+	}
+	if tailStr := r.URL.Query().Get("tail"); tailStr != "" {
+		var err error
+		if opts.Tail, err = strconv.ParseInt(tailStr, 10, 64); err != nil ||
+			opts.Tail < 0 {
+			l.writeInvalidQueryParamResponse(w, "tail", tailStr)
+			return
+		}
+	}
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			l.writeInvalidQueryParamResponse(w, "since", sinceStr)
+			return
+		}
+		opts.Since = &since
+	}
+	if untilStr := r.URL.Query().Get("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			l.writeInvalidQueryParamResponse(w, "until", untilStr)
+			return
+		}
+		opts.Until = &until
 	}
 
 	logEntryCh, err := l.Service.Stream(r.Context(), id, selector, opts)
 	if err != nil {
-		if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
-			l.WriteAPIResponse(w, http.StatusNotFound, errors.Cause(err))
-			return
-		}
-		log.Println(
-			errors.Wrapf(err, "error retrieving log stream for event %q", id),
-		)
-		l.WriteAPIResponse(
+		l.writeError(
 			w,
-			http.StatusInternalServerError,
-			&meta.ErrInternalServer{},
+			errors.Wrapf(err, "error retrieving log stream for event %q", id),
 		)
 		return
 	}
 
 	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
 	w.(http.Flusher).Flush()
-	for logEntry := range logEntryCh {
-		logEntryBytes, err := json.Marshal(logEntry)
-		if err != nil {
-			log.Println(errors.Wrapf(err, "error marshaling log entry"))
+	heartbeatTicker := time.NewTicker(logsHeartbeatInterval)
+	defer heartbeatTicker.Stop()
+	for {
+		select {
+		case logEntry, ok := <-logEntryCh:
+			if !ok {
+				return
+			}
+			logEntryBytes, err := json.Marshal(logEntry)
+			if err != nil {
+				log.Println(errors.Wrapf(err, "error marshaling log entry"))
+				return
+			}
+			// JSON-encoded log entries never contain literal newlines, so each one
+			// fits in a single data field.
+			if logEntry.ID != "" {
+				fmt.Fprintf(w, "id: %s\n", logEntry.ID)
+			}
+			fmt.Fprintf(w, "data: %s\n\n", logEntryBytes)
+		case <-heartbeatTicker.C:
+			// Comments are ignored by SSE clients, but keep proxies from closing
+			// connections that otherwise appear idle.
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case <-r.Context().Done():
 			return
 		}
-		fmt.Fprint(w, string(logEntryBytes))
 		w.(http.Flusher).Flush()
 	}
 }
+
+func (l *LogsEndpoints) bundle(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	id := mux.Vars(r)["id"]
+
+	bundle, err := l.Service.Bundle(r.Context(), id)
+	if err != nil {
+		l.writeError(
+			w,
+			errors.Wrapf(err, "error retrieving logs bundle for event %q", id),
+		)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", bundle.Filename()),
+	)
+	w.WriteHeader(http.StatusOK)
+	if err = bundle.Stream(r.Context(), w); err != nil {
+		// It's too late to inform the client of the error via the status code, but
+		// the bundle will be truncated and therefore recognizably corrupt.
+		log.Println(
+			errors.Wrapf(err, "error writing logs bundle for event %q", id),
+		)
+	}
+}
+
+// writeError writes an appropriate API response for an error encountered
+// before any logs have been sent to the client.
+func (l *LogsEndpoints) writeError(w http.ResponseWriter, err error) {
+	switch e := errors.Cause(err).(type) {
+	case *meta.ErrAuthentication:
+		l.WriteAPIResponse(w, http.StatusUnauthorized, e)
+	case *meta.ErrAuthorization:
+		l.WriteAPIResponse(w, http.StatusForbidden, e)
+	case *meta.ErrBadRequest:
+		l.WriteAPIResponse(w, http.StatusBadRequest, e)
+	case *meta.ErrNotFound:
+		l.WriteAPIResponse(w, http.StatusNotFound, e)
+	default:
+		log.Println(err)
+		l.WriteAPIResponse(
+			w,
+			http.StatusInternalServerError,
+			&meta.ErrInternalServer{},
+		)
+	}
+}
+
+func (l *LogsEndpoints) writeInvalidQueryParamResponse(
+	w http.ResponseWriter,
+	param string,
+	value string,
+) {
+	l.WriteAPIResponse(
+		w,
+		http.StatusBadRequest,
+		&meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				`Invalid value %q for %q query parameter`,
+				value,
+				param,
+			),
+		},
+	)
+}
@@ -1,11 +1,15 @@
 package rest
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	authxREST "github.com/brigadecore/brigade/v2/apiserver/internal/authx/rest"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/gorilla/mux"
 	"github.com/xeipuuv/gojsonschema"
 )
@@ -17,6 +21,12 @@ type ProjectsRolesEndpoints struct {
 }
 
 func (p *ProjectsRolesEndpoints) Register(router *mux.Router) {
+	// List Project Role assignments
+	router.HandleFunc(
+		"/v2/projects/{projectID}/role-assignments",
+		p.TokenAuthFilter.Decorate(p.list),
+	).Methods(http.MethodGet)
+
 	// Grant a Project Role to a User or Service Account
 	router.HandleFunc(
 		"/v2/projects/{projectID}/role-assignments",
@@ -30,6 +40,82 @@ func (p *ProjectsRolesEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodDelete)
 }
 
+func (p *ProjectsRolesEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/projects/{projectID}/role-assignments",
+			Summary: "List a project's role assignments",
+			QueryParams: restmachinery.ListQueryParams(
+				authxREST.RoleAssignmentsSelectorQueryParams...,
+			),
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/v2/projects/{projectID}/role-assignments",
+			Summary: "Grant a project role to a user, service account, group, " +
+				"or team",
+			RequestSchema: "project-role-assignment.json",
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/v2/projects/{projectID}/role-assignments",
+			Summary: "Revoke a project role from a user, service account, group, " +
+				"or team",
+			QueryParams: []restmachinery.QueryParam{
+				{Name: "role", Description: "The role to revoke"},
+				{
+					Name: "principalType",
+					Description: "The type of principal; USER, SERVICE_ACCOUNT, " +
+						"GROUP, or TEAM",
+				},
+				{Name: "principalID", Description: "The ID of the principal"},
+			},
+		},
+	}
+}
+
+func (p *ProjectsRolesEndpoints) list(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	opts := meta.ListOptions{
+		Continue: r.URL.Query().Get("continue"),
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if opts.Limit, err = strconv.ParseInt(limitStr, 10, 64); err != nil ||
+			opts.Limit < 1 || opts.Limit > 100 {
+			p.WriteAPIResponse(
+				w,
+				http.StatusBadRequest,
+				&meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for "limit" query parameter`,
+						limitStr,
+					),
+				},
+			)
+			return
+		}
+	}
+	p.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return p.Service.List(
+					r.Context(),
+					mux.Vars(r)["projectID"],
+					authxREST.RoleAssignmentsSelectorFromQuery(r),
+					opts,
+				)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (p *ProjectsRolesEndpoints) grant(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -1,14 +1,20 @@
 package rest
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -43,6 +49,18 @@ func (p *ProjectsEndpoints) Register(router *mux.Router) {
 		p.TokenAuthFilter.Decorate(p.update),
 	).Methods(http.MethodPut)
 
+	// Apply Project
+	router.HandleFunc(
+		"/v2/projects/{id}/apply",
+		p.TokenAuthFilter.Decorate(p.apply),
+	).Methods(http.MethodPost)
+
+	// Patch Project
+	router.HandleFunc(
+		"/v2/projects/{id}",
+		p.TokenAuthFilter.Decorate(p.patch),
+	).Methods(http.MethodPatch)
+
 	// Delete Project
 	router.HandleFunc(
 		"/v2/projects/{id}",
@@ -50,6 +68,74 @@ func (p *ProjectsEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodDelete)
 }
 
+func (p *ProjectsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/projects",
+			Summary:       "Create a project",
+			QueryParams:   []restmachinery.QueryParam{teamQueryParam},
+			RequestSchema: "project.json",
+			SuccessCode:   http.StatusCreated,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/projects",
+			Summary: "List projects",
+			QueryParams: restmachinery.ListQueryParams(
+				restmachinery.LabelsQueryParam,
+			),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/projects/{id}",
+			Summary: "Get a project; the ETag header reflects its resource version",
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/v2/projects/{id}",
+			Summary: "Update a project; honors the If-Match header for " +
+				"optimistic concurrency",
+			RequestSchema: "project.json",
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/v2/projects/{id}/apply",
+			Summary: "Create or update a project to match the provided " +
+				"definition",
+			QueryParams: []restmachinery.QueryParam{
+				{
+					Name: "dryRun",
+					Description: "Whether to only report what would change without " +
+						"persisting anything",
+				},
+				teamQueryParam,
+			},
+			RequestSchema: "project.json",
+		},
+		{
+			Method: http.MethodPatch,
+			Path:   "/v2/projects/{id}",
+			Summary: "Patch a project using a JSON merge patch " +
+				"(application/merge-patch+json) or a JSON patch " +
+				"(application/json-patch+json)",
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/projects/{id}",
+			Summary: "Delete a project",
+		},
+	}
+}
+
+// teamQueryParam documents the query parameter by which the Team that is to
+// own a newly created Project may be specified.
+var teamQueryParam = restmachinery.QueryParam{
+	Name: "team",
+	Description: "The ID of a team to own the project if it is created; the " +
+		"team is granted the project's built-in roles in place of the caller",
+}
+
 func (p *ProjectsEndpoints) create(w http.ResponseWriter, r *http.Request) {
 	project := core.Project{}
 	p.ServeRequest(
@@ -59,7 +145,13 @@ func (p *ProjectsEndpoints) create(w http.ResponseWriter, r *http.Request) {
 			ReqBodySchemaLoader: p.ProjectSchemaLoader,
 			ReqBodyObj:          &project,
 			EndpointLogic: func() (interface{}, error) {
-				return p.Service.Create(r.Context(), project)
+				return p.Service.Create(
+					r.Context(),
+					project,
+					core.ProjectCreateOptions{
+						OwningTeam: r.URL.Query().Get("team"),
+					},
+				)
 			},
 			SuccessCode: http.StatusCreated,
 		},
@@ -67,6 +159,15 @@ func (p *ProjectsEndpoints) create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (p *ProjectsEndpoints) list(w http.ResponseWriter, r *http.Request) {
+	selector := core.ProjectsSelector{}
+	if labelsStr := r.URL.Query().Get("labels"); labelsStr != "" {
+		var err error
+		if selector.LabelSelector, err =
+			meta.ParseLabelSelector(labelsStr); err != nil {
+			p.WriteAPIResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
 	opts := meta.ListOptions{
 		Continue: r.URL.Query().Get("continue"),
 	}
@@ -92,7 +193,7 @@ func (p *ProjectsEndpoints) list(w http.ResponseWriter, r *http.Request) {
 			W: w,
 			R: r,
 			EndpointLogic: func() (interface{}, error) {
-				return p.Service.List(r.Context(), opts)
+				return p.Service.List(r.Context(), selector, opts)
 			},
 			SuccessCode: http.StatusOK,
 		},
@@ -105,7 +206,11 @@ func (p *ProjectsEndpoints) get(w http.ResponseWriter, r *http.Request) {
 			W: w,
 			R: r,
 			EndpointLogic: func() (interface{}, error) {
-				return p.Service.Get(r.Context(), mux.Vars(r)["id"])
+				project, err := p.Service.Get(r.Context(), mux.Vars(r)["id"])
+				if err == nil {
+					w.Header().Set("ETag", etag(project.ResourceVersion))
+				}
+				return project, err
 			},
 			SuccessCode: http.StatusOK,
 		},
@@ -127,7 +232,93 @@ func (p *ProjectsEndpoints) update(w http.ResponseWriter, r *http.Request) {
 							"not match.",
 					}
 				}
-				return p.Service.Update(r.Context(), project)
+				if err := applyIfMatch(r, &project); err != nil {
+					return nil, err
+				}
+				project, err := p.Service.Update(r.Context(), project)
+				if err == nil {
+					w.Header().Set("ETag", etag(project.ResourceVersion))
+				}
+				return project, err
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (p *ProjectsEndpoints) patch(w http.ResponseWriter, r *http.Request) {
+	p.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				defer r.Body.Close()
+				patchBytes, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					return nil, &meta.ErrBadRequest{
+						Reason: "Could not read request body.",
+					}
+				}
+				project, err := p.Service.Get(r.Context(), mux.Vars(r)["id"])
+				if err != nil {
+					return nil, err
+				}
+				if project, err = p.patchProject(
+					project,
+					r.Header.Get("Content-Type"),
+					patchBytes,
+				); err != nil {
+					return nil, err
+				}
+				if err = applyIfMatch(r, &project); err != nil {
+					return nil, err
+				}
+				project, err = p.Service.Update(r.Context(), project)
+				if err == nil {
+					w.Header().Set("ETag", etag(project.ResourceVersion))
+				}
+				return project, err
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (p *ProjectsEndpoints) apply(w http.ResponseWriter, r *http.Request) {
+	project := core.Project{}
+	p.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: p.ProjectSchemaLoader,
+			ReqBodyObj:          &project,
+			EndpointLogic: func() (interface{}, error) {
+				if mux.Vars(r)["id"] != project.ID {
+					return nil, &meta.ErrBadRequest{
+						Reason: "The project IDs in the URL path and request body do " +
+							"not match.",
+					}
+				}
+				var dryRun bool
+				if dryRunStr := r.URL.Query().Get("dryRun"); dryRunStr != "" {
+					var err error
+					if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+						return nil, &meta.ErrBadRequest{
+							Reason: fmt.Sprintf(
+								`Invalid value %q for "dryRun" query parameter`,
+								dryRunStr,
+							),
+						}
+					}
+				}
+				return p.Service.Apply(
+					r.Context(),
+					project,
+					core.ProjectApplyOptions{
+						DryRun:     dryRun,
+						OwningTeam: r.URL.Query().Get("team"),
+					},
+				)
 			},
 			SuccessCode: http.StatusOK,
 		},
@@ -146,3 +337,155 @@ func (p *ProjectsEndpoints) delete(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 }
+
+// etag returns a strong entity tag derived from the provided ResourceVersion.
+func etag(resourceVersion int64) string {
+	return fmt.Sprintf(`"%d"`, resourceVersion)
+}
+
+// parseETag extracts a ResourceVersion from the provided entity tag, which
+// should have been obtained from the etag function.
+func parseETag(tag string) (int64, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, errors.Errorf("%q is not a valid entity tag", tag)
+	}
+	resourceVersion, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || resourceVersion < 1 {
+		return 0, errors.Errorf("%q is not a valid entity tag", tag)
+	}
+	return resourceVersion, nil
+}
+
+// applyIfMatch sets the ResourceVersion of the provided Project to the one
+// specified by the provided request's If-Match header, if any. A
+// *meta.ErrBadRequest is returned if the header is invalid or conflicts with a
+// ResourceVersion that is already set.
+func applyIfMatch(r *http.Request, project *core.Project) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+	resourceVersion, err := parseETag(ifMatch)
+	if err != nil {
+		return &meta.ErrBadRequest{
+			Reason: fmt.Sprintf("Invalid If-Match header value %q.", ifMatch),
+		}
+	}
+	if project.ResourceVersion != 0 &&
+		project.ResourceVersion != resourceVersion {
+		return &meta.ErrBadRequest{
+			Reason: "The resource versions in the If-Match header and request " +
+				"body do not match.",
+		}
+	}
+	project.ResourceVersion = resourceVersion
+	return nil
+}
+
+// patchProject applies the provided patch to the client-writable fields of the
+// provided Project and returns the result. The format of the patch is
+// determined by the provided content type. RFC 7386 JSON merge patches
+// (application/merge-patch+json) and RFC 6902 JSON patches
+// (application/json-patch+json) are both supported. The result is validated
+// against the project schema. Unless the patch itself specifies a
+// ResourceVersion, the result retains the ResourceVersion of the original
+// Project so that concurrent modifications are detected when it is persisted.
+func (p *ProjectsEndpoints) patchProject(
+	project core.Project,
+	contentType string,
+	patch []byte,
+) (core.Project, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+
+	projectBytes, err := json.Marshal(project)
+	if err != nil {
+		return project, errors.Wrapf(err, "error marshaling project %q", project.ID)
+	}
+	// Strip everything clients are not permitted to write; otherwise the patched
+	// document would fail schema validation.
+	doc := map[string]interface{}{}
+	if err = json.Unmarshal(projectBytes, &doc); err != nil {
+		return project,
+			errors.Wrapf(err, "error unmarshaling project %q", project.ID)
+	}
+	delete(doc, "kubernetes")
+	if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+		for k := range metadata {
+			switch k {
+			case "id", "labels", "annotations":
+			default:
+				delete(metadata, k)
+			}
+		}
+	}
+	if projectBytes, err = json.Marshal(doc); err != nil {
+		return project, errors.Wrapf(err, "error marshaling project %q", project.ID)
+	}
+
+	var patchedBytes []byte
+	switch mediaType {
+	case "application/merge-patch+json":
+		if patchedBytes, err =
+			jsonpatch.MergePatch(projectBytes, patch); err != nil {
+			return project, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf("Could not apply merge patch: %s", err),
+			}
+		}
+	case "application/json-patch+json":
+		jsonPatch, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return project, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf("Could not decode JSON patch: %s", err),
+			}
+		}
+		if patchedBytes, err = jsonPatch.Apply(projectBytes); err != nil {
+			return project, &meta.ErrBadRequest{
+				Reason: fmt.Sprintf("Could not apply JSON patch: %s", err),
+			}
+		}
+	default:
+		return project, &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"Unsupported patch content type %q; supported content types are "+
+					"application/merge-patch+json and application/json-patch+json.",
+				contentType,
+			),
+		}
+	}
+
+	validationResult, err := gojsonschema.Validate(
+		p.ProjectSchemaLoader,
+		gojsonschema.NewBytesLoader(patchedBytes),
+	)
+	if err != nil {
+		return project, errors.Wrap(err, "error validating patched project")
+	}
+	if !validationResult.Valid() {
+		verrStrs := make([]string, len(validationResult.Errors()))
+		for i, verr := range validationResult.Errors() {
+			verrStrs[i] = verr.String()
+		}
+		return project, &meta.ErrBadRequest{
+			Reason:  "Patched project failed JSON validation",
+			Details: verrStrs,
+		}
+	}
+
+	patchedProject := core.Project{}
+	if err = json.Unmarshal(patchedBytes, &patchedProject); err != nil {
+		return project, errors.Wrap(err, "error unmarshaling patched project")
+	}
+	if patchedProject.ID != project.ID {
+		return project, &meta.ErrBadRequest{
+			Reason: "A project's ID cannot be changed.",
+		}
+	}
+	if patchedProject.ResourceVersion == 0 {
+		patchedProject.ResourceVersion = project.ResourceVersion
+	}
+	return patchedProject, nil
+}
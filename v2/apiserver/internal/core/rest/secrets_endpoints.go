@@ -38,6 +38,28 @@ func (s *SecretsEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodDelete)
 }
 
+func (s *SecretsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:      http.MethodGet,
+			Path:        "/v2/projects/{projectID}/secrets",
+			Summary:     "List a project's secrets; values are never returned",
+			QueryParams: restmachinery.ListQueryParams(),
+		},
+		{
+			Method:        http.MethodPut,
+			Path:          "/v2/projects/{projectID}/secrets/{key}",
+			Summary:       "Set a project secret",
+			RequestSchema: "secret.json",
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/v2/projects/{projectID}/secrets/{key}",
+			Summary: "Unset a project secret",
+		},
+	}
+}
+
 func (s *SecretsEndpoints) list(w http.ResponseWriter, r *http.Request) {
 	opts := meta.ListOptions{
 		Continue: r.URL.Query().Get("continue"),
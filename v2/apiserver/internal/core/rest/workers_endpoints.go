@@ -41,6 +41,34 @@ func (w *WorkersEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodPut)
 }
 
+func (w *WorkersEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:  http.MethodPut,
+			Path:    "/v2/events/{eventID}/worker/start",
+			Summary: "Start a worker",
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/events/{eventID}/worker/status",
+			Summary: "Get or stream a worker's status",
+			QueryParams: []restmachinery.QueryParam{
+				{
+					Name: "watch",
+					Description: "Whether to stream status updates as server-sent " +
+						"events",
+				},
+			},
+		},
+		{
+			Method:        http.MethodPut,
+			Path:          "/v2/events/{eventID}/worker/status",
+			Summary:       "Update a worker's status",
+			RequestSchema: "worker-status.json",
+		},
+	}
+}
+
 func (w *WorkersEndpoints) start(wr http.ResponseWriter, r *http.Request) {
 	w.ServeRequest(
 		restmachinery.InboundRequest{
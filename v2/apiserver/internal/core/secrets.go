@@ -101,19 +101,22 @@ type SecretsService interface {
 }
 
 type secretsService struct {
-	authorize     authx.AuthorizeFn
-	projectsStore ProjectsStore
-	secretsStore  SecretsStore
+	authorize        authx.AuthorizeFn
+	projectAuthorize authx.ProjectAuthorizeFn
+	projectsStore    ProjectsStore
+	secretsStore     SecretsStore
 }
 
 func NewSecretsService(
 	projectsStore ProjectsStore,
 	secretsStore SecretsStore,
+	projectAuthorize authx.ProjectAuthorizeFn,
 ) SecretsService {
 	return &secretsService{
-		authorize:     authx.Authorize,
-		projectsStore: projectsStore,
-		secretsStore:  secretsStore,
+		authorize:        authx.Authorize,
+		projectAuthorize: projectAuthorize,
+		projectsStore:    projectsStore,
+		secretsStore:     secretsStore,
 	}
 }
 
@@ -154,7 +157,11 @@ func (s *secretsService) Set(
 	projectID string,
 	secret Secret,
 ) error {
-	if err := s.authorize(ctx, authx.RoleProjectAdmin(projectID)); err != nil {
+	if err := s.projectAuthorize(
+		ctx,
+		projectID,
+		authx.PermissionSecretsUpdate,
+	); err != nil {
 		return err
 	}
 
@@ -181,7 +188,11 @@ func (s *secretsService) Unset(
 	projectID string,
 	key string,
 ) error {
-	if err := s.authorize(ctx, authx.RoleProjectAdmin(projectID)); err != nil {
+	if err := s.projectAuthorize(
+		ctx,
+		projectID,
+		authx.PermissionSecretsDelete,
+	); err != nil {
 		return err
 	}
 
@@ -211,6 +222,15 @@ type SecretsStore interface {
 		project Project,
 		opts meta.ListOptions,
 	) (SecretList, error)
+	// GetValues returns the values of all of the specified Project's Secrets.
+	// This is intended strictly for internal use (e.g. for redacting Secret
+	// values from logs) and results MUST NOT be returned to API clients.
+	GetValues(ctx context.Context, project Project) ([]string, error)
+	// GetAll returns all of the specified Project's Secrets, including their
+	// Values, sorted by Key. This is intended strictly for internal use (e.g. for
+	// producing encrypted system backups) and results MUST NOT be returned to API
+	// clients.
+	GetAll(ctx context.Context, project Project) ([]Secret, error)
 	Set(ctx context.Context, project Project, secret Secret) error
 	Unset(ctx context.Context, project Project, key string) error
 }
@@ -9,6 +9,8 @@ type Substrate interface {
 	// included.
 	PreCreateProject(ctx context.Context, project Project) (Project, error)
 	// CreateProject prepares the substrate to host Project workloads.
+	// Implementations MUST be idempotent so that substrate resources can be
+	// recreated for an existing Project.
 	CreateProject(ctx context.Context, project Project) error
 	// PreUpdateProject returns a Project that has been amended with
 	// substrate-specific details. This should always be called prior to an
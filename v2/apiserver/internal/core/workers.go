@@ -66,6 +66,31 @@ func WorkerPhasesAll() []WorkerPhase {
 	}
 }
 
+// WorkerPhasesTerminal returns a slice of WorkerPhases containing ALL phases
+// that are considered terminal. Note that instead of utilizing a package-level
+// slice, this a function returns ad-hoc copies of the slice in order to
+// preclude the possibility of this important collection being modified at
+// runtime.
+func WorkerPhasesTerminal() []WorkerPhase {
+	return []WorkerPhase{
+		WorkerPhaseAborted,
+		WorkerPhaseCanceled,
+		WorkerPhaseFailed,
+		WorkerPhaseSucceeded,
+		WorkerPhaseTimedOut,
+	}
+}
+
+// IsTerminal returns a bool indicating whether the WorkerPhase is terminal.
+func (w WorkerPhase) IsTerminal() bool {
+	for _, phase := range WorkerPhasesTerminal() {
+		if w == phase {
+			return true
+		}
+	}
+	return false
+}
+
 // Worker represents a component that orchestrates handling of a single Event.
 type Worker struct {
 	// Spec is the technical blueprint for the Worker.
@@ -350,6 +375,7 @@ func (w *workersService) UpdateStatus(
 			eventID,
 		)
 	}
+
 	return nil
 }
 
@@ -361,6 +387,11 @@ type WorkersStore interface {
 		eventID string,
 		spec WorkerSpec,
 	) error
+	// UpdateStatus updates the status of the specified Event's Worker. A Worker
+	// in a terminal phase has no further need of its token, so if the new
+	// status's phase is terminal, implementations MUST also remove the Worker's
+	// hashed token, in the same operation, so that the token can no longer be
+	// used to authenticate.
 	UpdateStatus(
 		ctx context.Context,
 		eventID string,
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/stretchr/testify/require"
+)
+
+type mockWorkersStore struct {
+	WorkersStore
+	UpdateStatusFn func(context.Context, string, WorkerStatus) error
+}
+
+func (m *mockWorkersStore) UpdateStatus(
+	ctx context.Context,
+	eventID string,
+	status WorkerStatus,
+) error {
+	return m.UpdateStatusFn(ctx, eventID, status)
+}
+
+func TestWorkersServiceUpdateStatus(t *testing.T) {
+	const testEventID = "tunguska"
+	testCases := []struct {
+		name       string
+		phase      WorkerPhase
+		updateErr  error
+		assertions func(*testing.T, error)
+	}{
+		{
+			name:  "non-terminal phase",
+			phase: WorkerPhaseRunning,
+			assertions: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			// The store is responsible for revoking the Worker's token in the same
+			// update, so the service makes no separate call to do so
+			name:  "terminal phase",
+			phase: WorkerPhaseSucceeded,
+			assertions: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "error updating status",
+			phase:     WorkerPhaseFailed,
+			updateErr: errors.New("something went wrong"),
+			assertions: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "something went wrong")
+				require.Contains(t, err.Error(), "error updating status")
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var updated bool
+			svc := &workersService{
+				authorize: authx.AlwaysAuthorize,
+				workersStore: &mockWorkersStore{
+					UpdateStatusFn: func(
+						_ context.Context,
+						eventID string,
+						status WorkerStatus,
+					) error {
+						require.Equal(t, testEventID, eventID)
+						require.Equal(t, testCase.phase, status.Phase)
+						updated = true
+						return testCase.updateErr
+					},
+				},
+			}
+			err := svc.UpdateStatus(
+				context.Background(),
+				testEventID,
+				WorkerStatus{
+					Phase: testCase.phase,
+				},
+			)
+			testCase.assertions(t, err)
+			require.True(t, updated)
+		})
+	}
+}
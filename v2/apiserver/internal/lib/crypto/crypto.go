@@ -1,14 +1,65 @@
 package crypto
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
+	"hash/crc32"
+	"strings"
 
-	"github.com/brigadecore/brigade/v2/internal/rand"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
 )
 
-var seededRand = rand.NewSeeded()
+const tokenChars = "abcdefghijklmnopqrstuvwxyz" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"0123456789"
 
+const (
+	// typedTokenRandomLength is the number of random characters in a typed
+	// token. With 62 possible characters each, this amounts to more than 238
+	// bits of entropy.
+	typedTokenRandomLength = 40
+	// typedTokenChecksumLength is the number of characters used to encode a
+	// typed token's CRC32 checksum. Six base62 characters suffice to encode any
+	// 32 bit value.
+	typedTokenChecksumLength = 6
+)
+
+// TokenType is a type whose values are used as recognizable prefixes for the
+// different kinds of tokens issued by Brigade. The prefixes permit secret
+// scanners to identify leaked Brigade tokens and permit the API server to
+// route a token to the one component that could have issued it.
+type TokenType string
+
+const (
+	// TokenTypeServiceAccount represents a token that authenticates a
+	// ServiceAccount.
+	TokenTypeServiceAccount TokenType = "brigsvc"
+	// TokenTypeSession represents a token that authenticates a Session.
+	TokenTypeSession TokenType = "brigses"
+	// TokenTypeRefresh represents a token that may be exchanged for a new
+	// Session token.
+	TokenTypeRefresh TokenType = "brigref"
+	// TokenTypePersonalAccess represents a User's personal access token.
+	TokenTypePersonalAccess TokenType = "brigpat"
+	// TokenTypeWorker represents a token that authenticates a Worker.
+	TokenTypeWorker TokenType = "brigwkr"
+)
+
+var tokenTypes = []TokenType{
+	TokenTypeServiceAccount,
+	TokenTypeSession,
+	TokenTypeRefresh,
+	TokenTypePersonalAccess,
+	TokenTypeWorker,
+}
+
+// ShortSHA returns an unkeyed, truncated SHA-256 hash of the provided input,
+// optionally salted. Earlier versions of Brigade stored tokens hashed this
+// way. Tokens are now hashed using a TokenHasher and this remains chiefly so
+// that legacy hashes can be recognized and replaced.
 func ShortSHA(salt, input string) string {
 	if salt != "" {
 		input = fmt.Sprintf("%s:%s", salt, input)
@@ -17,18 +68,141 @@ func ShortSHA(salt, input string) string {
 	return fmt.Sprintf("%x", sum)[0:54]
 }
 
-// TODO: These aren't technically guaranteed unique, although a collision would
-// be extraordinary. There's probably a reliable way of generating tokens that
-// are guaranteed unique. We should identify that and transition.
+// NewToken returns a random string of the specified length, drawn from a
+// cryptographically secure source. Credentials should use NewTypedToken
+// instead.
 func NewToken(tokenLength int) string {
-	const (
-		tokenChars = "abcdefghijklmnopqrstuvwxyz" +
-			"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-			"0123456789"
+	// Discard bytes that would bias the result toward the beginning of
+	// tokenChars when reduced modulo its length
+	const maxByte = 256 - (256 % len(tokenChars))
+	b := make([]byte, 0, tokenLength)
+	buf := make([]byte, tokenLength)
+	for len(b) < tokenLength {
+		if _, err := rand.Read(buf); err != nil {
+			// There is no sensible way to recover from the system's source of
+			// randomness having failed.
+			panic(fmt.Sprintf("error reading random bytes: %s", err))
+		}
+		for _, r := range buf {
+			if int(r) >= maxByte {
+				continue
+			}
+			b = append(b, tokenChars[int(r)%len(tokenChars)])
+			if len(b) == tokenLength {
+				break
+			}
+		}
+	}
+	return string(b)
+}
+
+// NewTypedToken returns a new, cryptographically secure token of the specified
+// type. The token consists of the type as a prefix, an underscore, random
+// characters, and a checksum that permits malformed tokens to be rejected
+// without consulting a data store.
+func NewTypedToken(tokenType TokenType) string {
+	token := fmt.Sprintf("%s_%s", tokenType, NewToken(typedTokenRandomLength))
+	return token + tokenChecksum(token)
+}
+
+// TokenTypeOf returns the TokenType indicated by the prefix of the provided
+// token. An empty TokenType is returned for tokens bearing no recognized
+// prefix, which includes all tokens issued by earlier versions of Brigade.
+func TokenTypeOf(token string) TokenType {
+	for _, tokenType := range tokenTypes {
+		if strings.HasPrefix(token, string(tokenType)+"_") {
+			return tokenType
+		}
+	}
+	return ""
+}
+
+// ValidTokenChecksum returns a bool indicating whether the provided token is a
+// typed token with a checksum that matches the rest of the token.
+func ValidTokenChecksum(token string) bool {
+	if TokenTypeOf(token) == "" ||
+		len(token) <= typedTokenChecksumLength {
+		return false
+	}
+	checksumIndex := len(token) - typedTokenChecksumLength
+	return hmac.Equal(
+		[]byte(token[checksumIndex:]),
+		[]byte(tokenChecksum(token[:checksumIndex])),
 	)
-	b := make([]byte, tokenLength)
-	for i := 0; i < tokenLength; i++ {
-		b[i] = tokenChars[seededRand.Intn(len(tokenChars))]
+}
+
+// tokenChecksum returns the CRC32 checksum of the provided string, encoded
+// using typedTokenChecksumLength base62 characters.
+func tokenChecksum(str string) string {
+	sum := crc32.ChecksumIEEE([]byte(str))
+	b := make([]byte, typedTokenChecksumLength)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = tokenChars[sum%uint32(len(tokenChars))]
+		sum /= uint32(len(tokenChars))
 	}
 	return string(b)
 }
+
+// TokenHasher is an interface for components that compute secure, one-way
+// hashes of tokens for storage.
+type TokenHasher interface {
+	// Hash returns a secure, one-way hash of the provided token.
+	Hash(token string) string
+	// KeyFingerprint returns a value that identifies the key used in computing
+	// hashes without revealing it. Hashes computed by two TokenHashers are
+	// comparable only if their key fingerprints match.
+	KeyFingerprint() string
+}
+
+type tokenHasher struct {
+	key []byte
+}
+
+// NewTokenHasher returns a TokenHasher that computes HMAC-SHA256 hashes using
+// the provided server-side key. Unlike plain hashes, these cannot be
+// reproduced, and therefore cannot be brute forced, by anyone who has obtained
+// a copy of the data store without also obtaining the key.
+func NewTokenHasher(key string) TokenHasher {
+	return &tokenHasher{
+		key: []byte(key),
+	}
+}
+
+func (t *tokenHasher) Hash(token string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(token)) // nolint: errcheck
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+func (t *tokenHasher) KeyFingerprint() string {
+	// This is a hash of a fixed input that no token can ever collide with, so
+	// it can be disclosed without weakening the hashes of real tokens.
+	return t.Hash("brigade-token-hash-key-fingerprint")[0:16]
+}
+
+// LookupByHashedToken invokes the provided lookup function with a hash of the
+// provided token computed by the provided TokenHasher. If the lookup fails
+// with a *meta.ErrNotFound error and the token predates typed tokens, the token
+// may have been hashed by an earlier version of Brigade, so the lookup is
+// retried using the legacy hash. If that succeeds and the provided rehash
+// function is non-nil, it is invoked to replace the legacy hash so that
+// subsequent lookups succeed on the first attempt. The hash with which the
+// looked up resource can now be found is returned.
+func LookupByHashedToken(
+	tokenHasher TokenHasher,
+	token string,
+	lookup func(hashedToken string) error,
+	rehash func(legacyHashedToken, hashedToken string) error,
+) (string, error) {
+	hashedToken := tokenHasher.Hash(token)
+	err := lookup(hashedToken)
+	if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok ||
+		TokenTypeOf(token) != "" {
+		return hashedToken, err
+	}
+	legacyHashedToken := ShortSHA("", token)
+	if err = lookup(legacyHashedToken); err != nil || rehash == nil {
+		return legacyHashedToken, err
+	}
+	return hashedToken, rehash(legacyHashedToken, hashedToken)
+}
@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	passphraseSaltLength = 16
+	passphraseKeyLength  = 32
+)
+
+// EncryptWithPassphrase encrypts the provided plaintext using AES-256-GCM with
+// a key derived from the provided passphrase using scrypt. The random salt and
+// nonce are prepended to the returned ciphertext so that it can later be
+// decrypted by DecryptWithPassphrase using only the same passphrase.
+func EncryptWithPassphrase(
+	passphrase string,
+	plaintext []byte,
+) ([]byte, error) {
+	salt := make([]byte, passphraseSaltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, errors.Wrap(err, "error generating salt")
+	}
+	gcm, err := newPassphraseGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "error generating nonce")
+	}
+	ciphertext := append(salt, nonce...)
+	return gcm.Seal(ciphertext, nonce, plaintext, nil), nil
+}
+
+// DecryptWithPassphrase decrypts ciphertext that was produced by
+// EncryptWithPassphrase. An error is returned if the passphrase is incorrect or
+// the ciphertext has been tampered with.
+func DecryptWithPassphrase(
+	passphrase string,
+	ciphertext []byte,
+) ([]byte, error) {
+	if len(ciphertext) < passphraseSaltLength {
+		return nil, errors.New("ciphertext is too short")
+	}
+	salt := ciphertext[:passphraseSaltLength]
+	ciphertext = ciphertext[passphraseSaltLength:]
+	gcm, err := newPassphraseGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	plaintext, err := gcm.Open(
+		nil,
+		ciphertext[:gcm.NonceSize()],
+		ciphertext[gcm.NonceSize():],
+		nil,
+	)
+	return plaintext, errors.Wrap(err, "error decrypting ciphertext")
+}
+
+func newPassphraseGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err :=
+		scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, passphraseKeyLength)
+	if err != nil {
+		return nil, errors.Wrap(err, "error deriving key from passphrase")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	return gcm, errors.Wrap(err, "error creating GCM")
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// continueToken is the decoded form of the opaque values that list operations
+// return for use in requesting the next page of results. It records the
+// position of the last item on the previous page within a list sorted by
+// creation time with ties broken by ID.
+type continueToken struct {
+	Created *time.Time `json:"c,omitempty"`
+	ID      string     `json:"i"`
+}
+
+// EncodeContinueToken returns an opaque token that records the position of an
+// item having the provided creation time and ID within a list sorted by
+// creation time with ties broken by ID.
+func EncodeContinueToken(created *time.Time, id string) string {
+	// Marshaling this type cannot fail
+	tokenBytes, _ := json.Marshal(continueToken{Created: created, ID: id})
+	return base64.RawURLEncoding.EncodeToString(tokenBytes)
+}
+
+// ParseContinueToken decodes the provided token, which should have been
+// obtained from EncodeContinueToken, and returns the creation time and ID that
+// it records. A *meta.ErrBadRequest is returned if the token is malformed.
+func ParseContinueToken(token string) (*time.Time, string, error) {
+	badTokenErr := &meta.ErrBadRequest{
+		Reason: "The continue token is invalid.",
+	}
+	tokenBytes, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, "", badTokenErr
+	}
+	ct := continueToken{}
+	if err = json.Unmarshal(tokenBytes, &ct); err != nil || ct.ID == "" {
+		return nil, "", badTokenErr
+	}
+	return ct.Created, ct.ID, nil
+}
+
+// KeysetCriteria returns query criteria that select documents following the
+// item having the provided creation time and ID within a list sorted by
+// creation time with ties broken by ID. The descending argument indicates the
+// direction of that sort. Documents lacking a creation time are assumed to
+// sort lowest, as they do in MongoDB.
+func KeysetCriteria(created *time.Time, id string, descending bool) bson.M {
+	op := "$gt"
+	if descending {
+		op = "$lt"
+	}
+	if created == nil {
+		criteria := []bson.M{
+			{"created": nil, "id": bson.M{op: id}},
+		}
+		if !descending {
+			criteria = append(criteria, bson.M{"created": bson.M{"$ne": nil}})
+		}
+		return bson.M{"$or": criteria}
+	}
+	criteria := []bson.M{
+		{"created": bson.M{op: *created}},
+		{"created": *created, "id": bson.M{op: id}},
+	}
+	if descending {
+		criteria = append(criteria, bson.M{"created": nil})
+	}
+	return bson.M{"$or": criteria}
+}
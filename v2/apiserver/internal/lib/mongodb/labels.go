@@ -0,0 +1,53 @@
+package mongodb
+
+import (
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LabelSelectorCriteria returns query criteria, suitable for inclusion in an
+// "$and" clause, that select documents whose labels satisfy ALL of the
+// requirements of the provided meta.LabelSelector. It assumes that, in each
+// document, the field specified by the labelsField argument contains labels
+// represented as an array of key/value pairs as follows:
+//
+//	[
+//	  { "key": "key0", "value": "value0" },
+//	  ...
+//	  { "key": "keyN", "value": "valueN" }
+//	]
+func LabelSelectorCriteria(
+	labelsField string,
+	selector meta.LabelSelector,
+) []bson.M {
+	criteria := make([]bson.M, 0, len(selector))
+	for _, req := range selector {
+		var match bson.M
+		var negate bool
+		switch req.Operator {
+		case meta.LabelOperatorEquals:
+			match = bson.M{"key": req.Key, "value": req.Values[0]}
+		case meta.LabelOperatorNotEquals:
+			match = bson.M{"key": req.Key, "value": req.Values[0]}
+			negate = true
+		case meta.LabelOperatorIn:
+			match = bson.M{"key": req.Key, "value": bson.M{"$in": req.Values}}
+		case meta.LabelOperatorNotIn:
+			match = bson.M{"key": req.Key, "value": bson.M{"$in": req.Values}}
+			negate = true
+		case meta.LabelOperatorExists:
+			match = bson.M{"key": req.Key}
+		case meta.LabelOperatorDoesNotExist:
+			match = bson.M{"key": req.Key}
+			negate = true
+		default:
+			continue
+		}
+		condition := bson.M{"$elemMatch": match}
+		if negate {
+			condition = bson.M{"$not": condition}
+		}
+		criteria = append(criteria, bson.M{labelsField: condition})
+	}
+	return criteria
+}
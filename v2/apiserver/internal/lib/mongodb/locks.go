@@ -0,0 +1,73 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TryAcquireLock attempts, without blocking, to acquire the specified lock on
+// behalf of the specified holder for the specified length of time. Locks are
+// recorded as documents in the metadata collection of the provided database. A
+// lock that is absent, has expired, or is already held by the same holder is
+// (re)acquired, extending its expiry. The bool returned indicates whether the
+// lock was acquired.
+func TryAcquireLock(
+	ctx context.Context,
+	database *mongo.Database,
+	lockID string,
+	holder string,
+	ttl time.Duration,
+) (bool, error) {
+	now := time.Now().UTC()
+	// If the lock is held by someone else, this matches nothing, so the upsert
+	// collides with the existing document and fails with a duplicate key error.
+	_, err := database.Collection(metadataCollection).UpdateOne(
+		ctx,
+		bson.M{
+			"_id": lockID,
+			"$or": []bson.M{
+				{"expires": bson.M{"$lt": now}},
+				{"holder": holder},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				"holder":  holder,
+				"expires": now.Add(ttl),
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err == nil {
+		return true, nil
+	}
+	if writeException, ok := err.(mongo.WriteException); ok &&
+		len(writeException.WriteErrors) == 1 &&
+		writeException.WriteErrors[0].Code == 11000 {
+		return false, nil
+	}
+	return false, errors.Wrapf(err, "error acquiring lock %q", lockID)
+}
+
+// ReleaseLock releases the specified lock if it is held by the specified
+// holder.
+func ReleaseLock(
+	ctx context.Context,
+	database *mongo.Database,
+	lockID string,
+	holder string,
+) error {
+	_, err := database.Collection(metadataCollection).DeleteOne(
+		ctx,
+		bson.M{
+			"_id":    lockID,
+			"holder": holder,
+		},
+	)
+	return errors.Wrapf(err, "error releasing lock %q", lockID)
+}
@@ -0,0 +1,208 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// metadataCollection is the name of the collection in which the schema
+	// version and locks (e.g. the migrations lock) are recorded.
+	metadataCollection = "metadata"
+	// schemaDocumentID is the ID of the document, in the metadata collection,
+	// that records the schema version.
+	schemaDocumentID = "schema"
+	// migrationsLockDocumentID is the ID of the document, in the metadata
+	// collection, that represents the migrations lock.
+	migrationsLockDocumentID = "migrations-lock"
+	// migrationsLockTTL is the length of time for which the migrations lock is
+	// held before it is presumed abandoned (e.g. by an API server that crashed
+	// mid-migration) and becomes eligible to be acquired by another process. No
+	// migration should take longer than this.
+	migrationsLockTTL = 10 * time.Minute
+	// migrationsLockPollInterval is the length of time to wait between attempts
+	// to acquire the migrations lock.
+	migrationsLockPollInterval = 2 * time.Second
+)
+
+// Migration represents a single, versioned change to the shape of the data in
+// the database, e.g. the creation of indexes or the transformation of existing
+// documents.
+type Migration struct {
+	// Version is the schema version that the database will be at once the
+	// Migration has been applied. Migrations are numbered consecutively,
+	// starting from 1.
+	Version int
+	// Description is a brief description of the Migration.
+	Description string
+	// Migrate applies the Migration.
+	Migrate func(ctx context.Context, database *mongo.Database) error
+}
+
+// schemaDocument is the document, in the metadata collection, that records the
+// schema version.
+type schemaDocument struct {
+	Version  int        `bson:"version"`
+	Migrated *time.Time `bson:"migrated,omitempty"`
+}
+
+// Migrate brings the schema of the provided database up to date by applying,
+// in order, any of Brigade's Migrations that have not already been applied.
+// Migrations are applied under a lock so that only one API server process
+// applies them. An error is returned if the database's schema version is
+// newer than this version of Brigade understands.
+func Migrate(ctx context.Context, database *mongo.Database) error {
+	return runMigrations(ctx, database, migrations)
+}
+
+func runMigrations(
+	ctx context.Context,
+	database *mongo.Database,
+	migrations []Migration,
+) error {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return errors.Errorf(
+				"migration %d has version %d; migrations must be numbered "+
+					"consecutively, starting from 1",
+				i,
+				migration.Version,
+			)
+		}
+	}
+	latestVersion := len(migrations)
+
+	collection := database.Collection(metadataCollection)
+
+	// Check the schema version before bothering with the lock. Most of the time,
+	// there will be nothing to do.
+	version, err := getSchemaVersion(ctx, collection, latestVersion)
+	if err != nil || version == latestVersion {
+		return err
+	}
+
+	holder := crypto.NewToken(20)
+	if err = acquireMigrationsLock(ctx, database, holder); err != nil {
+		return err
+	}
+	defer func() {
+		if err := ReleaseLock(
+			context.Background(),
+			database,
+			migrationsLockDocumentID,
+			holder,
+		); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	// Another process may have applied migrations while we waited for the lock,
+	// so check again.
+	if version, err =
+		getSchemaVersion(ctx, collection, latestVersion); err != nil {
+		return err
+	}
+
+	for _, migration := range migrations[version:] {
+		log.Printf(
+			"applying database migration %d: %s",
+			migration.Version,
+			migration.Description,
+		)
+		if err = migration.Migrate(ctx, database); err != nil {
+			return errors.Wrapf(
+				err,
+				"error applying database migration %d",
+				migration.Version,
+			)
+		}
+		now := time.Now().UTC()
+		if _, err = collection.UpdateOne(
+			ctx,
+			bson.M{"_id": schemaDocumentID},
+			bson.M{
+				"$set": schemaDocument{
+					Version:  migration.Version,
+					Migrated: &now,
+				},
+			},
+			options.Update().SetUpsert(true),
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error recording database schema version %d",
+				migration.Version,
+			)
+		}
+	}
+
+	return nil
+}
+
+// getSchemaVersion returns the schema version recorded in the provided
+// metadata collection. A database with no recorded schema version is at
+// version 0. An error is returned if the recorded version exceeds the provided
+// latest version.
+func getSchemaVersion(
+	ctx context.Context,
+	collection *mongo.Collection,
+	latestVersion int,
+) (int, error) {
+	schemaDoc := schemaDocument{}
+	res := collection.FindOne(ctx, bson.M{"_id": schemaDocumentID})
+	if err := res.Decode(&schemaDoc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "error retrieving database schema version")
+	}
+	if schemaDoc.Version > latestVersion {
+		return 0, errors.Errorf(
+			"database schema version %d is newer than the latest version (%d) "+
+				"understood by this version of Brigade; refusing to start",
+			schemaDoc.Version,
+			latestVersion,
+		)
+	}
+	return schemaDoc.Version, nil
+}
+
+// acquireMigrationsLock blocks until the migrations lock is acquired on behalf
+// of the specified holder or the provided context is canceled.
+func acquireMigrationsLock(
+	ctx context.Context,
+	database *mongo.Database,
+	holder string,
+) error {
+	for {
+		acquired, err := TryAcquireLock(
+			ctx,
+			database,
+			migrationsLockDocumentID,
+			holder,
+			migrationsLockTTL,
+		)
+		if err != nil {
+			return errors.Wrap(err, "error acquiring database migrations lock")
+		}
+		if acquired {
+			return nil
+		}
+		log.Println("waiting for database migrations lock")
+		select {
+		case <-time.After(migrationsLockPollInterval):
+		case <-ctx.Done():
+			return errors.Wrap(
+				ctx.Err(),
+				"error waiting for database migrations lock",
+			)
+		}
+	}
+}
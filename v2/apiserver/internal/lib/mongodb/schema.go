@@ -0,0 +1,515 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrations is the ordered list of all of Brigade's Migrations. Append new
+// Migrations to the end of this list. NEVER modify or reorder Migrations that
+// have already been released.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Create initial indexes",
+		Migrate:     createInitialIndexes,
+	},
+	{
+		Version:     2,
+		Description: "Index service account previous tokens",
+		Migrate:     indexServiceAccountPreviousTokens,
+	},
+	{
+		Version:     3,
+		Description: "Index sessions by refresh token and user",
+		Migrate:     indexSessionRefreshTokensAndUsers,
+	},
+	{
+		Version:     4,
+		Description: "Index personal access tokens",
+		Migrate:     indexPersonalAccessTokens,
+	},
+	{
+		Version:     5,
+		Description: "Index groups",
+		Migrate:     indexGroups,
+	},
+	{
+		Version:     6,
+		Description: "Index user invitations",
+		Migrate:     indexUserInvitations,
+	},
+	{
+		Version:     7,
+		Description: "Index role definitions",
+		Migrate:     indexRoleDefinitions,
+	},
+	{
+		Version:     8,
+		Description: "Index teams",
+		Migrate:     indexTeams,
+	},
+	{
+		Version:     9,
+		Description: "Backfill project resource versions",
+		Migrate:     backfillProjectResourceVersions,
+	},
+}
+
+// createInitialIndexes creates all indexes that predate the introduction of
+// Migrations. Creating an index that already exists is a no-op, so this is safe
+// to apply to databases that were initialized before Migrations existed.
+func createInitialIndexes(ctx context.Context, database *mongo.Database) error {
+	unique := true
+	collectionIndexes := []struct {
+		collection string
+		indexes    []mongo.IndexModel
+	}{
+		{
+			collection: "projects",
+			indexes: []mongo.IndexModel{
+				{
+					Keys: bson.M{
+						"id": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// This facilitates paging through a list sorted by creation date/time,
+				// with ties broken by ID
+				{
+					Keys: bson.D{
+						{Key: "created", Value: 1},
+						{Key: "id", Value: 1},
+					},
+				},
+				// This facilitates quickly selecting by label
+				{
+					Keys: bson.D{
+						{Key: "metadataLabels.key", Value: 1},
+						{Key: "metadataLabels.value", Value: 1},
+					},
+				},
+			},
+		},
+		{
+			collection: "events",
+			indexes: []mongo.IndexModel{
+				{
+					Keys: bson.M{
+						"id": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// This facilitates sorting by event creation date/time, with ties
+				// broken by ID
+				{
+					Keys: bson.D{
+						{Key: "created", Value: -1},
+						{Key: "id", Value: -1},
+					},
+				},
+				// This facilitates paging through events for a given project and/or in
+				// given worker phases
+				{
+					Keys: bson.D{
+						{Key: "projectID", Value: 1},
+						{Key: "worker.status.phase", Value: 1},
+						{Key: "created", Value: -1},
+						{Key: "id", Value: -1},
+					},
+				},
+				// This facilitates quickly selecting all events for a given project
+				{
+					Keys: bson.M{
+						"projectID": 1,
+					},
+				},
+				// This facilitates quickly selecting events by source and type
+				{
+					Keys: bson.D{
+						{Key: "source", Value: 1},
+						{Key: "type", Value: 1},
+						{Key: "created", Value: -1},
+					},
+				},
+				// This facilitates quickly selecting events by label
+				{
+					Keys: bson.D{
+						{Key: "labels.key", Value: 1},
+						{Key: "labels.value", Value: 1},
+					},
+				},
+				// This facilitates searching event titles
+				{
+					Keys: bson.D{
+						{Key: "shortTitle", Value: "text"},
+						{Key: "longTitle", Value: "text"},
+					},
+				},
+			},
+		},
+		{
+			collection: "users",
+			indexes: []mongo.IndexModel{
+				{
+					Keys: bson.M{
+						"id": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// This facilitates paging through a list sorted by creation date/time,
+				// with ties broken by ID
+				{
+					Keys: bson.D{
+						{Key: "created", Value: 1},
+						{Key: "id", Value: 1},
+					},
+				},
+				// This facilitates quickly selecting by label
+				{
+					Keys: bson.D{
+						{Key: "metadataLabels.key", Value: 1},
+						{Key: "metadataLabels.value", Value: 1},
+					},
+				},
+			},
+		},
+		{
+			collection: "service-accounts",
+			indexes: []mongo.IndexModel{
+				{
+					Keys: bson.M{
+						"id": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// This facilitates paging through a list sorted by creation date/time,
+				// with ties broken by ID
+				{
+					Keys: bson.D{
+						{Key: "created", Value: 1},
+						{Key: "id", Value: 1},
+					},
+				},
+				// This facilitates quickly selecting by label
+				{
+					Keys: bson.D{
+						{Key: "metadataLabels.key", Value: 1},
+						{Key: "metadataLabels.value", Value: 1},
+					},
+				},
+				// Fast lookup by bearer token
+				{
+					Keys: bson.M{
+						"hashedToken": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+			},
+		},
+		{
+			collection: "sessions",
+			indexes: []mongo.IndexModel{
+				{
+					Keys: bson.M{
+						"id": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// Fast lookup by token
+				{
+					Keys: bson.M{
+						"hashedToken": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+			},
+		},
+	}
+	for _, ci := range collectionIndexes {
+		if _, err := database.Collection(ci.collection).Indexes().CreateMany(
+			ctx,
+			ci.indexes,
+		); err != nil {
+			return errors.Wrapf(
+				err,
+				"error adding indexes to %s collection",
+				ci.collection,
+			)
+		}
+	}
+	return nil
+}
+
+// indexServiceAccountPreviousTokens facilitates fast lookup of ServiceAccounts
+// by the token that was replaced by their most recent token rotation. Most
+// ServiceAccounts have no such token, so the index is sparse.
+func indexServiceAccountPreviousTokens(
+	ctx context.Context,
+	database *mongo.Database,
+) error {
+	sparse := true
+	if _, err := database.Collection("service-accounts").Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.M{
+				"previousHashedToken": 1,
+			},
+			Options: &options.IndexOptions{
+				Sparse: &sparse,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(
+			err,
+			"error adding previous token index to service-accounts collection",
+		)
+	}
+	return nil
+}
+
+// indexSessionRefreshTokensAndUsers facilitates fast lookup of Sessions by
+// refresh token and paging through a User's Sessions sorted by creation
+// date/time, with ties broken by ID. Sessions that predate refresh tokens have
+// none, so the refresh token index is sparse.
+func indexSessionRefreshTokensAndUsers(
+	ctx context.Context,
+	database *mongo.Database,
+) error {
+	unique := true
+	sparse := true
+	if _, err := database.Collection("sessions").Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{
+				Keys: bson.M{
+					"hashedRefreshToken": 1,
+				},
+				Options: &options.IndexOptions{
+					Unique: &unique,
+					Sparse: &sparse,
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "userID", Value: 1},
+					{Key: "created", Value: 1},
+					{Key: "id", Value: 1},
+				},
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "error adding indexes to sessions collection")
+	}
+	return nil
+}
+
+// indexPersonalAccessTokens creates indexes for the personal-access-tokens
+// collection.
+func indexPersonalAccessTokens(
+	ctx context.Context,
+	database *mongo.Database,
+) error {
+	unique := true
+	if _, err := database.Collection("personal-access-tokens").Indexes().
+		CreateMany(
+			ctx,
+			[]mongo.IndexModel{
+				{
+					Keys: bson.M{
+						"id": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// Fast lookup by bearer token
+				{
+					Keys: bson.M{
+						"hashedToken": 1,
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// Token names are unique per user
+				{
+					Keys: bson.D{
+						{Key: "userID", Value: 1},
+						{Key: "name", Value: 1},
+					},
+					Options: &options.IndexOptions{
+						Unique: &unique,
+					},
+				},
+				// This facilitates paging through a user's tokens sorted by creation
+				// date/time, with ties broken by ID
+				{
+					Keys: bson.D{
+						{Key: "userID", Value: 1},
+						{Key: "created", Value: 1},
+						{Key: "id", Value: 1},
+					},
+				},
+			},
+		); err != nil {
+		return errors.Wrap(
+			err,
+			"error adding indexes to personal-access-tokens collection",
+		)
+	}
+	return nil
+}
+
+// indexGroups ensures there is at most one document recording the Roles held
+// by any given group.
+func indexGroups(ctx context.Context, database *mongo.Database) error {
+	unique := true
+	if _, err := database.Collection("groups").Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.M{
+				"id": 1,
+			},
+			Options: &options.IndexOptions{
+				Unique: &unique,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "error adding index to groups collection")
+	}
+	return nil
+}
+
+// indexUserInvitations ensures there is at most one UserInvitation for any
+// given email address and facilitates paging through UserInvitations sorted by
+// creation date/time, with ties broken by ID.
+func indexUserInvitations(
+	ctx context.Context,
+	database *mongo.Database,
+) error {
+	unique := true
+	if _, err := database.Collection("user-invitations").Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{
+				Keys: bson.M{
+					"id": 1,
+				},
+				Options: &options.IndexOptions{
+					Unique: &unique,
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "created", Value: 1},
+					{Key: "id", Value: 1},
+				},
+			},
+		},
+	); err != nil {
+		return errors.Wrap(
+			err,
+			"error adding indexes to user-invitations collection",
+		)
+	}
+	return nil
+}
+
+// indexRoleDefinitions ensures there is at most one RoleDefinition for any
+// given custom Role name.
+func indexRoleDefinitions(ctx context.Context, database *mongo.Database) error {
+	unique := true
+	if _, err := database.Collection("role-definitions").Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.M{
+				"id": 1,
+			},
+			Options: &options.IndexOptions{
+				Unique: &unique,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(
+			err,
+			"error adding index to role-definitions collection",
+		)
+	}
+	return nil
+}
+
+// indexTeams ensures there is at most one Team with any given ID, facilitates
+// paging through Teams sorted by creation date/time, with ties broken by ID,
+// and facilitates finding all Teams a given principal is a member of.
+func indexTeams(ctx context.Context, database *mongo.Database) error {
+	unique := true
+	if _, err := database.Collection("teams").Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{
+				Keys: bson.M{
+					"id": 1,
+				},
+				Options: &options.IndexOptions{
+					Unique: &unique,
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "created", Value: 1},
+					{Key: "id", Value: 1},
+				},
+			},
+			{
+				Keys: bson.M{
+					"members": 1,
+				},
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "error adding indexes to teams collection")
+	}
+	return nil
+}
+
+// backfillProjectResourceVersions assigns an initial resource version to any
+// Project created before resource versions were introduced. Without one, such
+// a Project's ETag could not be used in an If-Match header.
+func backfillProjectResourceVersions(
+	ctx context.Context,
+	database *mongo.Database,
+) error {
+	if _, err := database.Collection("projects").UpdateMany(
+		ctx,
+		bson.M{
+			"resourceVersion": bson.M{"$exists": false},
+		},
+		bson.M{
+			"$set": bson.M{
+				"resourceVersion": 1,
+			},
+		},
+	); err != nil {
+		return errors.Wrap(err, "error backfilling project resource versions")
+	}
+	return nil
+}
@@ -13,18 +13,20 @@ import (
 const envconfigPrefix = "OIDC"
 
 type config struct {
-	Enabled         bool   `envconfig:"ENABLED"`
-	ProviderURL     string `envconfig:"PROVIDER_URL"`
-	ClientID        string `envconfig:"CLIENT_ID"`
-	ClientSecret    string `envconfig:"CLIENT_SECRET"`
-	RedirectURLBase string `envconfig:"REDIRECT_URL_BASE"`
+	Enabled         bool     `envconfig:"ENABLED"`
+	ProviderURL     string   `envconfig:"PROVIDER_URL"`
+	ClientID        string   `envconfig:"CLIENT_ID"`
+	ClientSecret    string   `envconfig:"CLIENT_SECRET"`
+	RedirectURLBase string   `envconfig:"REDIRECT_URL_BASE"`
+	GroupsClaim     string   `envconfig:"GROUPS_CLAIM" default:"groups"`
+	ExtraScopes     []string `envconfig:"EXTRA_SCOPES"`
 }
 
 // GetConfigAndVerifierFromEnvironment returns OAuth client configuration and an
-// OIDC identity token verifier, all derived from environment variables.
+// IdentityVerifier, all derived from environment variables.
 func GetConfigAndVerifierFromEnvironment() (
 	*oauth2.Config,
-	*oidc.IDTokenVerifier,
+	IdentityVerifier,
 	error,
 ) {
 	c := config{}
@@ -75,14 +77,20 @@ func GetConfigAndVerifierFromEnvironment() (
 			c.RedirectURLBase,
 			"v2/session/auth",
 		),
-		Scopes: []string{oidc.ScopeOpenID, "profile", "email"},
+		Scopes: append(
+			[]string{oidc.ScopeOpenID, "profile", "email"},
+			c.ExtraScopes...,
+		),
 	}
 
-	verifier := provider.Verifier(
-		&oidc.Config{
-			ClientID: c.ClientID,
-		},
-	)
+	verifier := &identityVerifier{
+		tokenVerifier: provider.Verifier(
+			&oidc.Config{
+				ClientID: c.ClientID,
+			},
+		),
+		groupsClaim: c.GroupsClaim,
+	}
 
 	return config, verifier, nil
 }
@@ -0,0 +1,144 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/coreos/go-oidc"
+	"github.com/pkg/errors"
+)
+
+// Identity encapsulates those claims from a verified OpenID Connect identity
+// token that are of interest to Brigade.
+type Identity struct {
+	// Name is the user's full name.
+	Name string
+	// Email is the user's email address.
+	Email string
+	// EmailVerified indicates whether the identity provider has verified that
+	// the user controls the email address. It is nil if the identity token did
+	// not include the email_verified claim, which some identity providers omit.
+	EmailVerified *bool
+	// Groups enumerates the groups that the identity provider says the user
+	// belongs to. It will be empty if the identity token did not include the
+	// configured groups claim.
+	Groups []string
+}
+
+// IdentityVerifier is an interface for components that can verify an OpenID
+// Connect identity token and extract an Identity from its claims.
+type IdentityVerifier interface {
+	// Verify verifies the provided raw identity token and returns the Identity
+	// described by its claims.
+	Verify(ctx context.Context, rawIDToken string) (Identity, error)
+}
+
+type identityVerifier struct {
+	tokenVerifier *oidc.IDTokenVerifier
+	groupsClaim   string
+}
+
+func (i *identityVerifier) Verify(
+	ctx context.Context,
+	rawIDToken string,
+) (Identity, error) {
+	identity := Identity{}
+	idToken, err := i.tokenVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return identity,
+			errors.Wrap(err, "error verifying OpenID Connect identity token")
+	}
+	claims := map[string]json.RawMessage{}
+	if err = idToken.Claims(&claims); err != nil {
+		return identity, errors.Wrap(
+			err,
+			"error decoding OpenID Connect identity token claims",
+		)
+	}
+	if err = unmarshalClaim(claims, "name", &identity.Name); err != nil {
+		return identity, err
+	}
+	if err = unmarshalClaim(claims, "email", &identity.Email); err != nil {
+		return identity, err
+	}
+	if identity.EmailVerified, err =
+		emailVerifiedFromClaim(claims["email_verified"]); err != nil {
+		return identity, errors.Wrap(
+			err,
+			`error decoding OpenID Connect identity token claim "email_verified"`,
+		)
+	}
+	if i.groupsClaim != "" {
+		identity.Groups, err = groupsFromClaim(claims[i.groupsClaim])
+		if err != nil {
+			return identity, errors.Wrapf(
+				err,
+				"error decoding OpenID Connect identity token claim %q",
+				i.groupsClaim,
+			)
+		}
+	}
+	return identity, nil
+}
+
+// unmarshalClaim unmarshals the named claim, if present, into the provided
+// value.
+func unmarshalClaim(
+	claims map[string]json.RawMessage,
+	name string,
+	value interface{},
+) error {
+	raw, ok := claims[name]
+	if !ok {
+		return nil
+	}
+	return errors.Wrapf(
+		json.Unmarshal(raw, value),
+		"error decoding OpenID Connect identity token claim %q",
+		name,
+	)
+}
+
+// emailVerifiedFromClaim returns the value of the provided raw claim. The claim
+// is supposed to be a boolean, but some identity providers represent it as a
+// string. Both are accommodated. If the claim is absent, nil is returned.
+func emailVerifiedFromClaim(raw json.RawMessage) (*bool, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var verified bool
+	if err := json.Unmarshal(raw, &verified); err == nil {
+		return &verified, nil
+	}
+	var verifiedStr string
+	if err := json.Unmarshal(raw, &verifiedStr); err != nil {
+		return nil, errors.New("claim is neither a boolean nor a string")
+	}
+	verified, err := strconv.ParseBool(verifiedStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing %q as a boolean", verifiedStr)
+	}
+	return &verified, nil
+}
+
+// groupsFromClaim returns the groups listed in the provided raw claim. Most
+// identity providers represent groups as an array of strings, but some
+// represent a single group as a lone string. Both are accommodated.
+func groupsFromClaim(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	groups := []string{}
+	if err := json.Unmarshal(raw, &groups); err == nil {
+		return groups, nil
+	}
+	var group string
+	if err := json.Unmarshal(raw, &group); err != nil {
+		return nil, errors.New("claim is neither a string nor an array of strings")
+	}
+	if group == "" {
+		return nil, nil
+	}
+	return []string{group}, nil
+}
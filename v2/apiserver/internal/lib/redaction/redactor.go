@@ -0,0 +1,160 @@
+package redaction
+
+import "strings"
+
+// Redactor masks occurrences of any of a fixed set of sensitive strings within
+// arbitrary input. Matching is performed using the Aho-Corasick algorithm, so
+// the cost of redacting an input is proportional to the length of that input
+// and does not grow with the number of sensitive strings.
+type Redactor interface {
+	// Redact returns a copy of the input wherein every occurrence of a sensitive
+	// string has been replaced with the Redactor's mask. Where occurrences
+	// overlap or are adjacent, they're replaced with a single mask.
+	Redact(input string) string
+}
+
+// node is a node of the trie underlying the Aho-Corasick automaton.
+type node struct {
+	// children maps the next byte of input to the next node of the trie.
+	children map[byte]*node
+	// fail is the node representing the longest proper suffix of the string
+	// represented by this node that is also present in the trie.
+	fail *node
+	// matchLen is the length of the longest sensitive string that ends at this
+	// node, either directly or by way of fail links. It is zero if none does.
+	matchLen int
+}
+
+// MinSensitiveLineLength is the length below which SplitLines discards a
+// line. Very short strings occur so frequently in ordinary output that masking
+// them would render logs unreadable while revealing the sensitive value anyway.
+const MinSensitiveLineLength = 4
+
+// SplitLines returns the lines of all the provided strings that are worth
+// redacting. Input that is redacted line by line can never contain the whole of
+// a multi-line sensitive string, so such strings should be split before being
+// passed to NewRedactor. Each line is trimmed of surrounding whitespace and
+// discarded if it is shorter than MinSensitiveLineLength, contains no letters
+// or digits (e.g. the braces of a JSON document), or is a PEM encapsulation
+// boundary (e.g. "-----END PRIVATE KEY-----"). Any of these would otherwise
+// cause innocuous text to be masked wherever it appears.
+func SplitLines(strs []string) []string {
+	lines := make([]string, 0, len(strs))
+	for _, str := range strs {
+		for _, line := range strings.Split(str, "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) < MinSensitiveLineLength ||
+				!strings.ContainsAny(line, alphanumerics) ||
+				(strings.HasPrefix(line, "-----") &&
+					strings.HasSuffix(line, "-----")) {
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+const alphanumerics = "abcdefghijklmnopqrstuvwxyz" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"0123456789"
+
+type redactor struct {
+	root *node
+	mask string
+}
+
+// NewRedactor returns a Redactor that replaces any occurrence of the provided
+// sensitive strings with the provided mask. Empty strings are ignored.
+func NewRedactor(sensitiveStrings []string, mask string) Redactor {
+	root := &node{children: map[byte]*node{}}
+	for _, str := range sensitiveStrings {
+		if str == "" {
+			continue
+		}
+		n := root
+		for i := 0; i < len(str); i++ {
+			child, ok := n.children[str[i]]
+			if !ok {
+				child = &node{children: map[byte]*node{}}
+				n.children[str[i]] = child
+			}
+			n = child
+		}
+		if len(str) > n.matchLen {
+			n.matchLen = len(str)
+		}
+	}
+	// Build fail links breadth-first so that every node's fail link (which
+	// always points to a shallower node) is set before it's needed.
+	root.fail = root
+	queue := []*node{}
+	for _, child := range root.children {
+		child.fail = root
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for b, child := range n.children {
+			fail := n.fail
+			for fail != root && fail.children[b] == nil {
+				fail = fail.fail
+			}
+			if next, ok := fail.children[b]; ok && next != child {
+				child.fail = next
+			} else {
+				child.fail = root
+			}
+			if child.fail.matchLen > child.matchLen {
+				child.matchLen = child.fail.matchLen
+			}
+			queue = append(queue, child)
+		}
+	}
+	return &redactor{
+		root: root,
+		mask: mask,
+	}
+}
+
+func (r *redactor) Redact(input string) string {
+	if len(r.root.children) == 0 {
+		return input
+	}
+	// Find every byte range covered by at least one match. Since each match is
+	// discovered at its end, we track, for every position, the furthest back
+	// any match ending there extends.
+	var covered []bool
+	n := r.root
+	for i := 0; i < len(input); i++ {
+		for n != r.root && n.children[input[i]] == nil {
+			n = n.fail
+		}
+		if next, ok := n.children[input[i]]; ok {
+			n = next
+		}
+		if n.matchLen > 0 {
+			if covered == nil {
+				covered = make([]bool, len(input))
+			}
+			for j := i - n.matchLen + 1; j <= i; j++ {
+				covered[j] = true
+			}
+		}
+	}
+	if covered == nil {
+		return input
+	}
+	sb := strings.Builder{}
+	for i := 0; i < len(input); i++ {
+		if !covered[i] {
+			sb.WriteByte(input[i])
+			continue
+		}
+		if i == 0 || !covered[i-1] {
+			sb.WriteString(r.mask)
+		}
+	}
+	return sb.String()
+}
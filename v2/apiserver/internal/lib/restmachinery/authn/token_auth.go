@@ -30,14 +30,20 @@ type FindServiceAccountFn func(
 	token string,
 ) (authx.ServiceAccount, error)
 
+type FindPersonalAccessTokenFn func(
+	ctx context.Context,
+	token string,
+) (authx.PersonalAccessToken, error)
+
 type tokenAuthFilter struct {
-	findSession          FindSessionFn
-	findEvent            FindEventFn
-	findUser             FindUserFn
-	findServiceAccount   FindServiceAccountFn
-	rootUserEnabled      bool
-	hashedSchedulerToken string
-	hashedObserverToken  string
+	findSession             FindSessionFn
+	findEvent               FindEventFn
+	findUser                FindUserFn
+	findServiceAccount      FindServiceAccountFn
+	findPersonalAccessToken FindPersonalAccessTokenFn
+	rootUserEnabled         bool
+	hashedSchedulerToken    string
+	hashedObserverToken     string
 }
 
 func NewTokenAuthFilter(
@@ -45,18 +51,20 @@ func NewTokenAuthFilter(
 	findEvent FindEventFn,
 	findUser FindUserFn,
 	findServiceAccount FindServiceAccountFn,
+	findPersonalAccessToken FindPersonalAccessTokenFn,
 	rootUserEnabled bool,
 	hashedSchedulerToken string,
 	hashedObserverToken string,
 ) restmachinery.Filter {
 	return &tokenAuthFilter{
-		findSession:          findSession,
-		findEvent:            findEvent,
-		findUser:             findUser,
-		findServiceAccount:   findServiceAccount,
-		rootUserEnabled:      rootUserEnabled,
-		hashedSchedulerToken: hashedSchedulerToken,
-		hashedObserverToken:  hashedObserverToken,
+		findSession:             findSession,
+		findEvent:               findEvent,
+		findUser:                findUser,
+		findServiceAccount:      findServiceAccount,
+		findPersonalAccessToken: findPersonalAccessToken,
+		rootUserEnabled:         rootUserEnabled,
+		hashedSchedulerToken:    hashedSchedulerToken,
+		hashedObserverToken:     hashedObserverToken,
 	}
 }
 
@@ -104,45 +112,139 @@ func (t *tokenAuthFilter) Decorate(handle http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// Tokens issued by this version of Brigade indicate their type and carry a
+		// checksum. Reject those that are malformed without consulting any data
+		// store. Tokens bearing no type were issued by an earlier version of
+		// Brigade and may be of any type.
+		tokenType := crypto.TokenTypeOf(token)
+		if tokenType != "" && !crypto.ValidTokenChecksum(token) {
+			t.writeResponse(
+				w,
+				http.StatusUnauthorized,
+				&meta.ErrAuthentication{
+					Reason: "Supplied token is malformed.",
+				},
+			)
+			return
+		}
+		mayBe := func(candidateType crypto.TokenType) bool {
+			return tokenType == "" || tokenType == candidateType
+		}
+
 		// Is it a Worker's token?
-		if event, err := t.findEvent(r.Context(), token); err != nil {
-			if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
-				log.Println(err)
-				t.writeResponse(
-					w,
-					http.StatusInternalServerError,
-					&meta.ErrInternalServer{},
-				)
+		if mayBe(crypto.TokenTypeWorker) {
+			if event, err := t.findEvent(r.Context(), token); err != nil {
+				if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+					log.Println(err)
+					t.writeResponse(
+						w,
+						http.StatusInternalServerError,
+						&meta.ErrInternalServer{},
+					)
+					return
+				}
+			} else {
+				ctx := authx.ContextWithPrincipal(r.Context(), authx.Worker(event.ID))
+				handle(w, r.WithContext(ctx))
 				return
 			}
-		} else {
-			ctx := authx.ContextWithPrincipal(r.Context(), authx.Worker(event.ID))
-			handle(w, r.WithContext(ctx))
-			return
 		}
 
 		// Is it a ServiceAccount's token?
-		if serviceAccount, err :=
-			t.findServiceAccount(r.Context(), token); err != nil {
-			if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
-				log.Println(err)
-				t.writeResponse(
-					w,
-					http.StatusInternalServerError,
-					&meta.ErrInternalServer{},
-				)
+		if mayBe(crypto.TokenTypeServiceAccount) {
+			if serviceAccount, err :=
+				t.findServiceAccount(r.Context(), token); err != nil {
+				if authErr, ok :=
+					errors.Cause(err).(*meta.ErrAuthentication); ok {
+					// e.g. The token has expired
+					t.writeResponse(w, http.StatusUnauthorized, authErr)
+					return
+				}
+				if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+					log.Println(err)
+					t.writeResponse(
+						w,
+						http.StatusInternalServerError,
+						&meta.ErrInternalServer{},
+					)
+					return
+				}
+			} else {
+				if serviceAccount.Locked != nil {
+					http.Error(w, "{}", http.StatusForbidden)
+					return
+				}
+				ctx := authx.ContextWithPrincipal(r.Context(), &serviceAccount)
+				handle(w, r.WithContext(ctx))
 				return
 			}
-		} else {
-			if serviceAccount.Locked != nil {
-				http.Error(w, "{}", http.StatusForbidden)
+		}
+
+		// Is it a User's personal access token?
+		if mayBe(crypto.TokenTypePersonalAccess) {
+			if personalAccessToken, err :=
+				t.findPersonalAccessToken(r.Context(), token); err != nil {
+				if authErr, ok :=
+					errors.Cause(err).(*meta.ErrAuthentication); ok {
+					// e.g. The token has expired
+					t.writeResponse(w, http.StatusUnauthorized, authErr)
+					return
+				}
+				if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+					log.Println(err)
+					t.writeResponse(
+						w,
+						http.StatusInternalServerError,
+						&meta.ErrInternalServer{},
+					)
+					return
+				}
+			} else {
+				user, err := t.findUser(r.Context(), personalAccessToken.UserID)
+				if err != nil {
+					log.Println(err)
+					// There should never be a personal access token for a user that
+					// doesn't exist.
+					t.writeResponse(
+						w,
+						http.StatusInternalServerError,
+						&meta.ErrInternalServer{},
+					)
+					return
+				}
+				if user.Locked != nil {
+					http.Error(w, "{}", http.StatusForbidden)
+					return
+				}
+				// Group membership is only known as of the user's last interactive
+				// login and personal access tokens may be used indefinitely without
+				// one, so the token never carries roles held by virtue of group
+				// membership. It may carry only a subset of the user's remaining
+				// roles, including those held by virtue of team membership.
+				user.GroupRoles = nil
+				user.UserRoles = personalAccessToken.EffectiveRoles(user.Roles())
+				user.TeamRoles = nil
+				ctx := authx.ContextWithPrincipal(r.Context(), &user)
+				ctx = authx.ContextWithPersonalAccessTokenID(
+					ctx,
+					personalAccessToken.ID,
+				)
+				handle(w, r.WithContext(ctx))
 				return
 			}
-			ctx := authx.ContextWithPrincipal(r.Context(), &serviceAccount)
-			handle(w, r.WithContext(ctx))
-			return
 		}
 
+		// If it isn't a Session token, there is nothing left that it could be
+		if !mayBe(crypto.TokenTypeSession) {
+			t.writeResponse(
+				w,
+				http.StatusUnauthorized,
+				&meta.ErrAuthentication{
+					Reason: "Supplied token was not found.",
+				},
+			)
+			return
+		}
 		session, err := t.findSession(r.Context(), token)
 		if err != nil {
 			if _, ok := errors.Cause(err).(*meta.ErrNotFound); ok {
@@ -191,7 +293,8 @@ func (t *tokenAuthFilter) Decorate(handle http.HandlerFunc) http.HandlerFunc {
 				w,
 				http.StatusUnauthorized,
 				&meta.ErrAuthentication{
-					Reason: "Supplied token has expired. Please log in again.",
+					Reason: "Supplied token has expired. Please refresh the session " +
+						"or log in again.",
 				},
 			)
 			return
@@ -10,6 +10,7 @@ import (
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/stretchr/testify/require"
 )
@@ -23,6 +24,7 @@ func TestTokenAuthFilterWithHeaderMissing(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 		false,
 		testSchedulerToken,
 		testObserverToken,
@@ -44,6 +46,7 @@ func TestTokenAuthFilterWithHeaderNotBearer(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 		false,
 		testSchedulerToken,
 		testObserverToken,
@@ -72,6 +75,12 @@ func TestTokenAuthFilterWithTokenInvalid(t *testing.T) {
 		func(ctx context.Context, token string) (authx.ServiceAccount, error) {
 			return authx.ServiceAccount{}, &meta.ErrNotFound{}
 		},
+		func(
+			ctx context.Context,
+			token string,
+		) (authx.PersonalAccessToken, error) {
+			return authx.PersonalAccessToken{}, &meta.ErrNotFound{}
+		},
 		false,
 		testSchedulerToken,
 		testObserverToken,
@@ -91,6 +100,67 @@ func TestTokenAuthFilterWithTokenInvalid(t *testing.T) {
 	require.False(t, handlerCalled)
 }
 
+func TestTokenAuthFilterWithTypedTokenMalformed(t *testing.T) {
+	// None of the lookup functions are defined because a typed token with a bad
+	// checksum should be rejected without consulting any of them
+	a := NewTokenAuthFilter(
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		false,
+		testSchedulerToken,
+		testObserverToken,
+	)
+	token := crypto.NewTypedToken(crypto.TokenTypeServiceAccount)
+	// Corrupt the checksum
+	token = token[:len(token)-1] + "!"
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	req.Header.Add(
+		"Authorization",
+		fmt.Sprintf("Bearer %s", token),
+	)
+	rr := httptest.NewRecorder()
+	handlerCalled := false
+	a.Decorate(func(http.ResponseWriter, *http.Request) {
+		handlerCalled = true
+	})(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.False(t, handlerCalled)
+}
+
+func TestTokenAuthFilterWithServiceAccountTokenExpired(t *testing.T) {
+	a := NewTokenAuthFilter(
+		nil,
+		func(ctx context.Context, token string) (core.Event, error) {
+			return core.Event{}, &meta.ErrNotFound{}
+		},
+		nil,
+		func(ctx context.Context, token string) (authx.ServiceAccount, error) {
+			return authx.ServiceAccount{}, &meta.ErrAuthentication{
+				Reason: "Supplied token has expired.",
+			}
+		},
+		nil,
+		false,
+		testSchedulerToken,
+		testObserverToken,
+	)
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	req.Header.Add("Authorization", "Bearer foobar")
+	rr := httptest.NewRecorder()
+	handlerCalled := false
+	a.Decorate(func(http.ResponseWriter, *http.Request) {
+		handlerCalled = true
+	})(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.Contains(t, rr.Body.String(), "Supplied token has expired.")
+	require.False(t, handlerCalled)
+}
+
 func TestTokenAuthFilterWithUnauthenticatedSession(t *testing.T) {
 	a := NewTokenAuthFilter(
 		func(context.Context, string) (authx.Session, error) {
@@ -105,6 +175,12 @@ func TestTokenAuthFilterWithUnauthenticatedSession(t *testing.T) {
 		func(ctx context.Context, token string) (authx.ServiceAccount, error) {
 			return authx.ServiceAccount{}, &meta.ErrNotFound{}
 		},
+		func(
+			ctx context.Context,
+			token string,
+		) (authx.PersonalAccessToken, error) {
+			return authx.PersonalAccessToken{}, &meta.ErrNotFound{}
+		},
 		false,
 		testSchedulerToken,
 		testObserverToken,
@@ -146,6 +222,12 @@ func TestTokenAuthFilterWithAuthenticatedSession(t *testing.T) {
 		func(ctx context.Context, token string) (authx.ServiceAccount, error) {
 			return authx.ServiceAccount{}, &meta.ErrNotFound{}
 		},
+		func(
+			ctx context.Context,
+			token string,
+		) (authx.PersonalAccessToken, error) {
+			return authx.PersonalAccessToken{}, &meta.ErrNotFound{}
+		},
 		false,
 		testSchedulerToken,
 		testObserverToken,
@@ -163,3 +245,105 @@ func TestTokenAuthFilterWithAuthenticatedSession(t *testing.T) {
 	require.Equal(t, http.StatusOK, rr.Code)
 	require.True(t, handlerCalled)
 }
+
+func TestTokenAuthFilterWithPersonalAccessToken(t *testing.T) {
+	const testUserID = "tony@starkindustries.com"
+	const testTokenID = "foobar"
+	a := NewTokenAuthFilter(
+		nil,
+		func(ctx context.Context, token string) (core.Event, error) {
+			return core.Event{}, &meta.ErrNotFound{}
+		},
+		func(_ context.Context, id string) (authx.User, error) {
+			require.Equal(t, testUserID, id)
+			return authx.User{
+				ObjectMeta: meta.ObjectMeta{
+					ID: testUserID,
+				},
+				UserRoles: []authx.Role{
+					authx.RoleAdmin(),
+					authx.RoleProjectAdmin(authx.RoleScopeGlobal),
+				},
+			}, nil
+		},
+		func(ctx context.Context, token string) (authx.ServiceAccount, error) {
+			return authx.ServiceAccount{}, &meta.ErrNotFound{}
+		},
+		func(
+			ctx context.Context,
+			token string,
+		) (authx.PersonalAccessToken, error) {
+			return authx.PersonalAccessToken{
+				ObjectMeta: meta.ObjectMeta{
+					ID: testTokenID,
+				},
+				UserID: testUserID,
+				Roles: []authx.Role{
+					authx.RoleProjectAdmin("italian"),
+					// The user doesn't hold this one
+					authx.RoleReader(),
+				},
+			}, nil
+		},
+		false,
+		testSchedulerToken,
+		testObserverToken,
+	)
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	req.Header.Add("Authorization", "Bearer foobar")
+	rr := httptest.NewRecorder()
+	var handlerCalled bool
+	a.Decorate(func(_ http.ResponseWriter, r *http.Request) {
+		handlerCalled = true
+		user, ok := authx.PincipalFromContext(r.Context()).(*authx.User)
+		require.True(t, ok)
+		require.Equal(t, testUserID, user.ID)
+		require.Equal(
+			t,
+			[]authx.Role{authx.RoleProjectAdmin("italian")},
+			user.Roles(),
+		)
+		require.Equal(
+			t,
+			testTokenID,
+			authx.PersonalAccessTokenIDFromContext(r.Context()),
+		)
+	})(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, handlerCalled)
+}
+
+func TestTokenAuthFilterWithPersonalAccessTokenExpired(t *testing.T) {
+	a := NewTokenAuthFilter(
+		nil,
+		func(ctx context.Context, token string) (core.Event, error) {
+			return core.Event{}, &meta.ErrNotFound{}
+		},
+		nil,
+		func(ctx context.Context, token string) (authx.ServiceAccount, error) {
+			return authx.ServiceAccount{}, &meta.ErrNotFound{}
+		},
+		func(
+			ctx context.Context,
+			token string,
+		) (authx.PersonalAccessToken, error) {
+			return authx.PersonalAccessToken{}, &meta.ErrAuthentication{
+				Reason: "Supplied personal access token has expired.",
+			}
+		},
+		false,
+		testSchedulerToken,
+		testObserverToken,
+	)
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	req.Header.Add("Authorization", "Bearer foobar")
+	rr := httptest.NewRecorder()
+	handlerCalled := false
+	a.Decorate(func(http.ResponseWriter, *http.Request) {
+		handlerCalled = true
+	})(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.False(t, handlerCalled)
+}
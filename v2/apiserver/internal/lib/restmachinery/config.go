@@ -2,6 +2,8 @@ package restmachinery
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
 	"github.com/kelseyhightower/envconfig"
@@ -9,6 +11,10 @@ import (
 
 const envconfigPrefix = "API_SERVER"
 
+// minTokenHashKeyLength is the minimum length of the server-side key used for
+// hashing tokens.
+const minTokenHashKeyLength = 32
+
 // We use an exported interface to govern access to our config because the
 // underlying struct has fields we don't want to expose.
 type Config interface {
@@ -17,6 +23,9 @@ type Config interface {
 	HashedRootUserPassword() string
 	HashedSchedulerToken() string
 	HashedObserverToken() string
+	TokenHashKey() string
+	SessionTTL() time.Duration
+	RefreshTokenTTL() time.Duration
 	TLSEnabled() bool
 	TLSCertPath() string
 	TLSKeyPath() string
@@ -31,16 +40,23 @@ type config struct {
 	HashedSchedulerTokenAttr   string
 	ObserverTokenAttr          string `envconfig:"OBSERVER_TOKEN" required:"true"` // nolint: lll
 	HashedObserverTokenAttr    string
-	TLSEnabledAttr             bool   `envconfig:"TLS_ENABLED"`
-	TLSCertPathAttr            string `envconfig:"TLS_CERT_PATH"`
-	TLSKeyPathAttr             string `envconfig:"TLS_KEY_PATH"`
+	TokenHashKeyAttr           string        `envconfig:"TOKEN_HASH_KEY" required:"true"` // nolint: lll
+	SessionTTLAttr             time.Duration `envconfig:"SESSION_TTL"`
+	RefreshTokenTTLAttr        time.Duration `envconfig:"REFRESH_TOKEN_TTL"`
+	TLSEnabledAttr             bool          `envconfig:"TLS_ENABLED"`
+	TLSCertPathAttr            string        `envconfig:"TLS_CERT_PATH"`
+	TLSKeyPathAttr             string        `envconfig:"TLS_KEY_PATH"`
 }
 
 // NewConfigWithDefaults returns a Config object with default values already
 // applied. Callers are then free to set custom values for the remaining fields
 // and/or override default values.
 func NewConfigWithDefaults() Config {
-	return &config{PortAttr: 8080}
+	return &config{
+		PortAttr:            8080,
+		SessionTTLAttr:      time.Hour,
+		RefreshTokenTTLAttr: 7 * 24 * time.Hour,
+	}
 }
 
 // GetConfigFromEnvironment returns configuration derived from environment
@@ -58,6 +74,27 @@ func GetConfigFromEnvironment() (Config, error) {
 		)
 	}
 
+	if len(c.TokenHashKeyAttr) < minTokenHashKeyLength {
+		return c, fmt.Errorf(
+			"the value of the TOKEN_HASH_KEY environment variable must be at "+
+				"least %d characters long",
+			minTokenHashKeyLength,
+		)
+	}
+
+	if c.SessionTTLAttr <= 0 {
+		return c, errors.New(
+			"the value of the SESSION_TTL environment variable must be a " +
+				"positive duration",
+		)
+	}
+	if c.RefreshTokenTTLAttr < c.SessionTTLAttr {
+		return c, errors.New(
+			"the value of the REFRESH_TOKEN_TTL environment variable must be a " +
+				"duration no shorter than that of SESSION_TTL",
+		)
+	}
+
 	if c.TLSEnabledAttr {
 		if c.TLSCertPathAttr == "" {
 			return c, errors.New(
@@ -108,6 +145,18 @@ func (c *config) HashedObserverToken() string {
 	return c.HashedObserverTokenAttr
 }
 
+func (c *config) TokenHashKey() string {
+	return c.TokenHashKeyAttr
+}
+
+func (c *config) SessionTTL() time.Duration {
+	return c.SessionTTLAttr
+}
+
+func (c *config) RefreshTokenTTL() time.Duration {
+	return c.RefreshTokenTTLAttr
+}
+
 func (c *config) TLSEnabled() bool {
 	return c.TLSEnabledAttr
 }
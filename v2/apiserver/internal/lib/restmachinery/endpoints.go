@@ -15,6 +15,9 @@ import (
 
 type Endpoints interface {
 	Register(router *mux.Router)
+	// Operations describes every route registered by Register for inclusion in
+	// the API's OpenAPI document.
+	Operations() []Operation
 }
 
 type BaseEndpoints struct {
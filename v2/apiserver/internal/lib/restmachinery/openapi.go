@@ -0,0 +1,348 @@
+package restmachinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brigadecore/brigade/v2/internal/version"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// Operation describes a single API operation for inclusion in the API's
+// OpenAPI document.
+type Operation struct {
+	// Method is the operation's HTTP method.
+	Method string
+	// Path is the operation's path template, exactly as registered with the
+	// router, e.g. /v2/projects/{id}.
+	Path string
+	// Summary is a brief description of the operation.
+	Summary string
+	// QueryParams enumerates query parameters supported by the operation.
+	QueryParams []QueryParam
+	// RequestSchema optionally specifies the name of a file, within the schemas
+	// directory, containing a JSON schema for the operation's request body.
+	RequestSchema string
+	// SuccessCode is the HTTP status code returned when the operation succeeds.
+	// If unspecified, http.StatusOK is assumed.
+	SuccessCode int
+	// SuccessContentType is the content type of the response body when the
+	// operation succeeds. If unspecified, application/json is assumed.
+	SuccessContentType string
+	// Unauthenticated indicates that the operation does not require a bearer
+	// token.
+	Unauthenticated bool
+}
+
+// QueryParam describes a query parameter supported by an Operation.
+type QueryParam struct {
+	// Name is the name of the query parameter.
+	Name string
+	// Description is a brief description of the query parameter.
+	Description string
+}
+
+// pathParamRegex matches path parameters within path templates.
+var pathParamRegex = regexp.MustCompile(`{([^}:]+)(?::[^}]+)?}`)
+
+// builtInOperations describes operations that are served directly by the
+// server rather than by any Endpoints.
+var builtInOperations = []Operation{
+	{
+		Method:          http.MethodGet,
+		Path:            "/healthz",
+		Summary:         "Check the health of the API server",
+		Unauthenticated: true,
+	},
+	{
+		Method:          http.MethodGet,
+		Path:            "/v2/openapi.json",
+		Summary:         "Get the OpenAPI document describing this API",
+		Unauthenticated: true,
+	},
+}
+
+// NewOpenAPIDocument returns an OpenAPI 3 document, in JSON format, describing
+// all routes registered by the provided Endpoints. Request body schemas are
+// read from the specified schemas directory. An error is returned if any
+// registered route is undocumented or if any documented Operation does not
+// correspond to a registered route.
+func NewOpenAPIDocument(
+	endpoints []Endpoints,
+	schemasPath string,
+) ([]byte, error) {
+	router := mux.NewRouter()
+	for _, eps := range endpoints {
+		eps.Register(router)
+	}
+	routes := map[string]struct{}{}
+	if err := router.Walk(
+		func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			methods, err := route.GetMethods()
+			if err != nil {
+				return errors.Wrapf(err, "route %s specifies no methods", path)
+			}
+			for _, method := range methods {
+				routes[operationKey(method, path)] = struct{}{}
+			}
+			return nil
+		},
+	); err != nil {
+		return nil, errors.Wrap(err, "error enumerating routes")
+	}
+
+	operations := map[string]Operation{}
+	for _, eps := range endpoints {
+		for _, op := range eps.Operations() {
+			operations[operationKey(op.Method, op.Path)] = op
+		}
+	}
+
+	problems := []string{}
+	for key := range routes {
+		if _, ok := operations[key]; !ok {
+			problems = append(problems, fmt.Sprintf("route %s is undocumented", key))
+		}
+	}
+	for key := range operations {
+		if _, ok := routes[key]; !ok {
+			problems = append(
+				problems,
+				fmt.Sprintf("documented operation %s is not a registered route", key),
+			)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, errors.Errorf(
+			"OpenAPI document is inconsistent with registered routes: %s",
+			strings.Join(problems, "; "),
+		)
+	}
+
+	for _, op := range builtInOperations {
+		operations[operationKey(op.Method, op.Path)] = op
+	}
+
+	paths := map[string]map[string]interface{}{}
+	schemas := map[string]interface{}{}
+	for _, op := range operations {
+		operation, err := openAPIOperation(op, schemasPath, schemas)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := paths[op.Path]; !ok {
+			paths[op.Path] = map[string]interface{}{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	docBytes, err := json.MarshalIndent(
+		map[string]interface{}{
+			"openapi": "3.1.0",
+			"info": map[string]interface{}{
+				"title":   "Brigade API",
+				"version": version.Version(),
+			},
+			"paths": paths,
+			"components": map[string]interface{}{
+				"schemas": schemas,
+				"securitySchemes": map[string]interface{}{
+					"bearerAuth": map[string]interface{}{
+						"type":   "http",
+						"scheme": "bearer",
+					},
+				},
+			},
+		},
+		"",
+		"  ",
+	)
+	return docBytes, errors.Wrap(err, "error marshaling OpenAPI document")
+}
+
+// openAPIOperation returns an OpenAPI representation of the provided
+// Operation. If the Operation references a request body schema, that schema is
+// loaded and added to the provided map of component schemas.
+func openAPIOperation(
+	op Operation,
+	schemasPath string,
+	schemas map[string]interface{},
+) (map[string]interface{}, error) {
+	operation := map[string]interface{}{
+		"summary": op.Summary,
+	}
+
+	parameters := []map[string]interface{}{}
+	for _, match := range pathParamRegex.FindAllStringSubmatch(op.Path, -1) {
+		parameters = append(
+			parameters,
+			map[string]interface{}{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			},
+		)
+	}
+	for _, param := range op.QueryParams {
+		parameters = append(
+			parameters,
+			map[string]interface{}{
+				"name":        param.Name,
+				"in":          "query",
+				"description": param.Description,
+				"schema":      map[string]interface{}{"type": "string"},
+			},
+		)
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if op.RequestSchema != "" {
+		schemaName, err := loadSchema(op.RequestSchema, schemasPath, schemas)
+		if err != nil {
+			return nil, err
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": fmt.Sprintf("#/components/schemas/%s", schemaName),
+					},
+				},
+			},
+		}
+	}
+
+	successCode := op.SuccessCode
+	if successCode == 0 {
+		successCode = http.StatusOK
+	}
+	successContentType := op.SuccessContentType
+	if successContentType == "" {
+		successContentType = "application/json"
+	}
+	operation["responses"] = map[string]interface{}{
+		strconv.Itoa(successCode): map[string]interface{}{
+			"description": http.StatusText(successCode),
+			"content": map[string]interface{}{
+				successContentType: map[string]interface{}{},
+			},
+		},
+	}
+
+	if op.Unauthenticated {
+		operation["security"] = []interface{}{}
+	} else {
+		operation["security"] = []map[string][]string{
+			{"bearerAuth": {}},
+		}
+	}
+
+	return operation, nil
+}
+
+// loadSchema loads the JSON schema in the specified file, within the specified
+// schemas directory, and adds it to the provided map of component schemas
+// under a name derived from the file name, e.g. project-role-assignment.json
+// becomes ProjectRoleAssignment. References to the schema's own definitions
+// are rewritten to remain valid once the schema is embedded in the OpenAPI
+// document. The schema's name is returned.
+func loadSchema(
+	filename string,
+	schemasPath string,
+	schemas map[string]interface{},
+) (string, error) {
+	nameParts := strings.Split(strings.TrimSuffix(filename, ".json"), "-")
+	for i, part := range nameParts {
+		nameParts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	name := strings.Join(nameParts, "")
+	if _, ok := schemas[name]; ok {
+		return name, nil
+	}
+	schemaBytes, err := ioutil.ReadFile(filepath.Join(schemasPath, filename))
+	if err != nil {
+		return "", errors.Wrapf(err, "error reading schema %s", filename)
+	}
+	schema := map[string]interface{}{}
+	if err = json.Unmarshal(schemaBytes, &schema); err != nil {
+		return "", errors.Wrapf(err, "error unmarshaling schema %s", filename)
+	}
+	delete(schema, "$schema")
+	delete(schema, "$id")
+	schemas[name] = rewriteSchemaRefs(
+		schema,
+		fmt.Sprintf("#/components/schemas/%s/", name),
+	)
+	return name, nil
+}
+
+// rewriteSchemaRefs recursively rewrites local references (i.e. those
+// beginning with "#/") within the provided schema so that they are relative to
+// the specified prefix instead of to the document root.
+func rewriteSchemaRefs(value interface{}, prefix string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if ref, ok := val.(string); ok && key == "$ref" &&
+				strings.HasPrefix(ref, "#/") {
+				v[key] = prefix + strings.TrimPrefix(ref, "#/")
+			} else {
+				v[key] = rewriteSchemaRefs(val, prefix)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = rewriteSchemaRefs(val, prefix)
+		}
+	}
+	return value
+}
+
+func operationKey(method, path string) string {
+	return fmt.Sprintf("%s %s", method, path)
+}
+
+// ListQueryParams returns QueryParams describing the pagination options
+// supported by all list operations, followed by any additional QueryParams
+// provided.
+func ListQueryParams(additional ...QueryParam) []QueryParam {
+	return append(
+		[]QueryParam{
+			{
+				Name: "continue",
+				Description: "An opaque token, obtained from a previous page of " +
+					"results, that requests the next page",
+			},
+			{
+				Name:        "limit",
+				Description: "The maximum number of items to return",
+			},
+		},
+		additional...,
+	)
+}
+
+// LabelsQueryParam describes a query parameter for selecting resources by
+// label.
+var LabelsQueryParam = QueryParam{
+	Name: "labels",
+	Description: "A comma-delimited label selector, e.g. " +
+		"env=prod,tier in (frontend,backend),!deprecated",
+}
@@ -0,0 +1,143 @@
+package restmachinery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type testEndpoints struct {
+	routes     []Operation
+	operations []Operation
+}
+
+func (t *testEndpoints) Register(router *mux.Router) {
+	for _, route := range t.routes {
+		router.HandleFunc(
+			route.Path,
+			func(http.ResponseWriter, *http.Request) {},
+		).Methods(route.Method)
+	}
+}
+
+func (t *testEndpoints) Operations() []Operation {
+	return t.operations
+}
+
+func TestNewOpenAPIDocument(t *testing.T) {
+	getWidget := Operation{
+		Method:  http.MethodGet,
+		Path:    "/v2/widgets/{id}",
+		Summary: "Get a widget",
+	}
+	createWidget := Operation{
+		Method:        http.MethodPost,
+		Path:          "/v2/widgets",
+		Summary:       "Create a widget",
+		RequestSchema: "widget.json",
+		SuccessCode:   http.StatusCreated,
+	}
+
+	schemasPath, err := ioutil.TempDir("", "schemas")
+	require.NoError(t, err)
+	defer os.RemoveAll(schemasPath)
+	require.NoError(
+		t,
+		ioutil.WriteFile(
+			filepath.Join(schemasPath, "widget.json"),
+			[]byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema#",
+				"definitions": {"color": {"type": "string"}},
+				"type": "object",
+				"properties": {"color": {"$ref": "#/definitions/color"}}
+			}`),
+			0600,
+		),
+	)
+
+	testCases := []struct {
+		name       string
+		endpoints  *testEndpoints
+		assertions func([]byte, error)
+	}{
+		{
+			name: "route is undocumented",
+			endpoints: &testEndpoints{
+				routes:     []Operation{getWidget, createWidget},
+				operations: []Operation{getWidget},
+			},
+			assertions: func(_ []byte, err error) {
+				require.Error(t, err)
+				require.Contains(
+					t,
+					err.Error(),
+					"route POST /v2/widgets is undocumented",
+				)
+			},
+		},
+		{
+			name: "documented operation is not a registered route",
+			endpoints: &testEndpoints{
+				routes:     []Operation{getWidget},
+				operations: []Operation{getWidget, createWidget},
+			},
+			assertions: func(_ []byte, err error) {
+				require.Error(t, err)
+				require.Contains(
+					t,
+					err.Error(),
+					"documented operation POST /v2/widgets is not a registered route",
+				)
+			},
+		},
+		{
+			name: "success",
+			endpoints: &testEndpoints{
+				routes:     []Operation{getWidget, createWidget},
+				operations: []Operation{getWidget, createWidget},
+			},
+			assertions: func(docBytes []byte, err error) {
+				require.NoError(t, err)
+				doc := map[string]interface{}{}
+				require.NoError(t, json.Unmarshal(docBytes, &doc))
+				paths := doc["paths"].(map[string]interface{})
+				require.Contains(t, paths, "/healthz")
+				require.Contains(t, paths, "/v2/openapi.json")
+				getOp := paths["/v2/widgets/{id}"].(map[string]interface{})["get"]
+				params := getOp.(map[string]interface{})["parameters"].([]interface{})
+				require.Len(t, params, 1)
+				require.Equal(t, "id", params[0].(map[string]interface{})["name"])
+				createOp := paths["/v2/widgets"].(map[string]interface{})["post"]
+				require.Contains(
+					t,
+					createOp.(map[string]interface{})["responses"],
+					"201",
+				)
+				components := doc["components"].(map[string]interface{})
+				schemas := components["schemas"].(map[string]interface{})
+				schema := schemas["Widget"].(map[string]interface{})
+				require.NotContains(t, schema, "$schema")
+				colorProp :=
+					schema["properties"].(map[string]interface{})["color"]
+				require.Equal(
+					t,
+					"#/components/schemas/Widget/definitions/color",
+					colorProp.(map[string]interface{})["$ref"],
+				)
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.assertions(
+				NewOpenAPIDocument([]Endpoints{testCase.endpoints}, schemasPath),
+			)
+		})
+	}
+}
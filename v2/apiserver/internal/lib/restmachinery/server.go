@@ -20,17 +20,20 @@ type Server interface {
 }
 
 type server struct {
-	*BaseEndpoints // The server itself exposes health check endpoints
-	config         Config
-	endpoints      []Endpoints
-	handler        http.Handler
+	*BaseEndpoints  // The server itself exposes health check endpoints
+	config          Config
+	endpoints       []Endpoints
+	openAPIDocument []byte
+	handler         http.Handler
 }
 
-// NewServer returns a REST API server
+// NewServer returns a REST API server. The provided OpenAPI document, which
+// can be obtained from NewOpenAPIDocument, is served at /v2/openapi.json.
 func NewServer(
 	config Config,
 	baseEndpoints *BaseEndpoints,
 	endpoints []Endpoints,
+	openAPIDocument []byte,
 ) Server {
 	router := mux.NewRouter()
 	router.StrictSlash(true)
@@ -40,12 +43,13 @@ func NewServer(
 	}
 
 	s := &server{
-		BaseEndpoints: baseEndpoints,
-		config:        config,
-		endpoints:     endpoints,
+		BaseEndpoints:   baseEndpoints,
+		config:          config,
+		endpoints:       endpoints,
+		openAPIDocument: openAPIDocument,
 		handler: cors.New(
 			cors.Options{
-				AllowedMethods: []string{"DELETE", "GET", "POST", "PUT"},
+				AllowedMethods: []string{"DELETE", "GET", "PATCH", "POST", "PUT"},
 			},
 		).Handler(router),
 	}
@@ -56,6 +60,12 @@ func NewServer(
 		s.checkHealth, // No filters applied to this request
 	).Methods(http.MethodGet)
 
+	// OpenAPI document
+	router.HandleFunc(
+		"/v2/openapi.json",
+		s.getOpenAPIDocument, // No filters applied to this request
+	).Methods(http.MethodGet)
+
 	return s
 }
 
@@ -102,3 +112,19 @@ func (s *server) checkHealth(
 		},
 	)
 }
+
+func (s *server) getOpenAPIDocument(
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	s.ServeRequest(
+		InboundRequest{
+			W: w,
+			R: r,
+			EndpointLogic: func() (interface{}, error) {
+				return s.openAPIDocument, nil
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
@@ -0,0 +1,198 @@
+package meta
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LabelOperator represents a relationship between a label and a set of values
+// that a LabelRequirement demands.
+type LabelOperator string
+
+const (
+	// LabelOperatorEquals requires a label to exist and have the specified
+	// value.
+	LabelOperatorEquals LabelOperator = "="
+	// LabelOperatorNotEquals requires a label to either not exist or have a value
+	// other than the one specified.
+	LabelOperatorNotEquals LabelOperator = "!="
+	// LabelOperatorIn requires a label to exist and have one of the specified
+	// values.
+	LabelOperatorIn LabelOperator = "in"
+	// LabelOperatorNotIn requires a label to either not exist or have a value
+	// other than any of those specified.
+	LabelOperatorNotIn LabelOperator = "notin"
+	// LabelOperatorExists requires a label to exist, regardless of its value.
+	LabelOperatorExists LabelOperator = "exists"
+	// LabelOperatorDoesNotExist requires a label to not exist.
+	LabelOperatorDoesNotExist LabelOperator = "!"
+)
+
+// LabelRequirement represents a single condition that a resource's labels must
+// satisfy in order for the resource to be selected.
+type LabelRequirement struct {
+	// Key is the label's key.
+	Key string
+	// Operator is the relationship between the label and Values.
+	Operator LabelOperator
+	// Values is the set of values the Operator is applied to. For the
+	// LabelOperatorEquals and LabelOperatorNotEquals operators, it contains
+	// exactly one value. For the LabelOperatorExists and
+	// LabelOperatorDoesNotExist operators, it is empty.
+	Values []string
+}
+
+// String returns the LabelRequirement's representation in the syntax
+// understood by ParseLabelSelector.
+func (l LabelRequirement) String() string {
+	switch l.Operator {
+	case LabelOperatorEquals, LabelOperatorNotEquals:
+		return fmt.Sprintf("%s%s%s", l.Key, l.Operator, strings.Join(l.Values, ""))
+	case LabelOperatorIn, LabelOperatorNotIn:
+		return fmt.Sprintf(
+			"%s %s (%s)",
+			l.Key,
+			l.Operator,
+			strings.Join(l.Values, ","),
+		)
+	case LabelOperatorDoesNotExist:
+		return fmt.Sprintf("!%s", l.Key)
+	default:
+		return l.Key
+	}
+}
+
+// LabelSelector is a set of LabelRequirements, ALL of which a resource's labels
+// must satisfy in order for the resource to be selected.
+type LabelSelector []LabelRequirement
+
+// String returns the LabelSelector's representation in the syntax understood
+// by ParseLabelSelector.
+func (l LabelSelector) String() string {
+	reqStrs := make([]string, len(l))
+	for i, req := range l {
+		reqStrs[i] = req.String()
+	}
+	return strings.Join(reqStrs, ",")
+}
+
+// ParseLabelSelector parses a comma-delimited list of label requirements into
+// a LabelSelector. Each requirement takes one of the following forms:
+//
+//	key=value          the label exists and has the specified value
+//	key==value         same as above
+//	key!=value         the label does not exist or has a different value
+//	key in (v1,v2)     the label exists and has one of the specified values
+//	key notin (v1,v2)  the label does not exist or has none of the values
+//	key                the label exists
+//	!key               the label does not exist
+//
+// An empty string yields an empty LabelSelector. If the string cannot be
+// parsed, a *ErrBadRequest error is returned.
+func ParseLabelSelector(selectorStr string) (LabelSelector, error) {
+	selector := LabelSelector{}
+	for _, reqStr := range splitLabelRequirements(selectorStr) {
+		reqStr = strings.TrimSpace(reqStr)
+		if reqStr == "" {
+			continue
+		}
+		req, err := parseLabelRequirement(reqStr)
+		if err != nil {
+			return nil, &ErrBadRequest{
+				Reason:  fmt.Sprintf("Invalid label selector %q", selectorStr),
+				Details: []string{err.Error()},
+			}
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// splitLabelRequirements splits a label selector string on commas that are
+// not enclosed in parentheses.
+func splitLabelRequirements(selectorStr string) []string {
+	reqStrs := []string{}
+	var depth int
+	var start int
+	for i, r := range selectorStr {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				reqStrs = append(reqStrs, selectorStr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(reqStrs, selectorStr[start:])
+}
+
+func parseLabelRequirement(reqStr string) (LabelRequirement, error) {
+	req := LabelRequirement{}
+	switch {
+	case strings.HasPrefix(reqStr, "!") && !strings.Contains(reqStr, "="):
+		req.Key = strings.TrimSpace(reqStr[1:])
+		req.Operator = LabelOperatorDoesNotExist
+	case strings.Contains(reqStr, " notin "):
+		parts := strings.SplitN(reqStr, " notin ", 2)
+		req.Key = strings.TrimSpace(parts[0])
+		req.Operator = LabelOperatorNotIn
+		var err error
+		if req.Values, err = parseLabelValueSet(parts[1]); err != nil {
+			return req, err
+		}
+	case strings.Contains(reqStr, " in "):
+		parts := strings.SplitN(reqStr, " in ", 2)
+		req.Key = strings.TrimSpace(parts[0])
+		req.Operator = LabelOperatorIn
+		var err error
+		if req.Values, err = parseLabelValueSet(parts[1]); err != nil {
+			return req, err
+		}
+	case strings.Contains(reqStr, "!="):
+		parts := strings.SplitN(reqStr, "!=", 2)
+		req.Key = strings.TrimSpace(parts[0])
+		req.Operator = LabelOperatorNotEquals
+		req.Values = []string{strings.TrimSpace(parts[1])}
+	case strings.Contains(reqStr, "=="):
+		parts := strings.SplitN(reqStr, "==", 2)
+		req.Key = strings.TrimSpace(parts[0])
+		req.Operator = LabelOperatorEquals
+		req.Values = []string{strings.TrimSpace(parts[1])}
+	case strings.Contains(reqStr, "="):
+		parts := strings.SplitN(reqStr, "=", 2)
+		req.Key = strings.TrimSpace(parts[0])
+		req.Operator = LabelOperatorEquals
+		req.Values = []string{strings.TrimSpace(parts[1])}
+	default:
+		req.Key = reqStr
+		req.Operator = LabelOperatorExists
+	}
+	if req.Key == "" || strings.ContainsAny(req.Key, " (),!=") {
+		return req, errors.Errorf("invalid label key in requirement %q", reqStr)
+	}
+	return req, nil
+}
+
+// parseLabelValueSet parses a parenthesized, comma-delimited set of values.
+func parseLabelValueSet(setStr string) ([]string, error) {
+	setStr = strings.TrimSpace(setStr)
+	if !strings.HasPrefix(setStr, "(") || !strings.HasSuffix(setStr, ")") {
+		return nil, errors.Errorf("invalid set of values %q", setStr)
+	}
+	values := []string{}
+	for _, value := range strings.Split(setStr[1:len(setStr)-1], ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil, errors.Errorf("empty set of values %q", setStr)
+	}
+	return values, nil
+}
@@ -0,0 +1,79 @@
+package meta
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Labels is a map of key/value pairs that can be used to organize resources
+// and to select subsets of them.
+type Labels map[string]string
+
+// MarshalBSONValue implements custom BSON marshaling for the Labels type.
+// Labels is, essentially, a map[string]string, but when marshaled to BSON,
+// it must be represented as follows because Mongo can index this more easily,
+// making for faster queries:
+//
+//	[
+//	  { "key": "key0", "value": "value0" },
+//	  { "key": "key1", "value": "value1" },
+//	  ...
+//	  { "key": "keyN", "value": "valueN" }
+//	]
+func (l Labels) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalKeyValuePairs(l)
+}
+
+// UnmarshalBSONValue implements custom BSON unmarshaling for the Labels type.
+// See MarshalBSONValue for details.
+func (l *Labels) UnmarshalBSONValue(_ bsontype.Type, bytes []byte) error {
+	m, err := unmarshalKeyValuePairs(bytes)
+	*l = m
+	return err
+}
+
+// Annotations is a map of key/value pairs that can be used to attach
+// arbitrary, non-identifying metadata to resources.
+type Annotations map[string]string
+
+// MarshalBSONValue implements custom BSON marshaling for the Annotations type.
+// Annotations are represented in BSON in the same manner as Labels. This
+// permits keys that would otherwise be illegal as BSON field names.
+func (a Annotations) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalKeyValuePairs(a)
+}
+
+// UnmarshalBSONValue implements custom BSON unmarshaling for the Annotations
+// type. See MarshalBSONValue for details.
+func (a *Annotations) UnmarshalBSONValue(_ bsontype.Type, bytes []byte) error {
+	m, err := unmarshalKeyValuePairs(bytes)
+	*a = m
+	return err
+}
+
+func marshalKeyValuePairs(m map[string]string) (bsontype.Type, []byte, error) {
+	ms := make([]bson.M, 0, len(m))
+	for k, v := range m {
+		ms = append(
+			ms,
+			bson.M{
+				"key":   k,
+				"value": v,
+			},
+		)
+	}
+	return bson.MarshalValue(ms)
+}
+
+func unmarshalKeyValuePairs(bytes []byte) (map[string]string, error) {
+	pairs := bson.M{}
+	if err := bson.Unmarshal(bytes, &pairs); err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		p := pair.(bson.M)
+		m[p["key"].(string)] = p["value"].(string)
+	}
+	return m, nil
+}
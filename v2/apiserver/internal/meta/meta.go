@@ -24,6 +24,31 @@ type ObjectMeta struct {
 	ID string `json:"id,omitempty" bson:"id,omitempty"`
 	// Created indicates the time at which a resource was created.
 	Created *time.Time `json:"created,omitempty" bson:"created,omitempty"`
+	// ResourceVersion is a monotonically increasing number that is incremented
+	// each time a resource is updated. When included in an update, it specifies
+	// that the update should only succeed if the resource has not been modified
+	// since that version was retrieved.
+	ResourceVersion int64 `json:"resourceVersion,omitempty" bson:"resourceVersion,omitempty"` // nolint: lll
+	// Labels are key/value pairs that can be used to organize resources and to
+	// select subsets of them.
+	Labels Labels `json:"labels,omitempty" bson:"metadataLabels,omitempty"`
+	// Annotations are key/value pairs that can be used to attach arbitrary,
+	// non-identifying metadata to a resource.
+	Annotations Annotations `json:"annotations,omitempty" bson:"metadataAnnotations,omitempty"` // nolint: lll
+	// LastUpdated indicates the time at which a resource was last updated.
+	LastUpdated *time.Time `json:"lastUpdated,omitempty" bson:"lastUpdated,omitempty"` // nolint: lll
+	// CreatedBy references the principal that created a resource.
+	CreatedBy *PrincipalReference `json:"createdBy,omitempty" bson:"createdBy,omitempty"` // nolint: lll
+}
+
+// PrincipalReference is a reference to a principal, such as a User or a
+// ServiceAccount.
+type PrincipalReference struct {
+	// Type qualifies what kind of principal is referenced by the ID field.
+	Type string `json:"type" bson:"type"`
+	// ID references a principal. It is empty for principals that are unique
+	// within their type, such as the root user.
+	ID string `json:"id,omitempty" bson:"id,omitempty"`
 }
 
 // ListMeta is metadata for ordered collections of resources.
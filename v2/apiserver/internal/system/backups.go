@@ -0,0 +1,741 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/core"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/crypto"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
+	"github.com/pkg/errors"
+)
+
+// BackupFormatVersion is the version of the Backup format produced by this
+// version of Brigade. Restore operations reject Backups of any other version.
+const BackupFormatVersion = 1
+
+// backupPageSize is the number of items retrieved per page when enumerating
+// the contents of the system for inclusion in a Backup.
+const backupPageSize = 100
+
+// Backup is a versioned archive of a Brigade system's Projects, Users, Users'
+// PersonalAccessTokens, UserInvitations, ServiceAccounts, Teams, custom
+// project-level RoleDefinitions, and system-level and project-level role
+// assignments and, optionally, Project Secrets. Events are transient and are
+// not included.
+//
+// Hashed tokens are included as-is. Because they are keyed using the API
+// server's token hash key, which is deliberately NOT included, they remain
+// usable only when a Backup is restored to a system configured with the same
+// key. A fingerprint of that key is recorded so that restoring elsewhere can
+// be refused rather than silently invalidating every restored token.
+type Backup struct {
+	// FormatVersion indicates the version of the format of the Backup.
+	FormatVersion int `json:"formatVersion"`
+	// Created indicates the time at which the Backup was created.
+	Created *time.Time `json:"created,omitempty"`
+	// TokenHashKeyFingerprint identifies, without revealing, the key with which
+	// the Backup's hashed tokens were computed.
+	TokenHashKeyFingerprint string `json:"tokenHashKeyFingerprint,omitempty"`
+	// Projects is a slice of all Projects.
+	Projects []core.Project `json:"projects,omitempty"`
+	// Users is a slice of all Users. Role assignments are omitted from each User
+	// and are, instead, captured by the RoleAssignments field.
+	Users []authx.User `json:"users,omitempty"`
+	// PersonalAccessTokens is a slice of all Users' PersonalAccessTokens along
+	// with their hashed tokens.
+	PersonalAccessTokens []BackupPersonalAccessToken `json:"personalAccessTokens,omitempty"` // nolint: lll
+	// UserInvitations is a slice of all outstanding UserInvitations.
+	UserInvitations []authx.UserInvitation `json:"userInvitations,omitempty"`
+	// ServiceAccounts is a slice of all ServiceAccounts along with their hashed
+	// tokens. Role assignments are omitted from each ServiceAccount and are,
+	// instead, captured by the RoleAssignments field.
+	ServiceAccounts []BackupServiceAccount `json:"serviceAccounts,omitempty"`
+	// Teams is a slice of all Teams, including their members. Role assignments
+	// are omitted from each Team and are, instead, captured by the
+	// RoleAssignments field.
+	Teams []authx.Team `json:"teams,omitempty"`
+	// RoleDefinitions is a slice of all custom project-level RoleDefinitions.
+	// Built-in Roles are defined by Brigade itself and are not included.
+	RoleDefinitions []authx.RoleDefinition `json:"roleDefinitions,omitempty"`
+	// RoleAssignments is a slice of all system-level and project-level Roles
+	// assigned to Users, ServiceAccounts, Teams, and groups.
+	RoleAssignments []BackupRoleAssignment `json:"roleAssignments,omitempty"`
+	// Secrets, if present, is an encrypted representation of all Project
+	// Secrets. It can only be decrypted using the passphrase that was specified
+	// when the Backup was created.
+	Secrets []byte `json:"secrets,omitempty"`
+}
+
+// MarshalJSON amends Backup instances with type metadata.
+func (b Backup) MarshalJSON() ([]byte, error) {
+	type Alias Backup
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "Backup",
+			},
+			Alias: (Alias)(b),
+		},
+	)
+}
+
+// BackupServiceAccount pairs a ServiceAccount with its hashed tokens, which
+// are otherwise never included in a ServiceAccount's JSON representation.
+type BackupServiceAccount struct {
+	// ServiceAccount is the ServiceAccount.
+	ServiceAccount authx.ServiceAccount `json:"serviceAccount"`
+	// HashedToken is a secure, one-way hash of the ServiceAccount's token.
+	HashedToken string `json:"hashedToken"`
+	// PreviousHashedToken is a secure, one-way hash of the ServiceAccount's
+	// previous token, which may still be within its grace period.
+	PreviousHashedToken string `json:"previousHashedToken,omitempty"`
+}
+
+// BackupPersonalAccessToken pairs a PersonalAccessToken with its hashed token,
+// which is otherwise never included in a PersonalAccessToken's JSON
+// representation.
+type BackupPersonalAccessToken struct {
+	// PersonalAccessToken is the PersonalAccessToken.
+	PersonalAccessToken authx.PersonalAccessToken `json:"personalAccessToken"`
+	// HashedToken is a secure, one-way hash of the PersonalAccessToken's token.
+	HashedToken string `json:"hashedToken"`
+}
+
+// BackupRoleAssignment represents the assignment of a Role to a principal.
+// Unlike a RoleAssignment, it specifies the Role's type, which permits it to
+// capture both system-level and project-level role assignments.
+type BackupRoleAssignment struct {
+	// Role specifies a Role.
+	Role authx.Role `json:"role"`
+	// PrincipalType qualifies what kind of principal is referenced by the
+	// PrincipalID field.
+	PrincipalType authx.PrincipalType `json:"principalType"`
+	// PrincipalID references a principal.
+	PrincipalID string `json:"principalID"`
+}
+
+// BackupOptions represents useful, optional criteria for the creation of a
+// Backup.
+type BackupOptions struct {
+	// SecretsPassphrase, if specified, causes Project Secrets to be included in
+	// the Backup, encrypted using a key derived from the passphrase. If not
+	// specified, Project Secrets are omitted from the Backup.
+	SecretsPassphrase string `json:"secretsPassphrase,omitempty"`
+}
+
+// RestoreRequest represents a request to restore the system from a Backup.
+type RestoreRequest struct {
+	// Backup is the Backup to restore from.
+	Backup Backup `json:"backup"`
+	// SecretsPassphrase is the passphrase that was used to encrypt Project
+	// Secrets when the Backup was created. It is required if, and only if, the
+	// Backup contains Secrets.
+	SecretsPassphrase string `json:"secretsPassphrase,omitempty"`
+}
+
+// RestoreResult summarizes the outcome of restoring the system from a Backup.
+type RestoreResult struct {
+	// Projects summarizes the restoration of Projects.
+	Projects RestoreCounts `json:"projects"`
+	// Users summarizes the restoration of Users.
+	Users RestoreCounts `json:"users"`
+	// PersonalAccessTokens summarizes the restoration of PersonalAccessTokens.
+	PersonalAccessTokens RestoreCounts `json:"personalAccessTokens"`
+	// UserInvitations summarizes the restoration of UserInvitations.
+	UserInvitations RestoreCounts `json:"userInvitations"`
+	// ServiceAccounts summarizes the restoration of ServiceAccounts.
+	ServiceAccounts RestoreCounts `json:"serviceAccounts"`
+	// Teams summarizes the restoration of Teams.
+	Teams RestoreCounts `json:"teams"`
+	// RoleDefinitions summarizes the restoration of custom RoleDefinitions.
+	RoleDefinitions RestoreCounts `json:"roleDefinitions"`
+	// RoleAssignments is the number of role assignments that were applied.
+	RoleAssignments int `json:"roleAssignments"`
+	// Secrets is the number of Project Secrets that were set.
+	Secrets int `json:"secrets"`
+}
+
+// MarshalJSON amends RestoreResult instances with type metadata.
+func (r RestoreResult) MarshalJSON() ([]byte, error) {
+	type Alias RestoreResult
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "RestoreResult",
+			},
+			Alias: (Alias)(r),
+		},
+	)
+}
+
+// RestoreCounts summarizes the restoration of one kind of resource.
+type RestoreCounts struct {
+	// Created is the number of resources that were created.
+	Created int `json:"created"`
+	// Existing is the number of resources that already existed and were left
+	// untouched.
+	Existing int `json:"existing"`
+}
+
+// BackupsService is the specialized interface for backing up and restoring the
+// system. It's decoupled from underlying technology choices (e.g. data store,
+// message bus, etc.) to keep business logic reusable and consistent while the
+// underlying tech stack remains free to change.
+type BackupsService interface {
+	// Backup returns a Backup of the entire system. Project Secrets are included
+	// only if a passphrase with which to encrypt them is specified.
+	Backup(context.Context, BackupOptions) (Backup, error)
+	// Restore restores the system from the provided Backup. Restoration is
+	// idempotent. Projects, Users, PersonalAccessTokens, UserInvitations,
+	// ServiceAccounts, Teams, and RoleDefinitions that already exist are left
+	// untouched (although
+	// substrate resources for every Project in the Backup are recreated if
+	// missing), role assignments are granted if not already held, and Project
+	// Secrets are (re)set. If the Backup's format version is not supported, if
+	// the Backup's hashed tokens were computed using a different token hash key
+	// than the system's own, if the Backup contains Secrets and no passphrase is
+	// specified, or if the passphrase is incorrect, implementations MUST return
+	// a *meta.ErrBadRequest error.
+	Restore(context.Context, RestoreRequest) (RestoreResult, error)
+}
+
+type backupsService struct {
+	authorize                 authx.AuthorizeFn
+	projectsStore             core.ProjectsStore
+	secretsStore              core.SecretsStore
+	usersStore                authx.UsersStore
+	serviceAccountsStore      authx.ServiceAccountsStore
+	rolesStore                authx.RolesStore
+	teamsStore                authx.TeamsStore
+	personalAccessTokensStore authx.PersonalAccessTokensStore
+	userInvitationsStore      authx.UserInvitationsStore
+	roleDefinitionsStore      authx.RoleDefinitionsStore
+	substrate                 core.Substrate
+	tokenHasher               crypto.TokenHasher
+}
+
+// NewBackupsService returns a specialized interface for backing up and
+// restoring the system.
+func NewBackupsService(
+	projectsStore core.ProjectsStore,
+	secretsStore core.SecretsStore,
+	usersStore authx.UsersStore,
+	serviceAccountsStore authx.ServiceAccountsStore,
+	rolesStore authx.RolesStore,
+	teamsStore authx.TeamsStore,
+	personalAccessTokensStore authx.PersonalAccessTokensStore,
+	userInvitationsStore authx.UserInvitationsStore,
+	roleDefinitionsStore authx.RoleDefinitionsStore,
+	substrate core.Substrate,
+	tokenHasher crypto.TokenHasher,
+) BackupsService {
+	return &backupsService{
+		authorize:                 authx.Authorize,
+		projectsStore:             projectsStore,
+		secretsStore:              secretsStore,
+		usersStore:                usersStore,
+		serviceAccountsStore:      serviceAccountsStore,
+		rolesStore:                rolesStore,
+		teamsStore:                teamsStore,
+		personalAccessTokensStore: personalAccessTokensStore,
+		userInvitationsStore:      userInvitationsStore,
+		roleDefinitionsStore:      roleDefinitionsStore,
+		substrate:                 substrate,
+		tokenHasher:               tokenHasher,
+	}
+}
+
+func (b *backupsService) Backup(
+	ctx context.Context,
+	opts BackupOptions,
+) (Backup, error) {
+	now := time.Now()
+	backup := Backup{
+		FormatVersion:           BackupFormatVersion,
+		Created:                 &now,
+		TokenHashKeyFingerprint: b.tokenHasher.KeyFingerprint(),
+	}
+
+	if err := b.authorize(ctx, authx.RoleAdmin()); err != nil {
+		return backup, err
+	}
+
+	listOpts := meta.ListOptions{Limit: backupPageSize}
+	for {
+		projects, err :=
+			b.projectsStore.List(ctx, core.ProjectsSelector{}, listOpts)
+		if err != nil {
+			return backup, errors.Wrap(err, "error retrieving projects from store")
+		}
+		backup.Projects = append(backup.Projects, projects.Items...)
+		if projects.Continue == "" {
+			break
+		}
+		listOpts.Continue = projects.Continue
+	}
+
+	listOpts = meta.ListOptions{Limit: backupPageSize}
+	for {
+		users, err := b.usersStore.List(ctx, authx.UsersSelector{}, listOpts)
+		if err != nil {
+			return backup, errors.Wrap(err, "error retrieving users from store")
+		}
+		for _, user := range users.Items {
+			for _, role := range user.UserRoles {
+				backup.RoleAssignments = append(
+					backup.RoleAssignments,
+					BackupRoleAssignment{
+						Role:          role,
+						PrincipalType: authx.PrincipalTypeUser,
+						PrincipalID:   user.ID,
+					},
+				)
+			}
+			user.UserRoles = nil
+			backup.Users = append(backup.Users, user)
+			if err = b.backupPersonalAccessTokens(ctx, &backup, user.ID); err != nil {
+				return backup, err
+			}
+		}
+		if users.Continue == "" {
+			break
+		}
+		listOpts.Continue = users.Continue
+	}
+
+	listOpts = meta.ListOptions{Limit: backupPageSize}
+	for {
+		invitations, err := b.userInvitationsStore.List(ctx, listOpts)
+		if err != nil {
+			return backup,
+				errors.Wrap(err, "error retrieving user invitations from store")
+		}
+		backup.UserInvitations =
+			append(backup.UserInvitations, invitations.Items...)
+		if invitations.Continue == "" {
+			break
+		}
+		listOpts.Continue = invitations.Continue
+	}
+
+	listOpts = meta.ListOptions{Limit: backupPageSize}
+	for {
+		serviceAccounts, err := b.serviceAccountsStore.List(
+			ctx,
+			authx.ServiceAccountsSelector{},
+			listOpts,
+		)
+		if err != nil {
+			return backup,
+				errors.Wrap(err, "error retrieving service accounts from store")
+		}
+		for _, serviceAccount := range serviceAccounts.Items {
+			for _, role := range serviceAccount.ServiceAccountRoles {
+				backup.RoleAssignments = append(
+					backup.RoleAssignments,
+					BackupRoleAssignment{
+						Role:          role,
+						PrincipalType: authx.PrincipalTypeServiceAccount,
+						PrincipalID:   serviceAccount.ID,
+					},
+				)
+			}
+			serviceAccount.ServiceAccountRoles = nil
+			backup.ServiceAccounts = append(
+				backup.ServiceAccounts,
+				BackupServiceAccount{
+					ServiceAccount:      serviceAccount,
+					HashedToken:         serviceAccount.HashedToken,
+					PreviousHashedToken: serviceAccount.PreviousHashedToken,
+				},
+			)
+		}
+		if serviceAccounts.Continue == "" {
+			break
+		}
+		listOpts.Continue = serviceAccounts.Continue
+	}
+
+	listOpts = meta.ListOptions{Limit: backupPageSize}
+	for {
+		teams, err := b.teamsStore.List(ctx, listOpts)
+		if err != nil {
+			return backup, errors.Wrap(err, "error retrieving teams from store")
+		}
+		for _, team := range teams.Items {
+			for _, role := range team.TeamRoles {
+				backup.RoleAssignments = append(
+					backup.RoleAssignments,
+					BackupRoleAssignment{
+						Role:          role,
+						PrincipalType: authx.PrincipalTypeTeam,
+						PrincipalID:   team.ID,
+					},
+				)
+			}
+			team.TeamRoles = nil
+			backup.Teams = append(backup.Teams, team)
+		}
+		if teams.Continue == "" {
+			break
+		}
+		listOpts.Continue = teams.Continue
+	}
+
+	roleDefinitions, err := b.roleDefinitionsStore.List(ctx)
+	if err != nil {
+		return backup,
+			errors.Wrap(err, "error retrieving role definitions from store")
+	}
+	backup.RoleDefinitions = roleDefinitions.Items
+
+	// Groups are defined by the identity provider rather than by Brigade, so
+	// only the Roles assigned to them are captured
+	for _, roleType := range []authx.RoleType{
+		authx.RoleTypeSystem,
+		authx.RoleTypeProject,
+	} {
+		roleAssignments, err := b.rolesStore.List(
+			ctx,
+			authx.RoleAssignmentsSelector{
+				PrincipalType: authx.PrincipalTypeGroup,
+				RoleType:      roleType,
+			},
+			meta.ListOptions{}, // No limit; a backup must capture every assignment
+		)
+		if err != nil {
+			return backup, errors.Wrapf(
+				err,
+				"error retrieving %s role assignments for groups from store",
+				roleType,
+			)
+		}
+		for _, roleAssignment := range roleAssignments.Items {
+			backup.RoleAssignments = append(
+				backup.RoleAssignments,
+				BackupRoleAssignment{
+					Role: authx.Role{
+						Type:  roleType,
+						Name:  roleAssignment.Role,
+						Scope: roleAssignment.Scope,
+					},
+					PrincipalType: authx.PrincipalTypeGroup,
+					PrincipalID:   roleAssignment.PrincipalID,
+				},
+			)
+		}
+	}
+
+	if opts.SecretsPassphrase == "" {
+		return backup, nil
+	}
+
+	// Secrets are keyed by Project ID
+	secrets := map[string][]core.Secret{}
+	for _, project := range backup.Projects {
+		projectSecrets, err := b.secretsStore.GetAll(ctx, project)
+		if err != nil {
+			return backup, errors.Wrapf(
+				err,
+				"error retrieving secrets for project %q from store",
+				project.ID,
+			)
+		}
+		if len(projectSecrets) > 0 {
+			secrets[project.ID] = projectSecrets
+		}
+	}
+	secretsBytes, err := json.Marshal(secrets)
+	if err != nil {
+		return backup, errors.Wrap(err, "error marshaling secrets")
+	}
+	if backup.Secrets, err = crypto.EncryptWithPassphrase(
+		opts.SecretsPassphrase,
+		secretsBytes,
+	); err != nil {
+		return backup, errors.Wrap(err, "error encrypting secrets")
+	}
+
+	return backup, nil
+}
+
+// backupPersonalAccessTokens adds all of the specified User's
+// PersonalAccessTokens to the provided Backup.
+func (b *backupsService) backupPersonalAccessTokens(
+	ctx context.Context,
+	backup *Backup,
+	userID string,
+) error {
+	listOpts := meta.ListOptions{Limit: backupPageSize}
+	for {
+		tokens, err :=
+			b.personalAccessTokensStore.List(ctx, userID, listOpts)
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving personal access tokens for user %q from store",
+				userID,
+			)
+		}
+		for _, token := range tokens.Items {
+			backup.PersonalAccessTokens = append(
+				backup.PersonalAccessTokens,
+				BackupPersonalAccessToken{
+					PersonalAccessToken: token,
+					HashedToken:         token.HashedToken,
+				},
+			)
+		}
+		if tokens.Continue == "" {
+			return nil
+		}
+		listOpts.Continue = tokens.Continue
+	}
+}
+
+func (b *backupsService) Restore(
+	ctx context.Context,
+	req RestoreRequest,
+) (RestoreResult, error) {
+	result := RestoreResult{}
+
+	if err := b.authorize(ctx, authx.RoleAdmin()); err != nil {
+		return result, err
+	}
+
+	backup := req.Backup
+	if backup.FormatVersion != BackupFormatVersion {
+		return result, &meta.ErrBadRequest{
+			Reason: fmt.Sprintf(
+				"Backup format version %d is not supported; expected version %d.",
+				backup.FormatVersion,
+				BackupFormatVersion,
+			),
+		}
+	}
+
+	// Restoring hashed tokens that were computed using a different key would
+	// leave every one of them unusable, so refuse before anything is restored
+	if backup.TokenHashKeyFingerprint != b.tokenHasher.KeyFingerprint() {
+		return result, &meta.ErrBadRequest{
+			Reason: "The backup's hashed tokens were computed using a different " +
+				"token hash key than this system's. Configure the API server with " +
+				"the key in use when the backup was created and try again.",
+		}
+	}
+
+	// Decrypt Secrets before restoring anything else so that a missing or
+	// incorrect passphrase doesn't result in a partial restoration.
+	secrets := map[string][]core.Secret{}
+	if len(backup.Secrets) > 0 {
+		if req.SecretsPassphrase == "" {
+			return result, &meta.ErrBadRequest{
+				Reason: "The backup contains encrypted secrets. A passphrase is " +
+					"required to restore them.",
+			}
+		}
+		secretsBytes, err :=
+			crypto.DecryptWithPassphrase(req.SecretsPassphrase, backup.Secrets)
+		if err != nil {
+			return result, &meta.ErrBadRequest{
+				Reason: "The backup's secrets could not be decrypted. The passphrase " +
+					"may be incorrect.",
+			}
+		}
+		if err = json.Unmarshal(secretsBytes, &secrets); err != nil {
+			return result, errors.Wrap(err, "error unmarshaling secrets")
+		}
+	}
+
+	for _, user := range backup.Users {
+		if _, err := b.usersStore.Get(ctx, user.ID); err == nil {
+			result.Users.Existing++
+			continue
+		} else if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+			return result,
+				errors.Wrapf(err, "error retrieving user %q from store", user.ID)
+		}
+		user.UserRoles = nil
+		if err := b.usersStore.Create(ctx, user); err != nil {
+			return result,
+				errors.Wrapf(err, "error storing new user %q", user.ID)
+		}
+		result.Users.Created++
+	}
+
+	// PersonalAccessTokens are restored after Users because they belong to them
+	for _, backupToken := range backup.PersonalAccessTokens {
+		token := backupToken.PersonalAccessToken
+		token.HashedToken = backupToken.HashedToken
+		if err := b.personalAccessTokensStore.Create(ctx, token); err != nil {
+			if _, ok := errors.Cause(err).(*meta.ErrConflict); ok {
+				result.PersonalAccessTokens.Existing++
+				continue
+			}
+			return result, errors.Wrapf(
+				err,
+				"error storing new personal access token %q for user %q",
+				token.Name,
+				token.UserID,
+			)
+		}
+		result.PersonalAccessTokens.Created++
+	}
+
+	for _, invitation := range backup.UserInvitations {
+		if err := b.userInvitationsStore.Create(ctx, invitation); err != nil {
+			if _, ok := errors.Cause(err).(*meta.ErrConflict); ok {
+				result.UserInvitations.Existing++
+				continue
+			}
+			return result, errors.Wrapf(
+				err,
+				"error storing new user invitation %q",
+				invitation.ID,
+			)
+		}
+		result.UserInvitations.Created++
+	}
+
+	for _, backupServiceAccount := range backup.ServiceAccounts {
+		serviceAccount := backupServiceAccount.ServiceAccount
+		if _, err :=
+			b.serviceAccountsStore.Get(ctx, serviceAccount.ID); err == nil {
+			result.ServiceAccounts.Existing++
+			continue
+		} else if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+			return result, errors.Wrapf(
+				err,
+				"error retrieving service account %q from store",
+				serviceAccount.ID,
+			)
+		}
+		serviceAccount.HashedToken = backupServiceAccount.HashedToken
+		serviceAccount.PreviousHashedToken =
+			backupServiceAccount.PreviousHashedToken
+		serviceAccount.ServiceAccountRoles = nil
+		if err := b.serviceAccountsStore.Create(ctx, serviceAccount); err != nil {
+			return result, errors.Wrapf(
+				err,
+				"error storing new service account %q",
+				serviceAccount.ID,
+			)
+		}
+		result.ServiceAccounts.Created++
+	}
+
+	// Teams are restored after Users and ServiceAccounts because they reference
+	// them as members
+	for _, team := range backup.Teams {
+		if _, err := b.teamsStore.Get(ctx, team.ID); err == nil {
+			result.Teams.Existing++
+			continue
+		} else if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+			return result,
+				errors.Wrapf(err, "error retrieving team %q from store", team.ID)
+		}
+		team.TeamRoles = nil
+		if err := b.teamsStore.Create(ctx, team); err != nil {
+			return result,
+				errors.Wrapf(err, "error storing new team %q", team.ID)
+		}
+		result.Teams.Created++
+	}
+
+	for _, project := range backup.Projects {
+		existingProject, err := b.projectsStore.Get(ctx, project.ID)
+		if err == nil {
+			result.Projects.Existing++
+			project = existingProject
+		} else if _, ok := errors.Cause(err).(*meta.ErrNotFound); !ok {
+			return result,
+				errors.Wrapf(err, "error retrieving project %q from store", project.ID)
+		} else {
+			if project.Kubernetes == nil {
+				if project, err =
+					b.substrate.PreCreateProject(ctx, project); err != nil {
+					return result, errors.Wrapf(
+						err,
+						"error pre-creating project %q on the substrate",
+						project.ID,
+					)
+				}
+			}
+			if err = b.projectsStore.Create(ctx, project); err != nil {
+				return result,
+					errors.Wrapf(err, "error storing new project %q", project.ID)
+			}
+			result.Projects.Created++
+		}
+		// This is idempotent and recreates any substrate resources (e.g. the
+		// Project's namespace) that may be missing
+		if err = b.substrate.CreateProject(ctx, project); err != nil {
+			return result, errors.Wrapf(
+				err,
+				"error creating project %q on the substrate",
+				project.ID,
+			)
+		}
+		for _, secret := range secrets[project.ID] {
+			if err = b.secretsStore.Set(ctx, project, secret); err != nil {
+				return result, errors.Wrapf(
+					err,
+					"error setting secret for project %q worker in store",
+					project.ID,
+				)
+			}
+			result.Secrets++
+		}
+	}
+
+	// RoleDefinitions are restored before role assignments because custom Roles
+	// must be defined before they are granted
+	for _, roleDefinition := range backup.RoleDefinitions {
+		if err := b.roleDefinitionsStore.Create(ctx, roleDefinition); err != nil {
+			if _, ok := errors.Cause(err).(*meta.ErrConflict); ok {
+				result.RoleDefinitions.Existing++
+				continue
+			}
+			return result, errors.Wrapf(
+				err,
+				"error storing new role definition %q",
+				roleDefinition.ID,
+			)
+		}
+		result.RoleDefinitions.Created++
+	}
+
+	for _, roleAssignment := range backup.RoleAssignments {
+		if err := b.rolesStore.Grant(
+			ctx,
+			roleAssignment.PrincipalType,
+			roleAssignment.PrincipalID,
+			roleAssignment.Role,
+		); err != nil {
+			return result, errors.Wrapf(
+				err,
+				"error granting %s role %q with scope %q to %s %q in store",
+				roleAssignment.Role.Type,
+				roleAssignment.Role.Name,
+				roleAssignment.Role.Scope,
+				roleAssignment.PrincipalType,
+				roleAssignment.PrincipalID,
+			)
+		}
+		result.RoleAssignments++
+	}
+
+	return result, nil
+}
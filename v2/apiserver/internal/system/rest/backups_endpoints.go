@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/system"
+	"github.com/gorilla/mux"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type BackupsEndpoints struct {
+	*restmachinery.BaseEndpoints
+	BackupOptionsSchemaLoader  gojsonschema.JSONLoader
+	RestoreRequestSchemaLoader gojsonschema.JSONLoader
+	Service                    system.BackupsService
+}
+
+func (b *BackupsEndpoints) Register(router *mux.Router) {
+	// Back up the system
+	router.HandleFunc(
+		"/v2/system/backups",
+		b.TokenAuthFilter.Decorate(b.backup),
+	).Methods(http.MethodPost)
+
+	// Restore the system from a backup
+	router.HandleFunc(
+		"/v2/system/restores",
+		b.TokenAuthFilter.Decorate(b.restore),
+	).Methods(http.MethodPost)
+}
+
+func (b *BackupsEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/system/backups",
+			Summary:       "Back up the system",
+			RequestSchema: "backup-options.json",
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/v2/system/restores",
+			Summary:       "Restore the system from a backup",
+			RequestSchema: "restore-request.json",
+		},
+	}
+}
+
+func (b *BackupsEndpoints) backup(w http.ResponseWriter, r *http.Request) {
+	opts := system.BackupOptions{}
+	b.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: b.BackupOptionsSchemaLoader,
+			ReqBodyObj:          &opts,
+			EndpointLogic: func() (interface{}, error) {
+				return b.Service.Backup(r.Context(), opts)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
+func (b *BackupsEndpoints) restore(w http.ResponseWriter, r *http.Request) {
+	restoreRequest := system.RestoreRequest{}
+	b.ServeRequest(
+		restmachinery.InboundRequest{
+			W:                   w,
+			R:                   r,
+			ReqBodySchemaLoader: b.RestoreRequestSchemaLoader,
+			ReqBodyObj:          &restoreRequest,
+			EndpointLogic: func() (interface{}, error) {
+				return b.Service.Restore(r.Context(), restoreRequest)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
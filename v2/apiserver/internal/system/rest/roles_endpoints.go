@@ -1,10 +1,14 @@
 package rest
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	authxREST "github.com/brigadecore/brigade/v2/apiserver/internal/authx/rest"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/brigadecore/brigade/v2/apiserver/internal/system"
 	"github.com/gorilla/mux"
 	"github.com/xeipuuv/gojsonschema"
@@ -17,6 +21,12 @@ type RolesEndpoints struct {
 }
 
 func (r *RolesEndpoints) Register(router *mux.Router) {
+	// List system Role assignments
+	router.HandleFunc(
+		"/v2/system/role-assignments",
+		r.TokenAuthFilter.Decorate(r.list),
+	).Methods(http.MethodGet)
+
 	// Grant a system Role to a User or ServiceAccount
 	router.HandleFunc(
 		"/v2/system/role-assignments",
@@ -30,6 +40,85 @@ func (r *RolesEndpoints) Register(router *mux.Router) {
 	).Methods(http.MethodDelete)
 }
 
+func (r *RolesEndpoints) Operations() []restmachinery.Operation {
+	return []restmachinery.Operation{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/system/role-assignments",
+			Summary: "List system role assignments",
+			QueryParams: append(
+				restmachinery.ListQueryParams(
+					authxREST.RoleAssignmentsSelectorQueryParams...,
+				),
+				restmachinery.QueryParam{
+					Name:        "scope",
+					Description: "Select only assignments of roles having this scope",
+				},
+			),
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/v2/system/role-assignments",
+			Summary: "Grant a system role to a user, service account, group, " +
+				"or team",
+			RequestSchema: "system-role-assignment.json",
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/v2/system/role-assignments",
+			Summary: "Revoke a system role from a user, service account, group, " +
+				"or team",
+			QueryParams: []restmachinery.QueryParam{
+				{Name: "role", Description: "The role to revoke"},
+				{
+					Name: "principalType",
+					Description: "The type of principal; USER, SERVICE_ACCOUNT, " +
+						"GROUP, or TEAM",
+				},
+				{Name: "principalID", Description: "The ID of the principal"},
+			},
+		},
+	}
+}
+
+func (r *RolesEndpoints) list(
+	w http.ResponseWriter,
+	req *http.Request,
+) {
+	selector := authxREST.RoleAssignmentsSelectorFromQuery(req)
+	selector.Scope = req.URL.Query().Get("scope")
+	opts := meta.ListOptions{
+		Continue: req.URL.Query().Get("continue"),
+	}
+	if limitStr := req.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if opts.Limit, err = strconv.ParseInt(limitStr, 10, 64); err != nil ||
+			opts.Limit < 1 || opts.Limit > 100 {
+			r.WriteAPIResponse(
+				w,
+				http.StatusBadRequest,
+				&meta.ErrBadRequest{
+					Reason: fmt.Sprintf(
+						`Invalid value %q for "limit" query parameter`,
+						limitStr,
+					),
+				},
+			)
+			return
+		}
+	}
+	r.ServeRequest(
+		restmachinery.InboundRequest{
+			W: w,
+			R: req,
+			EndpointLogic: func() (interface{}, error) {
+				return r.Service.List(req.Context(), selector, opts)
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+}
+
 func (r *RolesEndpoints) grant(
 	w http.ResponseWriter,
 	req *http.Request,
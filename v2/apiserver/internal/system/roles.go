@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/brigadecore/brigade/v2/apiserver/internal/authx"
+	"github.com/brigadecore/brigade/v2/apiserver/internal/meta"
 	"github.com/pkg/errors"
 )
 
@@ -15,7 +16,15 @@ type RolesService interface {
 
 	// TODO: This needs a function for listing available system roles
 
-	// TODO: This needs a function for listing system role assignments
+	// List returns a RoleAssignmentList, with its Items (system-level
+	// RoleAssignments) ordered by principal type, principal ID, role, and scope,
+	// satisfying the criteria specified by the RoleAssignmentsSelector. The
+	// selector's RoleType is ignored.
+	List(
+		ctx context.Context,
+		selector authx.RoleAssignmentsSelector,
+		opts meta.ListOptions,
+	) (authx.RoleAssignmentList, error)
 
 	// Grant grants the system-level Role specified by the RoleAssignment to the
 	// principal also specified by the RoleAssignment. If the specified specified
@@ -40,6 +49,7 @@ type rolesService struct {
 	usersStore           authx.UsersStore
 	serviceAccountsStore authx.ServiceAccountsStore
 	rolesStore           authx.RolesStore
+	teamsStore           authx.TeamsStore
 }
 
 // NewRolesService returns a specialized interface for managing system-level
@@ -48,13 +58,39 @@ func NewRolesService(
 	usersStore authx.UsersStore,
 	serviceAccountsStore authx.ServiceAccountsStore,
 	rolesStore authx.RolesStore,
+	teamsStore authx.TeamsStore,
 ) RolesService {
 	return &rolesService{
 		authorize:            authx.Authorize,
 		usersStore:           usersStore,
 		serviceAccountsStore: serviceAccountsStore,
 		rolesStore:           rolesStore,
+		teamsStore:           teamsStore,
+	}
+}
+
+func (s *rolesService) List(
+	ctx context.Context,
+	selector authx.RoleAssignmentsSelector,
+	opts meta.ListOptions,
+) (authx.RoleAssignmentList, error) {
+	if err := s.authorize(ctx, authx.RoleReader()); err != nil {
+		return authx.RoleAssignmentList{}, err
+	}
+
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+
+	selector.RoleType = authx.RoleTypeSystem
+	roleAssignments, err := s.rolesStore.List(ctx, selector, opts)
+	if err != nil {
+		return roleAssignments, errors.Wrap(
+			err,
+			"error retrieving system role assignments from store",
+		)
 	}
+	return roleAssignments, nil
 }
 
 func (s *rolesService) Grant(
@@ -84,7 +120,18 @@ func (s *rolesService) Grant(
 				roleAssignment.PrincipalID,
 			)
 		}
-	} else {
+	} else if roleAssignment.PrincipalType == authx.PrincipalTypeTeam {
+		// Make sure the Team exists
+		if _, err := s.teamsStore.Get(ctx, roleAssignment.PrincipalID); err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving team %q from store",
+				roleAssignment.PrincipalID,
+			)
+		}
+	} else if roleAssignment.PrincipalType != authx.PrincipalTypeGroup {
+		// Groups are managed by the OpenID Connect identity provider, so there's
+		// no way to make sure one exists. Anything else is unsupported.
 		return nil
 	}
 
@@ -139,7 +186,18 @@ func (s *rolesService) Revoke(
 				roleAssignment.PrincipalID,
 			)
 		}
-	} else {
+	} else if roleAssignment.PrincipalType == authx.PrincipalTypeTeam {
+		// Make sure the Team exists
+		if _, err := s.teamsStore.Get(ctx, roleAssignment.PrincipalID); err != nil {
+			return errors.Wrapf(
+				err,
+				"error retrieving team %q from store",
+				roleAssignment.PrincipalID,
+			)
+		}
+	} else if roleAssignment.PrincipalType != authx.PrincipalTypeGroup {
+		// Groups are managed by the OpenID Connect identity provider, so there's
+		// no way to make sure one exists. Anything else is unsupported.
 		return nil
 	}
 
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/brigadecore/brigade/v2/apiserver/internal/lib/restmachinery"
+	"github.com/stretchr/testify/require"
+)
+
+type noopFilter struct{}
+
+func (n *noopFilter) Decorate(handle http.HandlerFunc) http.HandlerFunc {
+	return handle
+}
+
+// TestOpenAPIDocument fails if any route registered by the API server's
+// Endpoints is undocumented, if any documented operation isn't a registered
+// route, or if any referenced request body schema cannot be loaded.
+func TestOpenAPIDocument(t *testing.T) {
+	endpoints := getEndpoints(
+		&restmachinery.BaseEndpoints{
+			TokenAuthFilter: &noopFilter{},
+		},
+		services{},
+	)
+	docBytes, err := restmachinery.NewOpenAPIDocument(endpoints, "schemas")
+	require.NoError(t, err)
+	doc := struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}{}
+	require.NoError(t, json.Unmarshal(docBytes, &doc))
+	require.Equal(t, "3.1.0", doc.OpenAPI)
+	require.Contains(t, doc.Paths, "/v2/openapi.json")
+	require.Contains(t, doc.Paths["/v2/projects/{id}"], "patch")
+}
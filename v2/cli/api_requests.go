@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// apiRequest models an outbound call to an API operation that the SDK does not
+// (yet) expose. It deliberately mirrors the SDK's own (internal)
+// OutboundRequest type so that commands built upon it can migrate to the SDK
+// with minimal effort once the SDK catches up with the API server.
+type apiRequest struct {
+	// APIAddress optionally specifies the address of the API server. If
+	// specified, the CLI's configuration is not consulted and the request is
+	// submitted without a bearer token. This is useful for requests, such as
+	// those that establish a session, that are made before the CLI has been
+	// configured.
+	APIAddress string
+	// Method specifies the HTTP method to be used.
+	Method string
+	// Path specifies a path (relative to the root of the API) to be used.
+	Path string
+	// QueryParams optionally specifies any URL query parameters to be used.
+	QueryParams map[string]string
+	// Headers optionally specifies any miscellaneous HTTP headers to be used.
+	Headers map[string]string
+	// ReqBodyObj optionally provides an object that can be marshaled to create
+	// the body of the HTTP request. If it is a []byte, it is used verbatim.
+	ReqBodyObj interface{}
+	// SuccessCode specifies what HTTP response code should indicate a successful
+	// API call. If unspecified, http.StatusOK is assumed.
+	SuccessCode int
+	// RespObj optionally provides an object into which the HTTP response body can
+	// be unmarshaled.
+	RespObj interface{}
+}
+
+// executeAPIRequest submits the provided apiRequest and, if applicable,
+// decodes the response body into the request's RespObj.
+func executeAPIRequest(c *cli.Context, req apiRequest) error {
+	resp, err := submitAPIRequest(c, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if req.RespObj != nil {
+		respBodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "error reading response body")
+		}
+		if err := json.Unmarshal(respBodyBytes, req.RespObj); err != nil {
+			return errors.Wrap(err, "error unmarshaling response body")
+		}
+	}
+	return nil
+}
+
+// submitAPIRequest submits the provided apiRequest, authenticating with the
+// token from the CLI's configuration (unless the apiRequest specifies its own
+// API address), and returns the HTTP response. Callers
+// are responsible for closing the response body. Unsuccessful responses are
+// translated into the corresponding error types from the SDK's meta package.
+func submitAPIRequest(c *cli.Context, req apiRequest) (*http.Response, error) {
+	apiAddress := req.APIAddress
+	var apiToken string
+	if apiAddress == "" {
+		config, err := getConfig()
+		if err != nil {
+			return nil, errors.Wrap(err, "error retrieving configuration")
+		}
+		apiAddress = config.APIAddress
+		apiToken = config.APIToken
+	}
+
+	var reqBodyReader io.Reader
+	if req.ReqBodyObj != nil {
+		switch rb := req.ReqBodyObj.(type) {
+		case []byte:
+			reqBodyReader = bytes.NewBuffer(rb)
+		default:
+			reqBodyBytes, err := json.Marshal(req.ReqBodyObj)
+			if err != nil {
+				return nil, errors.Wrap(err, "error marshaling request body")
+			}
+			reqBodyReader = bytes.NewBuffer(reqBodyBytes)
+		}
+	}
+
+	r, err := http.NewRequest(
+		req.Method,
+		fmt.Sprintf("%s/%s", apiAddress, req.Path),
+		reqBodyReader,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"error creating request %s %s",
+			req.Method,
+			req.Path,
+		)
+	}
+	r = r.WithContext(c.Context)
+	if len(req.QueryParams) > 0 {
+		q := r.URL.Query()
+		for k, v := range req.QueryParams {
+			q.Set(k, v)
+		}
+		r.URL.RawQuery = q.Encode()
+	}
+	if apiToken != "" {
+		r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiToken))
+	}
+	for k, v := range req.Headers {
+		r.Header.Add(k, v)
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: c.Bool(flagInsecure), // nolint: gosec
+			},
+		},
+	}
+	resp, err := httpClient.Do(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "error invoking API")
+	}
+
+	successCode := req.SuccessCode
+	if successCode == 0 {
+		successCode = http.StatusOK
+	}
+	if resp.StatusCode == successCode {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	var apiErr error
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		apiErr = &meta.ErrAuthentication{}
+	case http.StatusForbidden:
+		apiErr = &meta.ErrAuthorization{}
+	case http.StatusBadRequest:
+		apiErr = &meta.ErrBadRequest{}
+	case http.StatusNotFound:
+		apiErr = &meta.ErrNotFound{}
+	case http.StatusConflict:
+		apiErr = &meta.ErrConflict{}
+	case http.StatusInternalServerError:
+		apiErr = &meta.ErrInternalServer{}
+	default:
+		return nil, errors.Errorf("received %d from API server", resp.StatusCode)
+	}
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading error response body")
+	}
+	if err = json.Unmarshal(bodyBytes, apiErr); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling error response body")
+	}
+	return nil, apiErr
+}
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/brigadecore/brigade/sdk/v2/authx"
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 func confirmed(c *cli.Context) (bool, error) {
@@ -44,3 +54,242 @@ func shouldContinue(remainingItemCount int64) (bool, error) {
 	}
 	return shouldContinue, nil
 }
+
+// parseTimeFlag parses the provided string as either a duration relative to the
+// current time (e.g. 10m meaning ten minutes ago) or an RFC3339 timestamp. An
+// empty string results in a nil time.
+func parseTimeFlag(str string) (*time.Time, error) {
+	if str == "" {
+		return nil, nil
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		t := time.Now().Add(-d)
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, errors.Errorf(
+			"%q is neither a duration nor an RFC3339 timestamp",
+			str,
+		)
+	}
+	return &t, nil
+}
+
+// parseFutureTimeFlag parses the provided string as either a duration relative
+// to the current time (e.g. 720h meaning thirty days from now) or an RFC3339
+// timestamp. An empty string results in a nil time.
+func parseFutureTimeFlag(str string) (*time.Time, error) {
+	if str == "" {
+		return nil, nil
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		t := time.Now().Add(d)
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, errors.Errorf(
+			"%q is neither a duration nor an RFC3339 timestamp",
+			str,
+		)
+	}
+	return &t, nil
+}
+
+// principalTypeGroup represents a group of users, as asserted by an OpenID
+// Connect identity provider. The SDK does not yet support it.
+const principalTypeGroup authx.PrincipalType = "GROUP"
+
+// principalTypeTeam represents a team of users and service accounts. The SDK
+// does not yet support it.
+const principalTypeTeam authx.PrincipalType = "TEAM"
+
+// roleAssignmentPrincipal returns the type and ID of the principal specified
+// using exactly one of the --user, --service-account, --group, or --team
+// flags, along with a human-readable description of the principal's type.
+func roleAssignmentPrincipal(
+	c *cli.Context,
+) (authx.PrincipalType, string, string, error) {
+	principalFlags := []struct {
+		flag          string
+		principalType authx.PrincipalType
+		readableType  string
+	}{
+		{flagUser, authx.PrincipalTypeUser, "user"},
+		{flagServiceAccount, authx.PrincipalTypeServiceAccount, "service account"},
+		{flagGroup, principalTypeGroup, "group"},
+		{flagTeam, principalTypeTeam, "team"},
+	}
+	var principalType authx.PrincipalType
+	var principalID string
+	var readableType string
+	for _, principalFlag := range principalFlags {
+		id := c.String(principalFlag.flag)
+		if id == "" {
+			continue
+		}
+		if principalID != "" {
+			return "", "", "", errors.New(
+				"only one of --user, --service-account, --group, or --team must " +
+					"be specified",
+			)
+		}
+		principalType = principalFlag.principalType
+		principalID = id
+		readableType = principalFlag.readableType
+	}
+	if principalID == "" {
+		return "", "", "", errors.New(
+			"one of --user, --service-account, --group, or --team must be " +
+				"specified",
+		)
+	}
+	return principalType, principalID, readableType, nil
+}
+
+// roleAssignmentList is an ordered list of role assignments, which the SDK
+// does not yet support listing.
+type roleAssignmentList struct {
+	meta.ListMeta `json:"metadata"`
+	Items         []authx.RoleAssignment `json:"items,omitempty"`
+}
+
+// MarshalJSON amends roleAssignmentList instances with type metadata.
+func (r roleAssignmentList) MarshalJSON() ([]byte, error) {
+	type Alias roleAssignmentList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "RoleAssignmentList",
+			},
+			Alias: (Alias)(r),
+		},
+	)
+}
+
+// listRoleAssignments retrieves role assignments from the specified path,
+// narrowed using the optional --role, --user, --service-account, --group, and
+// --team flags, and prints them in the format specified by the --output flag.
+// The scope column is omitted from table output unless includeScope is true.
+// Paginated results are fetched one page at a time for as long as the user
+// wishes to continue.
+func listRoleAssignments(
+	c *cli.Context,
+	path string,
+	includeScope bool,
+) error {
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	queryParams := map[string]string{}
+	if role := c.String(flagRole); role != "" {
+		queryParams["role"] = role
+	}
+	if c.String(flagUser) != "" ||
+		c.String(flagServiceAccount) != "" ||
+		c.String(flagGroup) != "" ||
+		c.String(flagTeam) != "" {
+		principalType, principalID, _, err := roleAssignmentPrincipal(c)
+		if err != nil {
+			return err
+		}
+		queryParams["principalType"] = string(principalType)
+		queryParams["principalID"] = principalID
+	}
+
+	for {
+		roleAssignments := roleAssignmentList{}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodGet,
+				Path:        path,
+				QueryParams: queryParams,
+				RespObj:     &roleAssignments,
+			},
+		); err != nil {
+			return err
+		}
+
+		if len(roleAssignments.Items) == 0 {
+			fmt.Println("No role assignments found.")
+			return nil
+		}
+
+		switch strings.ToLower(output) {
+		case "table":
+			table := uitable.New()
+			if includeScope {
+				table.AddRow("PRINCIPAL TYPE", "PRINCIPAL ID", "ROLE", "SCOPE")
+			} else {
+				table.AddRow("PRINCIPAL TYPE", "PRINCIPAL ID", "ROLE")
+			}
+			for _, roleAssignment := range roleAssignments.Items {
+				if includeScope {
+					table.AddRow(
+						roleAssignment.PrincipalType,
+						roleAssignment.PrincipalID,
+						roleAssignment.Role,
+						roleAssignment.Scope,
+					)
+				} else {
+					table.AddRow(
+						roleAssignment.PrincipalType,
+						roleAssignment.PrincipalID,
+						roleAssignment.Role,
+					)
+				}
+			}
+			fmt.Println(table)
+
+		case "yaml":
+			yamlBytes, err := yaml.Marshal(roleAssignments)
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list role assignments operation",
+				)
+			}
+			fmt.Println(string(yamlBytes))
+
+		case "json":
+			prettyJSON, err := json.MarshalIndent(roleAssignments, "", "  ")
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list role assignments operation",
+				)
+			}
+			fmt.Println(string(prettyJSON))
+		}
+
+		if roleAssignments.RemainingItemCount < 1 ||
+			roleAssignments.Continue == "" {
+			break
+		}
+
+		// Exit after one page of output if this isn't a terminal
+		if !terminal.IsTerminal(int(os.Stdout.Fd())) {
+			break
+		}
+
+		if shouldContinue, err :=
+			shouldContinue(roleAssignments.RemainingItemCount); err != nil {
+			return err
+		} else if !shouldContinue {
+			break
+		}
+
+		queryParams["continue"] = roleAssignments.Continue
+	}
+
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -233,11 +235,29 @@ var eventCommand = &cli.Command{
 						"CANCELED phase; mutually exclusive with --terminal and " +
 						"--non-terminal",
 				},
+				&cli.StringFlag{
+					Name: flagCreatedAfter,
+					Usage: "If set, will retrieve only events created at or after the " +
+						"specified time; accepts a duration relative to the current " +
+						"time (e.g. 1h) or an RFC3339 timestamp",
+				},
+				&cli.StringFlag{
+					Name: flagCreatedBefore,
+					Usage: "If set, will retrieve only events created before the " +
+						"specified time; accepts a duration relative to the current " +
+						"time (e.g. 1h) or an RFC3339 timestamp",
+				},
 				&cli.BoolFlag{
 					Name: flagFailed,
 					Usage: "If set, will retrieve events with their worker in a FAILED " +
 						"phase; mutually exclusive with  --terminal and --non-terminal",
 				},
+				&cli.StringFlag{
+					Name:    flagLabels,
+					Aliases: []string{"l"},
+					Usage: "If set, will retrieve only events whose labels satisfy the " +
+						"specified selector (e.g. 'env=prod,tier in (web,api),!draft')",
+				},
 				&cli.BoolFlag{
 					Name: flagNonTerminal,
 					Usage: "If set, will retrieve events with their worker in any " +
@@ -260,6 +280,12 @@ var eventCommand = &cli.Command{
 					Usage: "If set, will retrieve events with their worker in RUNNING " +
 						"phase; mutually exclusive with --terminal and --non-terminal",
 				},
+				&cli.StringFlag{
+					Name:    flagSource,
+					Aliases: []string{"s"},
+					Usage: "If set, will retrieve only events from the specified " +
+						"source",
+				},
 				&cli.BoolFlag{
 					Name: flagSucceeded,
 					Usage: "If set, will retrieve events with their worker in a " +
@@ -277,6 +303,16 @@ var eventCommand = &cli.Command{
 						"TIMED_OUT phase; mutually exclusive with --terminal and " +
 						"--non-terminal",
 				},
+				&cli.StringFlag{
+					Name: flagTitleSearch,
+					Usage: "If set, will retrieve only events whose short or long " +
+						"title contains the specified words",
+				},
+				&cli.StringFlag{
+					Name:    flagType,
+					Aliases: []string{"t"},
+					Usage:   "If set, will retrieve only events of the specified type",
+				},
 				&cli.BoolFlag{
 					Name: flagUnknown,
 					Usage: "If set, will retrieve events with their worker in an " +
@@ -391,19 +427,29 @@ func eventList(c *cli.Context) error {
 		return err
 	}
 
-	client, err := getClient(c)
-	if err != nil {
-		return err
+	selector := eventsSelector{
+		EventsSelector: core.EventsSelector{
+			ProjectID:    projectID,
+			WorkerPhases: workerPhases,
+		},
+		Source:      c.String(flagSource),
+		Type:        c.String(flagType),
+		Labels:      c.String(flagLabels),
+		TitleSearch: c.String(flagTitleSearch),
 	}
-
-	selector := core.EventsSelector{
-		ProjectID:    projectID,
-		WorkerPhases: workerPhases,
+	var err error
+	if selector.CreatedAfter, err =
+		parseTimeFlag(c.String(flagCreatedAfter)); err != nil {
+		return errors.Wrapf(err, "invalid value for --%s", flagCreatedAfter)
+	}
+	if selector.CreatedBefore, err =
+		parseTimeFlag(c.String(flagCreatedBefore)); err != nil {
+		return errors.Wrapf(err, "invalid value for --%s", flagCreatedBefore)
 	}
 	opts := meta.ListOptions{}
 
 	for {
-		events, err := client.Core().Events().List(c.Context, &selector, &opts)
+		events, err := listEvents(c, selector, opts)
 		if err != nil {
 			return err
 		}
@@ -472,6 +518,85 @@ func eventList(c *cli.Context) error {
 	return nil
 }
 
+// eventsSelector is a superset of the SDK's core.EventsSelector that includes
+// criteria the SDK does not yet support.
+type eventsSelector struct {
+	core.EventsSelector
+	// Source specifies that only events from the indicated source should be
+	// selected.
+	Source string
+	// Type specifies that only events of the indicated type should be selected.
+	Type string
+	// Labels is a label selector expression that an event's labels must
+	// satisfy for the event to be selected.
+	Labels string
+	// CreatedAfter specifies that only events created at or after the indicated
+	// time should be selected.
+	CreatedAfter *time.Time
+	// CreatedBefore specifies that only events created before the indicated
+	// time should be selected.
+	CreatedBefore *time.Time
+	// TitleSearch specifies words that an event's short or long title must
+	// contain for the event to be selected.
+	TitleSearch string
+}
+
+// listEvents works like the SDK's EventsClient.List() function, but supports
+// the additional criteria in eventsSelector.
+func listEvents(
+	c *cli.Context,
+	selector eventsSelector,
+	opts meta.ListOptions,
+) (core.EventList, error) {
+	queryParams := map[string]string{}
+	if selector.ProjectID != "" {
+		queryParams["projectID"] = selector.ProjectID
+	}
+	if len(selector.WorkerPhases) > 0 {
+		workerPhaseStrs := make([]string, len(selector.WorkerPhases))
+		for i, workerPhase := range selector.WorkerPhases {
+			workerPhaseStrs[i] = string(workerPhase)
+		}
+		queryParams["workerPhases"] = strings.Join(workerPhaseStrs, ",")
+	}
+	if selector.Source != "" {
+		queryParams["source"] = selector.Source
+	}
+	if selector.Type != "" {
+		queryParams["type"] = selector.Type
+	}
+	if selector.Labels != "" {
+		queryParams["labels"] = selector.Labels
+	}
+	if selector.CreatedAfter != nil {
+		queryParams["createdAfter"] =
+			selector.CreatedAfter.UTC().Format(time.RFC3339)
+	}
+	if selector.CreatedBefore != nil {
+		queryParams["createdBefore"] =
+			selector.CreatedBefore.UTC().Format(time.RFC3339)
+	}
+	if selector.TitleSearch != "" {
+		queryParams["titleSearch"] = selector.TitleSearch
+	}
+	if opts.Continue != "" {
+		queryParams["continue"] = opts.Continue
+	}
+	if opts.Limit != 0 {
+		queryParams["limit"] = strconv.FormatInt(opts.Limit, 10)
+	}
+	events := core.EventList{}
+	return events, executeAPIRequest(
+		c,
+		apiRequest{
+			Method:      http.MethodGet,
+			Path:        "v2/events",
+			QueryParams: queryParams,
+			RespObj:     &events,
+		},
+	)
+}
+
 func eventGet(c *cli.Context) error {
 	id := c.String(flagID)
 	output := c.String(flagOutput)
@@ -4,24 +4,38 @@ import "github.com/urfave/cli/v2"
 
 const (
 	flagAborted        = "aborted"
+	flagAll            = "all"
 	flagAnyPhase       = "any-phase"
 	flagBrowse         = "browse"
 	flagCanceled       = "canceled"
 	flagContainer      = "container"
+	flagCreatedAfter   = "created-after"
+	flagCreatedBefore  = "created-before"
 	flagDescription    = "description"
+	flagDryRun         = "dry-run"
 	flagEvent          = "event"
+	flagExpires        = "expires"
 	flagFailed         = "failed"
 	flagFile           = "file"
 	flagFollow         = "follow"
+	flagGracePeriod    = "grace-period"
+	flagGroup          = "group"
 	flagID             = "id"
+	flagIncludeSecrets = "include-secrets"
 	flagInsecure       = "insecure"
 	flagJob            = "job"
+	flagLabels         = "labels"
+	flagName           = "name"
 	flagOutput         = "output"
+	flagPassphrase     = "passphrase"
 	flagPassword       = "password"
+	flagPatch          = "patch"
 	flagPayload        = "payload"
 	flagPayloadFile    = "payload-file"
 	flagPending        = "pending"
+	flagPermission     = "permission"
 	flagProject        = "project"
+	flagPrune          = "prune"
 	flagRole           = "role"
 	flagNonTerminal    = "non-terminal"
 	flagRoot           = "root"
@@ -29,13 +43,19 @@ const (
 	flagServer         = "server"
 	flagServiceAccount = "service-account"
 	flagSet            = "set"
+	flagSince          = "since"
 	flagSource         = "source"
 	flagSucceeded      = "succeeded"
+	flagTail           = "tail"
+	flagTeam           = "team"
 	flagTerminal       = "terminal"
 	flagTimedOut       = "timedout"
+	flagTimestamps     = "timestamps"
+	flagTitleSearch    = "title-search"
 	flagType           = "type"
 	flagUnknown        = "unknown"
 	flagUnset          = "unset"
+	flagUntil          = "until"
 	flagUser           = "user"
 	flagYes            = "yes"
 )
@@ -1,13 +1,13 @@
 package main
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/http"
 	"os/exec"
 	"runtime"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/brigadecore/brigade/sdk/v2/authx"
-	"github.com/brigadecore/brigade/sdk/v2/restmachinery"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
@@ -52,15 +52,7 @@ func login(c *cli.Context) error {
 	password := c.String(flagPassword)
 	rootLogin := c.Bool(flagRoot)
 
-	client := authx.NewSessionsClient(
-		address,
-		"",
-		&restmachinery.APIClientOptions{
-			AllowInsecureConnections: c.Bool(flagInsecure),
-		},
-	)
-
-	var tokenStr, authURL string
+	var tokenStr, refreshTokenStr, authURL string
 
 	if rootLogin {
 		for {
@@ -75,25 +67,54 @@ func login(c *cli.Context) error {
 			}
 		}
 
-		token, err := client.CreateRootSession(c.Context, password)
-		if err != nil {
+		token := sessionToken{}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				APIAddress:  address,
+				Method:      http.MethodPost,
+				Path:        "v2/sessions",
+				QueryParams: map[string]string{"root": "true"},
+				Headers: map[string]string{
+					"Authorization": fmt.Sprintf(
+						"Basic %s",
+						base64.StdEncoding.EncodeToString(
+							[]byte(fmt.Sprintf("root:%s", password)),
+						),
+					),
+				},
+				SuccessCode: http.StatusCreated,
+				RespObj:     &token,
+			},
+		); err != nil {
 			return err
 		}
 		tokenStr = token.Value
+		refreshTokenStr = token.RefreshToken
 	} else {
-		OIDCAuthDetails, err :=
-			client.CreateUserSession(c.Context)
-		if err != nil {
+		oidcAuthDetails := oidcAuthDetails{}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				APIAddress:  address,
+				Method:      http.MethodPost,
+				Path:        "v2/sessions",
+				SuccessCode: http.StatusCreated,
+				RespObj:     &oidcAuthDetails,
+			},
+		); err != nil {
 			return err
 		}
-		authURL = OIDCAuthDetails.AuthURL
-		tokenStr = OIDCAuthDetails.Token
+		authURL = oidcAuthDetails.AuthURL
+		tokenStr = oidcAuthDetails.Token
+		refreshTokenStr = oidcAuthDetails.RefreshToken
 	}
 
 	if err := saveConfig(
 		&config{
-			APIAddress: address,
-			APIToken:   tokenStr,
+			APIAddress:      address,
+			APIToken:        tokenStr,
+			APIRefreshToken: refreshTokenStr,
 		},
 	); err != nil {
 		return errors.Wrap(err, "error persisting configuration")
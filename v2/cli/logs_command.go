@@ -1,9 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/brigadecore/brigade/sdk/v2/core"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
@@ -11,17 +21,25 @@ var logsCommand = &cli.Command{
 	Name:  "logs",
 	Usage: "View worker or job logs",
 	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    flagAll,
+			Aliases: []string{"a"},
+			Usage: "View logs from the worker and all jobs and containers thereof, " +
+				"interleaved and prefixed with their source; mutually exclusive " +
+				"with --job and --container",
+		},
 		&cli.StringFlag{
 			Name:    flagContainer,
 			Aliases: []string{"c"},
 			Usage: "View logs from the specified container; if not set, displays " +
 				"logs from the worker or job's \"primary\" container",
 		},
+		// Not marked as required because that would also require it to be set
+		// before the name of any subcommand. We validate this ourselves instead.
 		&cli.StringFlag{
-			Name:     flagEvent,
-			Aliases:  []string{"e"},
-			Usage:    "View logs from the specified event",
-			Required: true,
+			Name:    flagEvent,
+			Aliases: []string{"e"},
+			Usage:   "View logs from the specified event (required)",
 		},
 		&cli.BoolFlag{
 			Name:    flagFollow,
@@ -34,37 +52,187 @@ var logsCommand = &cli.Command{
 			Usage: "View logs from the specified job; if not set, displays " +
 				"worker logs",
 		},
+		&cli.StringFlag{
+			Name: flagSince,
+			Usage: "Only display logs written after the specified time; accepts " +
+				"either a relative duration (e.g. 10m) or an RFC3339 timestamp",
+		},
+		&cli.Int64Flag{
+			Name:  flagTail,
+			Usage: "Only display the specified number of most recent lines of logs",
+		},
+		&cli.BoolFlag{
+			Name:    flagTimestamps,
+			Aliases: []string{"t"},
+			Usage:   "If set, will prefix each line of logs with its timestamp",
+		},
+		&cli.StringFlag{
+			Name: flagUntil,
+			Usage: "Only display logs written before the specified time; accepts " +
+				"either a relative duration (e.g. 10m) or an RFC3339 timestamp",
+		},
 	},
 	Action: logs,
+	Subcommands: []*cli.Command{
+		{
+			Name:  "download",
+			Usage: "Download all of an event's logs as a gzipped tarball",
+			Description: "Downloads a gzipped tarball containing one file per " +
+				"container of the event's worker and of every job, plus a JSON " +
+				"manifest describing the event and the statuses and timings of its " +
+				"worker and jobs",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagEvent,
+					Aliases:  []string{"e"},
+					Usage:    "Download logs from the specified event (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagFile,
+					Aliases: []string{"f"},
+					Usage: "Save logs to the specified file; if not set, saves to " +
+						"<event ID>-logs.tar.gz in the current directory",
+					TakesFile: true,
+				},
+			},
+			Action: logsDownload,
+		},
+	},
+}
+
+// logsSelector is a superset of the SDK's core.LogsSelector that includes
+// options the SDK does not yet support.
+type logsSelector struct {
+	core.LogsSelector
+	// All indicates that logs from all containers of the worker and all of its
+	// jobs should be multiplexed into a single stream.
+	All bool
+}
+
+// logEntry is a superset of the SDK's core.LogEntry that includes fields the
+// SDK does not yet support.
+type logEntry struct {
+	core.LogEntry `json:",inline"`
+	// Source identifies the container that wrote the line. It is only populated
+	// when logs from multiple containers are multiplexed into a single stream.
+	Source *logEntrySource `json:"source,omitempty"`
+}
+
+// logEntrySource identifies a specific container of a worker or job.
+type logEntrySource struct {
+	Job       string `json:"job,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+// String returns a concise, human-readable representation of the source.
+func (l logEntrySource) String() string {
+	if l.Job == "" {
+		return l.Container
+	}
+	if l.Container == "" || l.Container == l.Job {
+		return l.Job
+	}
+	return fmt.Sprintf("%s/%s", l.Job, l.Container)
+}
+
+// logSourceColors are the colors cycled through when prefixing multiplexed
+// log entries with their source.
+var logSourceColors = []color.Attribute{
+	color.FgCyan,
+	color.FgGreen,
+	color.FgYellow,
+	color.FgMagenta,
+	color.FgBlue,
+	color.FgRed,
+}
+
+// logStreamOptions is a superset of the SDK's core.LogStreamOptions that
+// includes options the SDK does not yet support.
+type logStreamOptions struct {
+	core.LogStreamOptions
+	// Tail, if greater than zero, limits the logs streamed to the specified
+	// number of most recent lines.
+	Tail int64
+	// Since, if non-nil, excludes logs written before the specified time.
+	Since *time.Time
+	// Until, if non-nil, excludes logs written after the specified time.
+	Until *time.Time
+	// Timestamps indicates whether each log entry should include the time it
+	// was written.
+	Timestamps bool
 }
 
 func logs(c *cli.Context) error {
 	eventID := c.String(flagEvent)
+	if eventID == "" {
+		return errors.Errorf("Required flag %q not set", flagEvent)
+	}
 	follow := c.Bool(flagFollow)
+	timestamps := c.Bool(flagTimestamps)
 
-	selector := core.LogsSelector{
-		Job:       c.String(flagJob),
-		Container: c.String(flagContainer),
+	selector := logsSelector{
+		LogsSelector: core.LogsSelector{
+			Job:       c.String(flagJob),
+			Container: c.String(flagContainer),
+		},
+		All: c.Bool(flagAll),
 	}
-	opts := core.LogStreamOptions{
-		Follow: follow,
+	if selector.All && (selector.Job != "" || selector.Container != "") {
+		return errors.Errorf(
+			"--%s is mutually exclusive with --%s and --%s",
+			flagAll,
+			flagJob,
+			flagContainer,
+		)
 	}
-
-	client, err := getClient(c)
-	if err != nil {
-		return err
+	opts := logStreamOptions{
+		LogStreamOptions: core.LogStreamOptions{
+			Follow: follow,
+		},
+		Tail:       c.Int64(flagTail),
+		Timestamps: timestamps,
+	}
+	if opts.Tail < 0 {
+		return errors.Errorf("invalid value %d for --%s", opts.Tail, flagTail)
+	}
+	var err error
+	if opts.Since, err = parseTimeFlag(c.String(flagSince)); err != nil {
+		return errors.Wrapf(err, "invalid value for --%s", flagSince)
+	}
+	if opts.Until, err = parseTimeFlag(c.String(flagUntil)); err != nil {
+		return errors.Wrapf(err, "invalid value for --%s", flagUntil)
 	}
 
-	logEntryCh, errCh, err :=
-		client.Core().Events().Logs().Stream(c.Context, eventID, &selector, &opts)
+	logEntryCh, errCh, err := streamLogs(c, eventID, selector, opts)
 	if err != nil {
 		return err
 	}
+	sourceColors := map[logEntrySource]*color.Color{}
 	for {
 		select {
 		case logEntry, ok := <-logEntryCh:
 			if ok {
-				fmt.Println(logEntry.Message)
+				line := logEntry.Message
+				if timestamps && logEntry.Time != nil {
+					line =
+						fmt.Sprintf("%s %s", logEntry.Time.Format(time.RFC3339), line)
+				}
+				if logEntry.Source != nil {
+					sourceColor, ok := sourceColors[*logEntry.Source]
+					if !ok {
+						sourceColor = color.New(
+							logSourceColors[len(sourceColors)%len(logSourceColors)],
+						)
+						sourceColors[*logEntry.Source] = sourceColor
+					}
+					line = fmt.Sprintf(
+						"%s %s",
+						sourceColor.Sprintf("[%s]", logEntry.Source),
+						line,
+					)
+				}
+				fmt.Println(line)
 			} else {
 				// logEntryCh was closed, but want to keep looping through this select
 				// in case there are pending errors on the errCh still. nil channels are
@@ -88,3 +256,187 @@ func logs(c *cli.Context) error {
 		}
 	}
 }
+
+func logsDownload(c *cli.Context) error {
+	eventID := c.String(flagEvent)
+	filename := c.String(flagFile)
+	if filename == "" {
+		filename = fmt.Sprintf("%s-logs.tar.gz", eventID)
+	}
+
+	resp, err := submitAPIRequest(
+		c,
+		apiRequest{
+			Method:      http.MethodGet,
+			Path:        fmt.Sprintf("v2/events/%s/logs/bundle", eventID),
+			SuccessCode: http.StatusOK,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrapf(err, "error creating file %s", filename)
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, resp.Body); err != nil {
+		return errors.Wrapf(err, "error writing logs to %s", filename)
+	}
+
+	fmt.Printf("Logs for event %q saved to %s.\n", eventID, filename)
+
+	return nil
+}
+
+// streamLogs works like the SDK's LogsClient.Stream() function, but supports
+// the additional options in logStreamOptions and, when following, transparently
+// resumes streams that are interrupted by a dropped connection.
+func streamLogs(
+	c *cli.Context,
+	eventID string,
+	selector logsSelector,
+	opts logStreamOptions,
+) (<-chan logEntry, <-chan error, error) {
+	queryParams := map[string]string{}
+	if selector.Job != "" {
+		queryParams["job"] = selector.Job
+	}
+	if selector.Container != "" {
+		queryParams["container"] = selector.Container
+	}
+	if selector.All {
+		queryParams["all"] = "true"
+	}
+	if opts.Follow {
+		queryParams["follow"] = "true"
+	}
+	if opts.Tail > 0 {
+		queryParams["tail"] = strconv.FormatInt(opts.Tail, 10)
+	}
+	if opts.Since != nil {
+		queryParams["since"] = opts.Since.Format(time.RFC3339)
+	}
+	if opts.Until != nil {
+		queryParams["until"] = opts.Until.Format(time.RFC3339)
+	}
+	if opts.Timestamps {
+		queryParams["timestamps"] = "true"
+	}
+
+	req := apiRequest{
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("v2/events/%s/logs", eventID),
+		QueryParams: queryParams,
+		SuccessCode: http.StatusOK,
+	}
+	resp, err := submitAPIRequest(c, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logEntryCh := make(chan logEntry)
+	errCh := make(chan error)
+
+	go func() {
+		defer close(logEntryCh)
+		defer close(errCh)
+		var lastEventID string
+		for {
+			err := receiveLogEvents(c, resp.Body, logEntryCh, &lastEventID)
+			resp.Body.Close()
+			if err == nil || c.Context.Err() != nil {
+				return
+			}
+			// The connection was interrupted. If we're following a stream that can
+			// be resumed, reconnect and pick up where we left off.
+			if !opts.Follow || selector.All {
+				select {
+				case errCh <- err:
+				case <-c.Context.Done():
+				}
+				return
+			}
+			select {
+			case <-time.After(time.Second): // Wait before reconnecting
+			case <-c.Context.Done():
+				return
+			}
+			if lastEventID != "" {
+				req.Headers = map[string]string{"Last-Event-ID": lastEventID}
+			}
+			if resp, err = submitAPIRequest(c, req); err != nil {
+				select {
+				case errCh <- err:
+				case <-c.Context.Done():
+				}
+				return
+			}
+		}
+	}()
+
+	return logEntryCh, errCh, nil
+}
+
+// receiveLogEvents reads Server-Sent Events from the provided reader, decodes
+// each one into a logEntry, and sends it over the provided channel. The ID of
+// the last event received is recorded in lastEventID so an interrupted stream
+// can be resumed. A nil error is returned when the stream ends normally.
+func receiveLogEvents(
+	c *cli.Context,
+	body io.Reader,
+	logEntryCh chan<- logEntry,
+	lastEventID *string,
+) error {
+	reader := bufio.NewReader(body)
+	var data []string
+	var eventID string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" { // A blank line terminates an event
+			if len(data) > 0 {
+				entry := logEntry{}
+				if err = json.Unmarshal(
+					[]byte(strings.Join(data, "\n")),
+					&entry,
+				); err != nil {
+					return errors.Wrap(err, "error unmarshaling log entry")
+				}
+				select {
+				case logEntryCh <- entry:
+				case <-c.Context.Done():
+					return nil
+				}
+			}
+			if eventID != "" {
+				*lastEventID = eventID
+			}
+			data = nil
+			eventID = ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") { // Comments (heartbeats) are ignored
+			continue
+		}
+		fieldParts := strings.SplitN(line, ":", 2)
+		var value string
+		if len(fieldParts) == 2 {
+			value = strings.TrimPrefix(fieldParts[1], " ")
+		}
+		switch fieldParts[0] {
+		case "data":
+			data = append(data, value)
+		case "id":
+			eventID = value
+		}
+	}
+}
@@ -30,8 +30,11 @@ func main() {
 		loginCommand,
 		logoutCommand,
 		projectCommand,
+		roleCommand,
 		serviceAccountCommand,
+		sessionCommand,
 		systemCommand,
+		teamCommand,
 		userCommand,
 	}
 	fmt.Println()
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -22,6 +25,50 @@ var projectCommand = &cli.Command{
 	Name:  "project",
 	Usage: "Manage projects",
 	Subcommands: []*cli.Command{
+		{
+			Name:  "apply",
+			Usage: "Create or update projects from definitions",
+			Description: "Creates each defined project that does not exist and " +
+				"updates each that does to match its definition",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name: flagDryRun,
+					Usage: "If set, will report what changes would be made without " +
+						"making them",
+				},
+				&cli.StringFlag{
+					Name:    flagFile,
+					Aliases: []string{"f"},
+					Usage: "A YAML or JSON file that describes a project or a " +
+						"directory containing such files (required)",
+					Required:  true,
+					TakesFile: true,
+				},
+				&cli.StringFlag{
+					Name:    flagLabels,
+					Aliases: []string{"l"},
+					Usage: "A label selector (e.g. 'team=frontend') identifying the " +
+						"projects that are managed by these definitions; required " +
+						"with --prune",
+				},
+				&cli.BoolFlag{
+					Name: flagPrune,
+					Usage: "If set, will delete projects selected by --labels that " +
+						"are not defined",
+				},
+				&cli.BoolFlag{
+					Name:    flagYes,
+					Aliases: []string{"y"},
+					Usage:   "Non-interactively confirm deletion of pruned projects",
+				},
+				&cli.StringFlag{
+					Name: flagTeam,
+					Usage: "The ID of a team to own any projects that are created; " +
+						"the team, rather than you, is granted the projects' roles",
+				},
+			},
+			Action: projectApply,
+		},
 		{
 			Name:  "create",
 			Usage: "Create a new project",
@@ -34,6 +81,11 @@ var projectCommand = &cli.Command{
 					Required:  true,
 					TakesFile: true,
 				},
+				&cli.StringFlag{
+					Name: flagTeam,
+					Usage: "The ID of a team to own the project; the team, rather " +
+						"than you, is granted the project's roles",
+				},
 			},
 			Action: projectCreate,
 		},
@@ -78,6 +130,41 @@ var projectCommand = &cli.Command{
 			},
 			Action: projectList,
 		},
+		{
+			Name:  "patch",
+			Usage: "Patch a project",
+			Description: "Applies a JSON merge patch (RFC 7386) or a JSON patch " +
+				"(RFC 6902), expressed in either YAML or JSON, to a project",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    flagFile,
+					Aliases: []string{"f"},
+					Usage: "A YAML or JSON file containing the patch; mutually " +
+						"exclusive with --patch",
+					TakesFile: true,
+				},
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Patch the specified project (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagPatch,
+					Aliases: []string{"p"},
+					Usage: "The patch, inline, in YAML or JSON; mutually exclusive " +
+						"with --file",
+				},
+				&cli.StringFlag{
+					Name:    flagType,
+					Aliases: []string{"t"},
+					Usage: "The type of patch; supported types: merge (RFC 7386), " +
+						"json (RFC 6902)",
+					Value: "merge",
+				},
+			},
+			Action: projectPatch,
+		},
 		projectRolesCommand,
 		secretsCommand,
 		{
@@ -98,8 +185,246 @@ var projectCommand = &cli.Command{
 	},
 }
 
+// projectApplyResult mirrors the API server's ProjectApplyResult type, which
+// the SDK does not (yet) support.
+type projectApplyResult struct {
+	Action  string          `json:"action"`
+	DryRun  bool            `json:"dryRun"`
+	Changes []projectChange `json:"changes"`
+	Project core.Project    `json:"project"`
+}
+
+// projectChange mirrors the API server's ProjectChange type, which the SDK
+// does not (yet) support.
+type projectChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+func projectApply(c *cli.Context) error {
+	path := c.String(flagFile)
+	dryRun := c.Bool(flagDryRun)
+	labels := c.String(flagLabels)
+	prune := c.Bool(flagPrune)
+	team := c.String(flagTeam)
+
+	if prune && labels == "" {
+		return errors.Errorf("--%s is required with --%s", flagLabels, flagPrune)
+	}
+
+	filenames, err := projectDefinitionFiles(path)
+	if err != nil {
+		return err
+	}
+
+	definedIDs := map[string]struct{}{}
+	for _, filename := range filenames {
+		projectBytes, id, err := readProjectDefinition(filename)
+		if err != nil {
+			return err
+		}
+		if _, ok := definedIDs[id]; ok {
+			return errors.Errorf("project %q is defined more than once", id)
+		}
+		definedIDs[id] = struct{}{}
+
+		result := projectApplyResult{}
+		queryParams := map[string]string{"dryRun": fmt.Sprint(dryRun)}
+		if team != "" {
+			queryParams["team"] = team
+		}
+		if err = executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodPost,
+				Path:        fmt.Sprintf("v2/projects/%s/apply", id),
+				QueryParams: queryParams,
+				ReqBodyObj:  projectBytes,
+				RespObj:     &result,
+			},
+		); err != nil {
+			return errors.Wrapf(err, "error applying project %q", id)
+		}
+		printProjectApplyResult(id, result)
+	}
+
+	if !prune {
+		return nil
+	}
+
+	pruneIDs := []string{}
+	var continueVal string
+	for {
+		projects := core.ProjectList{}
+		queryParams := map[string]string{"labels": labels}
+		if continueVal != "" {
+			queryParams["continue"] = continueVal
+		}
+		if err = executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodGet,
+				Path:        "v2/projects",
+				QueryParams: queryParams,
+				RespObj:     &projects,
+			},
+		); err != nil {
+			return errors.Wrap(err, "error listing projects to prune")
+		}
+		for _, project := range projects.Items {
+			if _, ok := definedIDs[project.ID]; !ok {
+				pruneIDs = append(pruneIDs, project.ID)
+			}
+		}
+		if projects.Continue == "" {
+			break
+		}
+		continueVal = projects.Continue
+	}
+
+	if len(pruneIDs) == 0 {
+		return nil
+	}
+	if dryRun {
+		for _, id := range pruneIDs {
+			fmt.Printf("Project %q would be deleted (dry run).\n", id)
+		}
+		return nil
+	}
+
+	fmt.Printf(
+		"The following projects will be deleted: %s\n",
+		strings.Join(pruneIDs, ", "),
+	)
+	confirmed, err := confirmed(c)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+	for _, id := range pruneIDs {
+		if err = client.Core().Projects().Delete(c.Context, id); err != nil {
+			return errors.Wrapf(err, "error deleting project %q", id)
+		}
+		fmt.Printf("Project %q deleted.\n", id)
+	}
+
+	return nil
+}
+
+// projectDefinitionFiles returns the provided path if it refers to a file or,
+// if it refers to a directory, the paths of all YAML and JSON files therein.
+func projectDefinitionFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading %s", path)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading directory %s", path)
+	}
+	filenames := []string{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		switch filepath.Ext(file.Name()) {
+		case ".yaml", ".yml", ".json":
+			filenames = append(filenames, filepath.Join(path, file.Name()))
+		}
+	}
+	if len(filenames) == 0 {
+		return nil, errors.Errorf("no project definitions found in %s", path)
+	}
+	return filenames, nil
+}
+
+// readProjectDefinition reads the specified YAML or JSON project definition
+// and returns it as JSON, along with the ID of the project it defines.
+func readProjectDefinition(filename string) ([]byte, string, error) {
+	projectBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, "",
+			errors.Wrapf(err, "error reading project file %s", filename)
+	}
+	if strings.HasSuffix(filename, ".yaml") ||
+		strings.HasSuffix(filename, ".yml") {
+		if projectBytes, err = yaml.YAMLToJSON(projectBytes); err != nil {
+			return nil, "",
+				errors.Wrapf(err, "error converting file %s to JSON", filename)
+		}
+	}
+	// As with updates, we unmarshal only to get the project ID. The raw JSON is
+	// what's sent to the API so that server-side JSON schema validation applies
+	// to what's actually in the file.
+	project := core.Project{}
+	if err = json.Unmarshal(projectBytes, &project); err != nil {
+		return nil, "",
+			errors.Wrapf(err, "error unmarshaling project file %s", filename)
+	}
+	if project.ID == "" {
+		return nil, "", errors.Errorf(
+			"project definition in %s does not specify an ID",
+			filename,
+		)
+	}
+	return projectBytes, project.ID, nil
+}
+
+func printProjectApplyResult(id string, result projectApplyResult) {
+	var dryRunStr string
+	if result.DryRun {
+		dryRunStr = " (dry run)"
+	}
+	switch result.Action {
+	case "CREATED":
+		fmt.Printf("Project %q created%s.\n", id, dryRunStr)
+	case "UNCHANGED":
+		fmt.Printf("Project %q unchanged.\n", id)
+	case "UPDATED":
+		fmt.Printf("Project %q updated%s:\n", id, dryRunStr)
+		for _, change := range result.Changes {
+			fmt.Printf(
+				"  %s: %s -> %s\n",
+				change.Path,
+				formatProjectChangeValue(change.Old),
+				formatProjectChangeValue(change.New),
+			)
+		}
+	default:
+		fmt.Printf(
+			"Project %q %s%s.\n",
+			id,
+			strings.ToLower(result.Action),
+			dryRunStr,
+		)
+	}
+}
+
+func formatProjectChangeValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(valueBytes)
+}
+
 func projectCreate(c *cli.Context) error {
 	filename := c.String(flagFile)
+	team := c.String(flagTeam)
 
 	// Read and parse the file
 	projectBytes, err := ioutil.ReadFile(filename)
@@ -124,6 +449,24 @@ func projectCreate(c *cli.Context) error {
 		return errors.Wrapf(err, "error unmarshaling project file %s", filename)
 	}
 
+	if team != "" {
+		// The SDK does not yet support specifying an owning team
+		if err = executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodPost,
+				Path:        "v2/projects",
+				QueryParams: map[string]string{"team": team},
+				ReqBodyObj:  projectBytes,
+				SuccessCode: http.StatusCreated,
+			},
+		); err != nil {
+			return err
+		}
+		fmt.Printf("Created project %q owned by team %q.\n", project.ID, team)
+		return nil
+	}
+
 	client, err := getClient(c)
 	if err != nil {
 		return err
@@ -231,6 +574,10 @@ func projectGet(c *cli.Context) error {
 		return err
 	}
 
+	if strings.ToLower(output) != "table" {
+		return projectGetRaw(c, id, output)
+	}
+
 	client, err := getClient(c)
 	if err != nil {
 		return err
@@ -241,23 +588,42 @@ func projectGet(c *cli.Context) error {
 		return err
 	}
 
-	switch strings.ToLower(output) {
-	case "table":
-		table := uitable.New()
-		table.AddRow("ID", "DESCRIPTION", "AGE")
-		var age string
-		if project.Created != nil {
-			age = duration.ShortHumanDuration(time.Since(*project.Created))
-		}
-		table.AddRow(
-			project.ID,
-			project.Description,
-			age,
-		)
-		fmt.Println(table)
+	table := uitable.New()
+	table.AddRow("ID", "DESCRIPTION", "AGE")
+	var age string
+	if project.Created != nil {
+		age = duration.ShortHumanDuration(time.Since(*project.Created))
+	}
+	table.AddRow(
+		project.ID,
+		project.Description,
+		age,
+	)
+	fmt.Println(table)
 
+	return nil
+}
+
+// projectGetRaw outputs a project exactly as it was returned by the API server.
+// The SDK's Project type does not (yet) include the project's resource version,
+// which is needed for the output to be edited and then safely submitted using
+// `brig project update`.
+func projectGetRaw(c *cli.Context, id string, output string) error {
+	projectJSON := json.RawMessage{}
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method:  http.MethodGet,
+			Path:    fmt.Sprintf("v2/projects/%s", id),
+			RespObj: &projectJSON,
+		},
+	); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(output) {
 	case "yaml":
-		yamlBytes, err := yaml.Marshal(project)
+		yamlBytes, err := yaml.JSONToYAML(projectJSON)
 		if err != nil {
 			return errors.Wrap(
 				err,
@@ -267,14 +633,14 @@ func projectGet(c *cli.Context) error {
 		fmt.Println(string(yamlBytes))
 
 	case "json":
-		prettyJSON, err := json.MarshalIndent(project, "", "  ")
-		if err != nil {
+		prettyJSON := bytes.Buffer{}
+		if err := json.Indent(&prettyJSON, projectJSON, "", "  "); err != nil {
 			return errors.Wrap(
 				err,
 				"error formatting output from get project operation",
 			)
 		}
-		fmt.Println(string(prettyJSON))
+		fmt.Println(prettyJSON.String())
 	}
 
 	return nil
@@ -296,37 +662,109 @@ func projectUpdate(c *cli.Context) error {
 		}
 	}
 
-	// We unmarshal just so that we can get the project ID. Otherwise, we wouldn't
-	// need to do this, because we pass raw JSON to the API so that server-side
-	// JSON schema validation is applied to what's in the file and NOT to a
-	// project description that was inadvertently scrubbed of non-permitted fields
-	// during client-side unmarshaling.
-	project := core.Project{}
+	// We unmarshal just so that we can get the project ID and resource version.
+	// Otherwise, we wouldn't need to do this, because we pass raw JSON to the API
+	// so that server-side JSON schema validation is applied to what's in the file
+	// and NOT to a project description that was inadvertently scrubbed of
+	// non-permitted fields during client-side unmarshaling. The SDK's Project
+	// type does not (yet) include the resource version, so we use our own.
+	project := struct {
+		Metadata struct {
+			ID              string `json:"id"`
+			ResourceVersion int64  `json:"resourceVersion"`
+		} `json:"metadata"`
+	}{}
 	if err = json.Unmarshal(projectBytes, &project); err != nil {
 		return errors.Wrapf(err, "error unmarshaling project file %s", filename)
 	}
+	id := project.Metadata.ID
 
 	// If the project ID is missing, we can go no further. All other validation
 	// occurs server-side, but without an ID, we cannot even construct the URL
 	// that we need to PUT to.
-	if project.ID == "" {
+	if id == "" {
 		return errors.New("project definition does not specify an ID")
 	}
 
-	client, err := getClient(c)
-	if err != nil {
+	// If the definition was based on a version of the project obtained using
+	// `brig project get`, the update should only succeed if the project hasn't
+	// been modified since.
+	headers := map[string]string{}
+	if project.Metadata.ResourceVersion != 0 {
+		headers["If-Match"] =
+			fmt.Sprintf(`"%d"`, project.Metadata.ResourceVersion)
+	}
+
+	if err = executeAPIRequest(
+		c,
+		apiRequest{
+			Method:     http.MethodPut,
+			Path:       fmt.Sprintf("v2/projects/%s", id),
+			Headers:    headers,
+			ReqBodyObj: projectBytes,
+		},
+	); err != nil {
 		return err
 	}
 
-	if _, err = client.Core().Projects().UpdateFromBytes(
-		c.Context,
-		project.ID,
-		projectBytes,
+	fmt.Printf("Updated project %q.\n", id)
+
+	return nil
+}
+
+func projectPatch(c *cli.Context) error {
+	id := c.String(flagID)
+	patch := c.String(flagPatch)
+	filename := c.String(flagFile)
+
+	if (patch == "") == (filename == "") {
+		return errors.Errorf(
+			"exactly one of --%s or --%s must be specified",
+			flagPatch,
+			flagFile,
+		)
+	}
+
+	var contentType string
+	switch strings.ToLower(c.String(flagType)) {
+	case "merge":
+		contentType = "application/merge-patch+json"
+	case "json":
+		contentType = "application/json-patch+json"
+	default:
+		return errors.Errorf(
+			"unsupported patch type %q; supported types are merge and json",
+			c.String(flagType),
+		)
+	}
+
+	patchBytes := []byte(patch)
+	var err error
+	if filename != "" {
+		if patchBytes, err = ioutil.ReadFile(filename); err != nil {
+			return errors.Wrapf(err, "error reading patch file %s", filename)
+		}
+	}
+	// YAML is a superset of JSON, so this works for patches expressed in either
+	if patchBytes, err = yaml.YAMLToJSON(patchBytes); err != nil {
+		return errors.Wrap(err, "error converting patch to JSON")
+	}
+
+	if err = executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPatch,
+			Path:   fmt.Sprintf("v2/projects/%s", id),
+			Headers: map[string]string{
+				"Content-Type": contentType,
+			},
+			ReqBodyObj: patchBytes,
+		},
 	); err != nil {
 		return err
 	}
 
-	fmt.Printf("Updated project %q.\n", project.ID)
+	fmt.Printf("Patched project %q.\n", id)
 
 	return nil
 }
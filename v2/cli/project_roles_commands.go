@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/brigadecore/brigade/sdk/v2/authx"
-	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
@@ -13,8 +12,9 @@ var projectRolesCommand = &cli.Command{
 	Usage: "Manage project roles",
 	Subcommands: []*cli.Command{
 		{
-			Name:  "grant",
-			Usage: "Grant a project role to a user or service account",
+			Name: "grant",
+			Usage: "Grant a project role to a user, service account, group, or " +
+				"team",
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:     flagProject,
@@ -28,24 +28,81 @@ var projectRolesCommand = &cli.Command{
 					Usage:    "Grant the specified role (required)",
 					Required: true,
 				},
+				&cli.StringFlag{
+					Name:    flagGroup,
+					Aliases: []string{"g"},
+					Usage: "Grant the role to the specified group; mutually exclusive " +
+						"with --service-account, --team, and --user",
+				},
 				&cli.StringFlag{
 					Name:    flagServiceAccount,
 					Aliases: []string{"s"},
 					Usage: "Grant the role to the specified service account; mutually " +
-						"exclusive with --user",
+						"exclusive with --group, --team, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagTeam,
+					Aliases: []string{"t"},
+					Usage: "Grant the role to the specified team; mutually exclusive " +
+						"with --group, --service-account, and --user",
 				},
 				&cli.StringFlag{
 					Name:    flagUser,
 					Aliases: []string{"u"},
 					Usage: "Grant the role to the specified user; mutually exclusive " +
-						"with --servcice-account",
+						"with --group, --service-account, and --team",
 				},
 			},
 			Action: projectRolesGrant,
 		},
 		{
-			Name:  "revoke",
-			Usage: "Revoke a project role from a user or service account",
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "List a project's role assignments",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    flagGroup,
+					Aliases: []string{"g"},
+					Usage: "List only assignments to the specified group; mutually " +
+						"exclusive with --service-account, --team, and --user",
+				},
+				cliFlagOutput,
+				&cli.StringFlag{
+					Name:     flagProject,
+					Aliases:  []string{"p"},
+					Usage:    "List assignments for the specified project (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagRole,
+					Aliases: []string{"r"},
+					Usage:   "List only assignments of the specified role",
+				},
+				&cli.StringFlag{
+					Name:    flagServiceAccount,
+					Aliases: []string{"s"},
+					Usage: "List only assignments to the specified service account; " +
+						"mutually exclusive with --group, --team, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagTeam,
+					Aliases: []string{"t"},
+					Usage: "List only assignments to the specified team; mutually " +
+						"exclusive with --group, --service-account, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagUser,
+					Aliases: []string{"u"},
+					Usage: "List only assignments to the specified user; mutually " +
+						"exclusive with --group, --service-account, and --team",
+				},
+			},
+			Action: projectRolesList,
+		},
+		{
+			Name: "revoke",
+			Usage: "Revoke a project role from a user, service account, group, " +
+				"or team",
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:     flagProject,
@@ -59,17 +116,29 @@ var projectRolesCommand = &cli.Command{
 					Usage:    "Revoke the specified role (required)",
 					Required: true,
 				},
+				&cli.StringFlag{
+					Name:    flagGroup,
+					Aliases: []string{"g"},
+					Usage: "Revoke the role from the specified group; mutually " +
+						"exclusive with --service-account, --team, and --user",
+				},
 				&cli.StringFlag{
 					Name:    flagServiceAccount,
 					Aliases: []string{"s"},
 					Usage: "Revoke the role from the specified service account; " +
-						"mutually exclusive with --user",
+						"mutually exclusive with --group, --team, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagTeam,
+					Aliases: []string{"t"},
+					Usage: "Revoke the role from the specified team; mutually " +
+						"exclusive with --group, --service-account, and --user",
 				},
 				&cli.StringFlag{
 					Name:    flagUser,
 					Aliases: []string{"u"},
 					Usage: "Revoke the role from the specified user; mutually " +
-						"exclusive with --service-account",
+						"exclusive with --group, --service-account, and --team",
 				},
 			},
 			Action: projectRolesRevoke,
@@ -80,18 +149,10 @@ var projectRolesCommand = &cli.Command{
 func projectRolesGrant(c *cli.Context) error {
 	projectID := c.String(flagProject)
 	role := c.String(flagRole)
-	userID := c.String(flagUser)
-	serviceAccountID := c.String(flagServiceAccount)
-
-	if userID == "" && serviceAccountID == "" {
-		return errors.New(
-			"one of --user or --service-account must be specified",
-		)
-	}
-	if userID != "" && serviceAccountID != "" {
-		return errors.New(
-			"only one of --user or --service-account must be specified",
-		)
+	principalType, principalID, readablePrincipalType, err :=
+		roleAssignmentPrincipal(c)
+	if err != nil {
+		return err
 	}
 
 	client, err := getClient(c)
@@ -100,18 +161,9 @@ func projectRolesGrant(c *cli.Context) error {
 	}
 
 	roleAssignment := authx.RoleAssignment{
-		Role: authx.RoleName(role),
-	}
-
-	var readablePrincipalType string
-	if userID != "" {
-		readablePrincipalType = "user"
-		roleAssignment.PrincipalType = authx.PrincipalTypeUser
-		roleAssignment.PrincipalID = userID
-	} else {
-		readablePrincipalType = "service account"
-		roleAssignment.PrincipalType = authx.PrincipalTypeServiceAccount
-		roleAssignment.PrincipalID = serviceAccountID
+		Role:          authx.RoleName(role),
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
 	}
 
 	if err := client.Core().Projects().Roles().Grant(
@@ -133,21 +185,21 @@ func projectRolesGrant(c *cli.Context) error {
 	return nil
 }
 
+func projectRolesList(c *cli.Context) error {
+	return listRoleAssignments(
+		c,
+		fmt.Sprintf("v2/projects/%s/role-assignments", c.String(flagProject)),
+		true,
+	)
+}
+
 func projectRolesRevoke(c *cli.Context) error {
 	projectID := c.String(flagProject)
 	role := c.String(flagRole)
-	userID := c.String(flagUser)
-	serviceAccountID := c.String(flagServiceAccount)
-
-	if userID == "" && serviceAccountID == "" {
-		return errors.New(
-			"one of --user or --service-account must be specified",
-		)
-	}
-	if userID != "" && serviceAccountID != "" {
-		return errors.New(
-			"only one of --user or --service-account must be specified",
-		)
+	principalType, principalID, readablePrincipalType, err :=
+		roleAssignmentPrincipal(c)
+	if err != nil {
+		return err
 	}
 
 	client, err := getClient(c)
@@ -156,18 +208,9 @@ func projectRolesRevoke(c *cli.Context) error {
 	}
 
 	roleAssignment := authx.RoleAssignment{
-		Role: authx.RoleName(role),
-	}
-
-	var readablePrincipalType string
-	if userID != "" {
-		readablePrincipalType = "user"
-		roleAssignment.PrincipalType = authx.PrincipalTypeUser
-		roleAssignment.PrincipalID = userID
-	} else {
-		readablePrincipalType = "service account"
-		roleAssignment.PrincipalType = authx.PrincipalTypeServiceAccount
-		roleAssignment.PrincipalID = serviceAccountID
+		Role:          authx.RoleName(role),
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
 	}
 
 	if err := client.Core().Projects().Roles().Revoke(
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var roleCommand = &cli.Command{
+	Name:  "role",
+	Usage: "Manage project role definitions",
+	Description: "Project roles are bundles of permissions. Besides the " +
+		"built-in PROJECT_ADMIN, PROJECT_DEVELOPER, and PROJECT_USER roles, " +
+		"administrators may define custom project roles, which can then be " +
+		"granted for any project.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "Define a new custom project role",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "The name of the new role, e.g. EVENT_MANAGER (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagDescription,
+					Aliases: []string{"d"},
+					Usage:   "Describe the role's purpose",
+				},
+				&cli.StringSliceFlag{
+					Name: flagPermission,
+					Usage: "Grant the specified permission, e.g. events:create; may " +
+						"be specified multiple times (at least one is required)",
+					Required: true,
+				},
+			},
+			Action: roleCreate,
+		},
+		{
+			Name:  "delete",
+			Usage: "Delete a custom project role",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Delete the specified role (required)",
+					Required: true,
+				},
+				&cli.BoolFlag{
+					Name:    flagYes,
+					Aliases: []string{"y"},
+					Usage:   "Non-interactively confirm deletion",
+				},
+			},
+			Action: roleDelete,
+		},
+		{
+			Name:  "get",
+			Usage: "Retrieve a project role definition",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Retrieve the specified role (required)",
+					Required: true,
+				},
+				cliFlagOutput,
+			},
+			Action: roleGet,
+		},
+		{
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "List built-in and custom project role definitions",
+			Flags: []cli.Flag{
+				cliFlagOutput,
+			},
+			Action: roleList,
+		},
+		{
+			Name:  "update",
+			Usage: "Replace the description and permissions of a custom project role",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Update the specified role (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagDescription,
+					Aliases: []string{"d"},
+					Usage:   "Describe the role's purpose",
+				},
+				&cli.StringSliceFlag{
+					Name: flagPermission,
+					Usage: "Grant the specified permission, e.g. events:create; may " +
+						"be specified multiple times (at least one is required)",
+					Required: true,
+				},
+			},
+			Action: roleUpdate,
+		},
+	},
+}
+
+// roleDefinition represents a RoleDefinition, which the SDK does not yet
+// support.
+type roleDefinition struct {
+	meta.ObjectMeta `json:"metadata"`
+	Description     string   `json:"description,omitempty"`
+	Permissions     []string `json:"permissions"`
+	BuiltIn         bool     `json:"builtIn,omitempty"`
+}
+
+// MarshalJSON amends roleDefinition instances with type metadata.
+func (r roleDefinition) MarshalJSON() ([]byte, error) {
+	type Alias roleDefinition
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "RoleDefinition",
+			},
+			Alias: (Alias)(r),
+		},
+	)
+}
+
+// roleDefinitionList is an ordered list of role definitions.
+type roleDefinitionList struct {
+	meta.ListMeta `json:"metadata"`
+	Items         []roleDefinition `json:"items,omitempty"`
+}
+
+// MarshalJSON amends roleDefinitionList instances with type metadata.
+func (r roleDefinitionList) MarshalJSON() ([]byte, error) {
+	type Alias roleDefinitionList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "RoleDefinitionList",
+			},
+			Alias: (Alias)(r),
+		},
+	)
+}
+
+// roleDefinitionRequest returns a request body for creating or updating a
+// custom project role using the values of the --id, --description, and
+// --permission flags.
+func roleDefinitionRequest(c *cli.Context) interface{} {
+	type metadata struct {
+		ID string `json:"id"`
+	}
+	return struct {
+		meta.TypeMeta `json:",inline"`
+		Metadata      metadata `json:"metadata"`
+		Description   string   `json:"description,omitempty"`
+		Permissions   []string `json:"permissions"`
+	}{
+		TypeMeta: meta.TypeMeta{
+			APIVersion: meta.APIVersion,
+			Kind:       "RoleDefinition",
+		},
+		Metadata: metadata{
+			ID: c.String(flagID),
+		},
+		Description: c.String(flagDescription),
+		Permissions: c.StringSlice(flagPermission),
+	}
+}
+
+func roleCreate(c *cli.Context) error {
+	id := c.String(flagID)
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method:      http.MethodPost,
+			Path:        "v2/role-definitions",
+			ReqBodyObj:  roleDefinitionRequest(c),
+			SuccessCode: http.StatusCreated,
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Project role %q defined.\n", id)
+
+	return nil
+}
+
+func roleList(c *cli.Context) error {
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	roleDefinitions := roleDefinitionList{}
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method:  http.MethodGet,
+			Path:    "v2/role-definitions",
+			RespObj: &roleDefinitions,
+		},
+	); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(output) {
+	case "table":
+		table := uitable.New()
+		table.AddRow("NAME", "BUILT-IN?", "PERMISSIONS", "DESCRIPTION")
+		for _, roleDefinition := range roleDefinitions.Items {
+			table.AddRow(
+				roleDefinition.ID,
+				roleDefinition.BuiltIn,
+				strings.Join(roleDefinition.Permissions, ","),
+				roleDefinition.Description,
+			)
+		}
+		fmt.Println(table)
+
+	case "yaml":
+		yamlBytes, err := yaml.Marshal(roleDefinitions)
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from list role definitions operation",
+			)
+		}
+		fmt.Println(string(yamlBytes))
+
+	case "json":
+		prettyJSON, err := json.MarshalIndent(roleDefinitions, "", "  ")
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from list role definitions operation",
+			)
+		}
+		fmt.Println(string(prettyJSON))
+	}
+
+	return nil
+}
+
+func roleGet(c *cli.Context) error {
+	id := c.String(flagID)
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	roleDefinition := roleDefinition{}
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method:  http.MethodGet,
+			Path:    fmt.Sprintf("v2/role-definitions/%s", id),
+			RespObj: &roleDefinition,
+		},
+	); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(output) {
+	case "table":
+		table := uitable.New()
+		table.AddRow("NAME", "BUILT-IN?", "PERMISSIONS", "DESCRIPTION")
+		table.AddRow(
+			roleDefinition.ID,
+			roleDefinition.BuiltIn,
+			strings.Join(roleDefinition.Permissions, ","),
+			roleDefinition.Description,
+		)
+		fmt.Println(table)
+
+	case "yaml":
+		yamlBytes, err := yaml.Marshal(roleDefinition)
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from get role definition operation",
+			)
+		}
+		fmt.Println(string(yamlBytes))
+
+	case "json":
+		prettyJSON, err := json.MarshalIndent(roleDefinition, "", "  ")
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from get role definition operation",
+			)
+		}
+		fmt.Println(string(prettyJSON))
+	}
+
+	return nil
+}
+
+func roleUpdate(c *cli.Context) error {
+	id := c.String(flagID)
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method:     http.MethodPut,
+			Path:       fmt.Sprintf("v2/role-definitions/%s", id),
+			ReqBodyObj: roleDefinitionRequest(c),
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Project role %q updated.\n", id)
+
+	return nil
+}
+
+func roleDelete(c *cli.Context) error {
+	id := c.String(flagID)
+
+	confirmed, err := confirmed(c)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("v2/role-definitions/%s", id),
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Project role %q deleted.\n", id)
+
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -41,6 +42,11 @@ var serviceAccountCommand = &cli.Command{
 						"description (required)",
 					Required: true,
 				},
+				&cli.StringFlag{
+					Name: flagExpires,
+					Usage: "Expire the service account's token at the specified " +
+						"time; accepts a duration (e.g. 720h) or an RFC3339 timestamp",
+				},
 			},
 			Action: serviceAccountCreate,
 		},
@@ -80,6 +86,31 @@ var serviceAccountCommand = &cli.Command{
 			},
 			Action: serviceAccountLock,
 		},
+		{
+			Name:  "rotate-token",
+			Usage: "Issue a new token for a service account",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    flagID,
+					Aliases: []string{"i"},
+					Usage: "Issue a new token for the specified service account " +
+						"(required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name: flagExpires,
+					Usage: "Expire the new token at the specified time; accepts a " +
+						"duration (e.g. 720h) or an RFC3339 timestamp",
+				},
+				&cli.StringFlag{
+					Name: flagGracePeriod,
+					Usage: "Keep the existing token valid for the specified " +
+						"duration (e.g. 24h); by default, the existing token is " +
+						"invalidated immediately",
+				},
+			},
+			Action: serviceAccountRotateToken,
+		},
 		{
 			Name:  "unlock",
 			Usage: "Restore a service account's access to Brigade",
@@ -96,25 +127,106 @@ var serviceAccountCommand = &cli.Command{
 	},
 }
 
-func serviceAccountCreate(c *cli.Context) error {
-	description := c.String(flagDescription)
-	id := c.String(flagID)
+// serviceAccountWithTokenInfo is an authx.ServiceAccount amended with details
+// of the ServiceAccount's token that the SDK does not yet support.
+type serviceAccountWithTokenInfo struct {
+	authx.ServiceAccount `json:",inline"`
+	// TokenCreated indicates when the ServiceAccount's current token was issued.
+	TokenCreated *time.Time `json:"tokenCreated,omitempty"`
+	// TokenExpires indicates when the ServiceAccount's current token expires. If
+	// this field's value is nil, the token never expires.
+	TokenExpires *time.Time `json:"tokenExpires,omitempty"`
+	// PreviousTokenExpires indicates when the token that the ServiceAccount's
+	// current token replaced expires.
+	PreviousTokenExpires *time.Time `json:"previousTokenExpires,omitempty"`
+}
 
-	client, err := getClient(c)
+// MarshalJSON amends the JSON representation of the embedded
+// authx.ServiceAccount with the ServiceAccount's token details. Without this,
+// the embedded authx.ServiceAccount's MarshalJSON method would be promoted and
+// those details would be lost.
+func (s serviceAccountWithTokenInfo) MarshalJSON() ([]byte, error) {
+	serviceAccountBytes, err := json.Marshal(s.ServiceAccount)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	fields := map[string]interface{}{}
+	if err = json.Unmarshal(serviceAccountBytes, &fields); err != nil {
+		return nil, err
+	}
+	if s.TokenCreated != nil {
+		fields["tokenCreated"] = s.TokenCreated
+	}
+	if s.TokenExpires != nil {
+		fields["tokenExpires"] = s.TokenExpires
+	}
+	if s.PreviousTokenExpires != nil {
+		fields["previousTokenExpires"] = s.PreviousTokenExpires
+	}
+	return json.Marshal(fields)
+}
 
-	token, err := client.Authx().ServiceAccounts().Create(
-		c.Context,
-		authx.ServiceAccount{
-			ObjectMeta: meta.ObjectMeta{
-				ID: id,
+// serviceAccountWithTokenInfoList is an authx.ServiceAccountList whose items
+// are amended with details of each ServiceAccount's token.
+type serviceAccountWithTokenInfoList struct {
+	meta.ListMeta `json:"metadata"`
+	Items         []serviceAccountWithTokenInfo `json:"items,omitempty"`
+}
+
+// MarshalJSON amends serviceAccountWithTokenInfoList instances with type
+// metadata.
+func (s serviceAccountWithTokenInfoList) MarshalJSON() ([]byte, error) {
+	type Alias serviceAccountWithTokenInfoList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "ServiceAccountList",
 			},
-			Description: description,
+			Alias: (Alias)(s),
 		},
 	)
+}
+
+// formatTokenTime formats the provided token creation or expiry time for
+// tabular output, substituting the provided default for a nil time.
+func formatTokenTime(t *time.Time, defaultStr string) string {
+	if t == nil {
+		return defaultStr
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func serviceAccountCreate(c *cli.Context) error {
+	description := c.String(flagDescription)
+	id := c.String(flagID)
+	tokenExpires, err := parseFutureTimeFlag(c.String(flagExpires))
 	if err != nil {
+		return errors.Wrapf(err, "error parsing --%s", flagExpires)
+	}
+
+	token := authx.Token{}
+	if err = executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   "v2/service-accounts",
+			ReqBodyObj: serviceAccountWithTokenInfo{
+				ServiceAccount: authx.ServiceAccount{
+					ObjectMeta: meta.ObjectMeta{
+						ID: id,
+					},
+					Description: description,
+				},
+				TokenExpires: tokenExpires,
+			},
+			SuccessCode: http.StatusCreated,
+			RespObj:     &token,
+		},
+	); err != nil {
 		return err
 	}
 
@@ -135,20 +247,22 @@ func serviceAccountList(c *cli.Context) error {
 		return err
 	}
 
-	client, err := getClient(c)
-	if err != nil {
-		return err
-	}
-
-	opts := meta.ListOptions{}
-
+	var continueVal string
 	for {
-		serviceAccounts, err := client.Authx().ServiceAccounts().List(
-			c.Context,
-			nil,
-			&opts,
-		)
-		if err != nil {
+		serviceAccounts := serviceAccountWithTokenInfoList{}
+		queryParams := map[string]string{}
+		if continueVal != "" {
+			queryParams["continue"] = continueVal
+		}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodGet,
+				Path:        "v2/service-accounts",
+				QueryParams: queryParams,
+				RespObj:     &serviceAccounts,
+			},
+		); err != nil {
 			return err
 		}
 
@@ -160,13 +274,22 @@ func serviceAccountList(c *cli.Context) error {
 		switch strings.ToLower(output) {
 		case "table":
 			table := uitable.New()
-			table.AddRow("ID", "DESCRIPTION", "AGE", "LOCKED?")
-			for _, serviceAccounts := range serviceAccounts.Items {
+			table.AddRow(
+				"ID",
+				"DESCRIPTION",
+				"AGE",
+				"LOCKED?",
+				"TOKEN CREATED",
+				"TOKEN EXPIRES",
+			)
+			for _, serviceAccount := range serviceAccounts.Items {
 				table.AddRow(
-					serviceAccounts.ID,
-					serviceAccounts.Description,
-					duration.ShortHumanDuration(time.Since(*serviceAccounts.Created)),
-					serviceAccounts.Locked != nil,
+					serviceAccount.ID,
+					serviceAccount.Description,
+					duration.ShortHumanDuration(time.Since(*serviceAccount.Created)),
+					serviceAccount.Locked != nil,
+					formatTokenTime(serviceAccount.TokenCreated, ""),
+					formatTokenTime(serviceAccount.TokenExpires, "never"),
 				)
 			}
 			fmt.Println(table)
@@ -209,7 +332,7 @@ func serviceAccountList(c *cli.Context) error {
 			break
 		}
 
-		opts.Continue = serviceAccounts.Continue
+		continueVal = serviceAccounts.Continue
 	}
 
 	return nil
@@ -223,20 +346,29 @@ func serviceAccountGet(c *cli.Context) error {
 		return err
 	}
 
-	client, err := getClient(c)
-	if err != nil {
-		return err
-	}
-
-	serviceAccount, err := client.Authx().ServiceAccounts().Get(c.Context, id)
-	if err != nil {
+	serviceAccount := serviceAccountWithTokenInfo{}
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method:  http.MethodGet,
+			Path:    fmt.Sprintf("v2/service-accounts/%s", id),
+			RespObj: &serviceAccount,
+		},
+	); err != nil {
 		return err
 	}
 
 	switch strings.ToLower(output) {
 	case "table":
 		table := uitable.New()
-		table.AddRow("ID", "DESCRIPTION", "AGE", "LOCKED?")
+		table.AddRow(
+			"ID",
+			"DESCRIPTION",
+			"AGE",
+			"LOCKED?",
+			"TOKEN CREATED",
+			"TOKEN EXPIRES",
+		)
 		var age string
 		if serviceAccount.Created != nil {
 			age = duration.ShortHumanDuration(time.Since(*serviceAccount.Created))
@@ -246,6 +378,8 @@ func serviceAccountGet(c *cli.Context) error {
 			serviceAccount.Description,
 			age,
 			serviceAccount.Locked != nil,
+			formatTokenTime(serviceAccount.TokenCreated, ""),
+			formatTokenTime(serviceAccount.TokenExpires, "never"),
 		)
 		fmt.Println(table)
 
@@ -315,3 +449,60 @@ func serviceAccountUnlock(c *cli.Context) error {
 
 	return nil
 }
+
+func serviceAccountRotateToken(c *cli.Context) error {
+	id := c.String(flagID)
+	gracePeriod := c.String(flagGracePeriod)
+	if gracePeriod != "" {
+		if _, err := time.ParseDuration(gracePeriod); err != nil {
+			return errors.Wrapf(err, "error parsing --%s", flagGracePeriod)
+		}
+	}
+	expires, err := parseFutureTimeFlag(c.String(flagExpires))
+	if err != nil {
+		return errors.Wrapf(err, "error parsing --%s", flagExpires)
+	}
+
+	token := authx.Token{}
+	if err = executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   fmt.Sprintf("v2/service-accounts/%s/token-rotations", id),
+			ReqBodyObj: struct {
+				meta.TypeMeta `json:",inline"`
+				GracePeriod   string     `json:"gracePeriod,omitempty"`
+				Expires       *time.Time `json:"expires,omitempty"`
+			}{
+				TypeMeta: meta.TypeMeta{
+					APIVersion: meta.APIVersion,
+					Kind:       "TokenRotationOptions",
+				},
+				GracePeriod: gracePeriod,
+				Expires:     expires,
+			},
+			SuccessCode: http.StatusCreated,
+			RespObj:     &token,
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nA new token has been issued for service account %q:\n", id)
+	fmt.Printf("\n\t%s\n", token.Value)
+	if gracePeriod != "" {
+		fmt.Printf(
+			"\nThe previous token remains valid for %s. Replace it everywhere "+
+				"it is used before then.\n",
+			gracePeriod,
+		)
+	} else {
+		fmt.Println("\nThe previous token is no longer valid.")
+	}
+	fmt.Println(
+		"\nStore this token someplace secure NOW. It cannot be retrieved " +
+			"later through any other means.",
+	)
+
+	return nil
+}
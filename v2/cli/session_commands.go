@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var sessionCommand = &cli.Command{
+	Name:  "session",
+	Usage: "Manage sessions",
+	Subcommands: []*cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "List active sessions",
+			Description: "By default, lists your own sessions. Listing other " +
+				"users' sessions requires the ADMIN role.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    flagAll,
+					Aliases: []string{"a"},
+					Usage:   "List the sessions of all users",
+				},
+				cliFlagOutput,
+				&cli.StringFlag{
+					Name:    flagUser,
+					Aliases: []string{"u"},
+					Usage:   "List the sessions of the specified user",
+				},
+			},
+			Action: sessionList,
+		},
+		{
+			Name:  "refresh",
+			Usage: "Exchange your refresh token for a new session token",
+			Description: "Extends your current session without logging in " +
+				"again, provided its refresh token has not expired.",
+			Action: sessionRefresh,
+		},
+		{
+			Name:  "revoke",
+			Usage: "Revoke a session",
+			Description: "Revoking other users' sessions requires the ADMIN " +
+				"role.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Revoke the specified session (required)",
+					Required: true,
+				},
+			},
+			Action: sessionRevoke,
+		},
+	},
+}
+
+// sessionToken is an authx.Token amended with the refresh token that the API
+// server issues alongside session tokens, which the SDK does not yet support.
+type sessionToken struct {
+	Value        string `json:"value"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// oidcAuthDetails is an authx.OIDCAuthDetails amended with the refresh token
+// that the API server issues alongside session tokens, which the SDK does not
+// yet support.
+type oidcAuthDetails struct {
+	AuthURL      string `json:"authURL"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// sessionInfo represents a Session, which the SDK does not yet support.
+type sessionInfo struct {
+	meta.ObjectMeta     `json:"metadata"`
+	Root                bool       `json:"root"`
+	UserID              string     `json:"userID"`
+	Authenticated       *time.Time `json:"authenticated"`
+	Expires             *time.Time `json:"expires"`
+	RefreshTokenExpires *time.Time `json:"refreshTokenExpires,omitempty"`
+	Current             bool       `json:"current,omitempty"`
+}
+
+// MarshalJSON amends sessionInfo instances with type metadata.
+func (s sessionInfo) MarshalJSON() ([]byte, error) {
+	type Alias sessionInfo
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "Session",
+			},
+			Alias: (Alias)(s),
+		},
+	)
+}
+
+// sessionInfoList is an ordered and pageable list of sessions.
+type sessionInfoList struct {
+	meta.ListMeta `json:"metadata"`
+	Items         []sessionInfo `json:"items,omitempty"`
+}
+
+// MarshalJSON amends sessionInfoList instances with type metadata.
+func (s sessionInfoList) MarshalJSON() ([]byte, error) {
+	type Alias sessionInfoList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "SessionList",
+			},
+			Alias: (Alias)(s),
+		},
+	)
+}
+
+func sessionList(c *cli.Context) error {
+	all := c.Bool(flagAll)
+	output := c.String(flagOutput)
+	userID := c.String(flagUser)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	var continueVal string
+	for {
+		sessions := sessionInfoList{}
+		queryParams := map[string]string{
+			"all": strconv.FormatBool(all),
+		}
+		if userID != "" {
+			queryParams["userID"] = userID
+		}
+		if continueVal != "" {
+			queryParams["continue"] = continueVal
+		}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodGet,
+				Path:        "v2/sessions",
+				QueryParams: queryParams,
+				RespObj:     &sessions,
+			},
+		); err != nil {
+			return err
+		}
+
+		if len(sessions.Items) == 0 {
+			fmt.Println("No sessions found.")
+			return nil
+		}
+
+		switch strings.ToLower(output) {
+		case "table":
+			table := uitable.New()
+			table.AddRow(
+				"ID",
+				"USER",
+				"AGE",
+				"EXPIRES",
+				"REFRESHABLE UNTIL",
+				"CURRENT?",
+			)
+			for _, session := range sessions.Items {
+				user := session.UserID
+				if session.Root {
+					user = "root"
+				}
+				var age string
+				if session.Authenticated != nil {
+					age = duration.ShortHumanDuration(
+						time.Since(*session.Authenticated),
+					)
+				}
+				table.AddRow(
+					session.ID,
+					user,
+					age,
+					formatTokenTime(session.Expires, ""),
+					formatTokenTime(session.RefreshTokenExpires, ""),
+					session.Current,
+				)
+			}
+			fmt.Println(table)
+
+		case "yaml":
+			yamlBytes, err := yaml.Marshal(sessions)
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list sessions operation",
+				)
+			}
+			fmt.Println(string(yamlBytes))
+
+		case "json":
+			prettyJSON, err := json.MarshalIndent(sessions, "", "  ")
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list sessions operation",
+				)
+			}
+			fmt.Println(string(prettyJSON))
+		}
+
+		if sessions.RemainingItemCount < 1 || sessions.Continue == "" {
+			break
+		}
+
+		// Exit after one page of output if this isn't a terminal
+		if !terminal.IsTerminal(int(os.Stdout.Fd())) {
+			break
+		}
+
+		if shouldContinue, err :=
+			shouldContinue(sessions.RemainingItemCount); err != nil {
+			return err
+		} else if !shouldContinue {
+			break
+		}
+
+		continueVal = sessions.Continue
+	}
+
+	return nil
+}
+
+func sessionRefresh(c *cli.Context) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.APIRefreshToken == "" {
+		return errors.New(
+			"no refresh token was found; please use `brig login` to continue",
+		)
+	}
+
+	token := sessionToken{}
+	if err = executeAPIRequest(
+		c,
+		apiRequest{
+			// Specifying the address explicitly keeps the (possibly expired)
+			// session token out of the request
+			APIAddress: cfg.APIAddress,
+			Method:     http.MethodPost,
+			Path:       "v2/session/refresh",
+			ReqBodyObj: struct {
+				meta.TypeMeta `json:",inline"`
+				RefreshToken  string `json:"refreshToken"`
+			}{
+				TypeMeta: meta.TypeMeta{
+					APIVersion: meta.APIVersion,
+					Kind:       "SessionRefreshRequest",
+				},
+				RefreshToken: cfg.APIRefreshToken,
+			},
+			RespObj: &token,
+		},
+	); err != nil {
+		return err
+	}
+
+	cfg.APIToken = token.Value
+	cfg.APIRefreshToken = token.RefreshToken
+	if err = saveConfig(cfg); err != nil {
+		return errors.Wrap(err, "error persisting configuration")
+	}
+
+	fmt.Println("Your session was refreshed.")
+
+	return nil
+}
+
+func sessionRevoke(c *cli.Context) error {
+	id := c.String(flagID)
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("v2/sessions/%s", id),
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Session %q revoked.\n", id)
+
+	return nil
+}
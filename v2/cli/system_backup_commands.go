@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var systemBackupCommand = &cli.Command{
+	Name: "backup",
+	Usage: "Back up projects, users, personal access tokens, user " +
+		"invitations, service accounts, teams, custom roles, role assignments " +
+		"and, optionally, project secrets",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    flagFile,
+			Aliases: []string{"f"},
+			Usage: "Write the backup to the specified file; if not specified, the " +
+				"backup is written to stdout",
+		},
+		&cli.BoolFlag{
+			Name:  flagIncludeSecrets,
+			Usage: "Include project secrets, encrypted using a passphrase",
+		},
+		&cli.StringFlag{
+			Name: flagPassphrase,
+			Usage: "Specify the passphrase for non-interactive encryption of " +
+				"project secrets; only applicable when --include-secrets is used",
+		},
+	},
+	Action: systemBackup,
+}
+
+var systemRestoreCommand = &cli.Command{
+	Name: "restore",
+	Usage: "Restore projects, users, personal access tokens, user " +
+		"invitations, service accounts, teams, custom roles, role assignments " +
+		"and project secrets from a backup; anything that already exists is " +
+		"left untouched",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     flagFile,
+			Aliases:  []string{"f"},
+			Usage:    "Restore from the specified backup file (required)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name: flagPassphrase,
+			Usage: "Specify the passphrase for non-interactive decryption of " +
+				"project secrets; only applicable if the backup includes secrets",
+		},
+	},
+	Action: systemRestore,
+}
+
+// restoreResult mirrors the API server's RestoreResult type, which the SDK
+// does not (yet) expose.
+type restoreResult struct {
+	Projects             restoreCounts `json:"projects"`
+	Users                restoreCounts `json:"users"`
+	PersonalAccessTokens restoreCounts `json:"personalAccessTokens"`
+	UserInvitations      restoreCounts `json:"userInvitations"`
+	ServiceAccounts      restoreCounts `json:"serviceAccounts"`
+	Teams                restoreCounts `json:"teams"`
+	RoleDefinitions      restoreCounts `json:"roleDefinitions"`
+	RoleAssignments      int           `json:"roleAssignments"`
+	Secrets              int           `json:"secrets"`
+}
+
+type restoreCounts struct {
+	Created  int `json:"created"`
+	Existing int `json:"existing"`
+}
+
+func systemBackup(c *cli.Context) error {
+	filename := c.String(flagFile)
+	includeSecrets := c.Bool(flagIncludeSecrets)
+	passphrase := c.String(flagPassphrase)
+
+	if includeSecrets && passphrase == "" {
+		var err error
+		if passphrase, err = promptForPassphrase(true); err != nil {
+			return err
+		}
+	}
+	if !includeSecrets {
+		passphrase = ""
+	}
+
+	resp, err := submitAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   "v2/system/backups",
+			ReqBodyObj: struct {
+				meta.TypeMeta     `json:",inline"`
+				SecretsPassphrase string `json:"secretsPassphrase,omitempty"`
+			}{
+				TypeMeta: meta.TypeMeta{
+					APIVersion: meta.APIVersion,
+					Kind:       "BackupOptions",
+				},
+				SecretsPassphrase: passphrase,
+			},
+			SuccessCode: http.StatusOK,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The backup is written verbatim so that nothing is lost to the CLI's
+	// (possibly outdated) understanding of its format.
+	if filename == "" {
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return errors.Wrap(err, "error writing backup")
+	}
+	// The backup contains hashed tokens and possibly encrypted secrets, so
+	// access to the file is restricted to its owner.
+	file, err :=
+		os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "error opening backup file %s", filename)
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, resp.Body); err != nil {
+		return errors.Wrapf(err, "error writing backup file %s", filename)
+	}
+
+	fmt.Printf("Wrote backup to %s.\n", filename)
+	if !includeSecrets {
+		fmt.Println("Project secrets were not included.")
+	}
+
+	return nil
+}
+
+func systemRestore(c *cli.Context) error {
+	filename := c.String(flagFile)
+	passphrase := c.String(flagPassphrase)
+
+	backupBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Wrapf(err, "error reading backup file %s", filename)
+	}
+	// We unmarshal only to determine whether the backup includes secrets. The
+	// backup itself is sent to the API verbatim.
+	backup := struct {
+		Secrets []byte `json:"secrets"`
+	}{}
+	if err = json.Unmarshal(backupBytes, &backup); err != nil {
+		return errors.Wrapf(err, "error unmarshaling backup file %s", filename)
+	}
+	if len(backup.Secrets) == 0 {
+		passphrase = ""
+	} else if passphrase == "" {
+		if passphrase, err = promptForPassphrase(false); err != nil {
+			return err
+		}
+	}
+
+	result := restoreResult{}
+	if err = executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   "v2/system/restores",
+			ReqBodyObj: struct {
+				meta.TypeMeta     `json:",inline"`
+				Backup            json.RawMessage `json:"backup"`
+				SecretsPassphrase string          `json:"secretsPassphrase,omitempty"`
+			}{
+				TypeMeta: meta.TypeMeta{
+					APIVersion: meta.APIVersion,
+					Kind:       "RestoreRequest",
+				},
+				Backup:            backupBytes,
+				SecretsPassphrase: passphrase,
+			},
+			SuccessCode: http.StatusOK,
+			RespObj:     &result,
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored from backup %s.\n\n", filename)
+	fmt.Printf(
+		"Projects:         %d created, %d already existed\n",
+		result.Projects.Created,
+		result.Projects.Existing,
+	)
+	fmt.Printf(
+		"Users:            %d created, %d already existed\n",
+		result.Users.Created,
+		result.Users.Existing,
+	)
+	fmt.Printf(
+		"Access tokens:    %d created, %d already existed\n",
+		result.PersonalAccessTokens.Created,
+		result.PersonalAccessTokens.Existing,
+	)
+	fmt.Printf(
+		"Invitations:      %d created, %d already existed\n",
+		result.UserInvitations.Created,
+		result.UserInvitations.Existing,
+	)
+	fmt.Printf(
+		"Service accounts: %d created, %d already existed\n",
+		result.ServiceAccounts.Created,
+		result.ServiceAccounts.Existing,
+	)
+	fmt.Printf(
+		"Teams:            %d created, %d already existed\n",
+		result.Teams.Created,
+		result.Teams.Existing,
+	)
+	fmt.Printf(
+		"Role definitions: %d created, %d already existed\n",
+		result.RoleDefinitions.Created,
+		result.RoleDefinitions.Existing,
+	)
+	fmt.Printf("Role assignments: %d applied\n", result.RoleAssignments)
+	fmt.Printf("Secrets:          %d set\n\n", result.Secrets)
+
+	return nil
+}
+
+// promptForPassphrase interactively prompts for a passphrase with which to
+// encrypt or decrypt project secrets. If confirm is true, the passphrase must
+// be entered twice.
+func promptForPassphrase(confirm bool) (string, error) {
+	var passphrase string
+	if err := survey.AskOne(
+		&survey.Password{
+			Message: "Passphrase for project secrets",
+		},
+		&passphrase,
+		survey.WithValidator(survey.Required),
+	); err != nil {
+		return "", err
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+	var confirmation string
+	if err := survey.AskOne(
+		&survey.Password{
+			Message: "Confirm passphrase",
+		},
+		&confirmation,
+	); err != nil {
+		return "", err
+	}
+	if confirmation != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
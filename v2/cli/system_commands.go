@@ -6,6 +6,8 @@ var systemCommand = &cli.Command{
 	Name:  "system",
 	Usage: "Manage the Brigade system",
 	Subcommands: []*cli.Command{
+		systemBackupCommand,
+		systemRestoreCommand,
 		systemRolesCommand,
 	},
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/brigadecore/brigade/sdk/v2/authx"
-	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
@@ -13,8 +12,9 @@ var systemRolesCommand = &cli.Command{
 	Usage: "Manage system roles",
 	Subcommands: []*cli.Command{
 		{
-			Name:  "grant",
-			Usage: "Grant a system role to a user or service account",
+			Name: "grant",
+			Usage: "Grant a system role to a user, service account, group, or " +
+				"team",
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:     flagRole,
@@ -22,24 +22,75 @@ var systemRolesCommand = &cli.Command{
 					Usage:    "Grant the specified role (required)",
 					Required: true,
 				},
+				&cli.StringFlag{
+					Name:    flagGroup,
+					Aliases: []string{"g"},
+					Usage: "Grant the role to the specified group; mutually exclusive " +
+						"with --service-account, --team, and --user",
+				},
 				&cli.StringFlag{
 					Name:    flagServiceAccount,
 					Aliases: []string{"s"},
 					Usage: "Grant the role to the specified service account; mutually " +
-						"exclusive with --user",
+						"exclusive with --group, --team, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagTeam,
+					Aliases: []string{"t"},
+					Usage: "Grant the role to the specified team; mutually exclusive " +
+						"with --group, --service-account, and --user",
 				},
 				&cli.StringFlag{
 					Name:    flagUser,
 					Aliases: []string{"u"},
 					Usage: "Grant the role to the specified user; mutually exclusive " +
-						"with --servcice-account",
+						"with --group, --service-account, and --team",
 				},
 			},
 			Action: systemRolesGrant,
 		},
 		{
-			Name:  "revoke",
-			Usage: "Revoke a system role from a user or service account",
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "List system role assignments",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    flagGroup,
+					Aliases: []string{"g"},
+					Usage: "List only assignments to the specified group; mutually " +
+						"exclusive with --service-account, --team, and --user",
+				},
+				cliFlagOutput,
+				&cli.StringFlag{
+					Name:    flagRole,
+					Aliases: []string{"r"},
+					Usage:   "List only assignments of the specified role",
+				},
+				&cli.StringFlag{
+					Name:    flagServiceAccount,
+					Aliases: []string{"s"},
+					Usage: "List only assignments to the specified service account; " +
+						"mutually exclusive with --group, --team, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagTeam,
+					Aliases: []string{"t"},
+					Usage: "List only assignments to the specified team; mutually " +
+						"exclusive with --group, --service-account, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagUser,
+					Aliases: []string{"u"},
+					Usage: "List only assignments to the specified user; mutually " +
+						"exclusive with --group, --service-account, and --team",
+				},
+			},
+			Action: systemRolesList,
+		},
+		{
+			Name: "revoke",
+			Usage: "Revoke a system role from a user, service account, group, " +
+				"or team",
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:     flagRole,
@@ -47,17 +98,29 @@ var systemRolesCommand = &cli.Command{
 					Usage:    "Revoke the specified role (required)",
 					Required: true,
 				},
+				&cli.StringFlag{
+					Name:    flagGroup,
+					Aliases: []string{"g"},
+					Usage: "Revoke the role from the specified group; mutually " +
+						"exclusive with --service-account, --team, and --user",
+				},
 				&cli.StringFlag{
 					Name:    flagServiceAccount,
 					Aliases: []string{"s"},
 					Usage: "Revoke the role from the specified service account; " +
-						"mutually exclusive with --user",
+						"mutually exclusive with --group, --team, and --user",
+				},
+				&cli.StringFlag{
+					Name:    flagTeam,
+					Aliases: []string{"t"},
+					Usage: "Revoke the role from the specified team; mutually " +
+						"exclusive with --group, --service-account, and --user",
 				},
 				&cli.StringFlag{
 					Name:    flagUser,
 					Aliases: []string{"u"},
 					Usage: "Revoke the role from the specified user; mutually " +
-						"exclusive with --service-account",
+						"exclusive with --group, --service-account, and --team",
 				},
 			},
 			Action: systemRolesRevoke,
@@ -67,18 +130,10 @@ var systemRolesCommand = &cli.Command{
 
 func systemRolesGrant(c *cli.Context) error {
 	role := c.String(flagRole)
-	userID := c.String(flagUser)
-	serviceAccountID := c.String(flagServiceAccount)
-
-	if userID == "" && serviceAccountID == "" {
-		return errors.New(
-			"one of --user or --service-account must be specified",
-		)
-	}
-	if userID != "" && serviceAccountID != "" {
-		return errors.New(
-			"only one of --user or --service-account must be specified",
-		)
+	principalType, principalID, readablePrincipalType, err :=
+		roleAssignmentPrincipal(c)
+	if err != nil {
+		return err
 	}
 
 	client, err := getClient(c)
@@ -87,18 +142,9 @@ func systemRolesGrant(c *cli.Context) error {
 	}
 
 	roleAssignment := authx.RoleAssignment{
-		Role: authx.RoleName(role),
-	}
-
-	var readablePrincipalType string
-	if userID != "" {
-		readablePrincipalType = "user"
-		roleAssignment.PrincipalType = authx.PrincipalTypeUser
-		roleAssignment.PrincipalID = userID
-	} else {
-		readablePrincipalType = "service account"
-		roleAssignment.PrincipalType = authx.PrincipalTypeServiceAccount
-		roleAssignment.PrincipalID = serviceAccountID
+		Role:          authx.RoleName(role),
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
 	}
 
 	if err :=
@@ -116,20 +162,16 @@ func systemRolesGrant(c *cli.Context) error {
 	return nil
 }
 
+func systemRolesList(c *cli.Context) error {
+	return listRoleAssignments(c, "v2/system/role-assignments", true)
+}
+
 func systemRolesRevoke(c *cli.Context) error {
 	role := c.String(flagRole)
-	userID := c.String(flagUser)
-	serviceAccountID := c.String(flagServiceAccount)
-
-	if userID == "" && serviceAccountID == "" {
-		return errors.New(
-			"one of --user or --service-account must be specified",
-		)
-	}
-	if userID != "" && serviceAccountID != "" {
-		return errors.New(
-			"only one of --user or --service-account must be specified",
-		)
+	principalType, principalID, readablePrincipalType, err :=
+		roleAssignmentPrincipal(c)
+	if err != nil {
+		return err
 	}
 
 	client, err := getClient(c)
@@ -138,18 +180,9 @@ func systemRolesRevoke(c *cli.Context) error {
 	}
 
 	roleAssignment := authx.RoleAssignment{
-		Role: authx.RoleName(role),
-	}
-
-	var readablePrincipalType string
-	if userID != "" {
-		readablePrincipalType = "user"
-		roleAssignment.PrincipalType = authx.PrincipalTypeUser
-		roleAssignment.PrincipalID = userID
-	} else {
-		readablePrincipalType = "service account"
-		roleAssignment.PrincipalType = authx.PrincipalTypeServiceAccount
-		roleAssignment.PrincipalID = serviceAccountID
+		Role:          authx.RoleName(role),
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
 	}
 
 	if err :=
@@ -161,7 +194,7 @@ func systemRolesRevoke(c *cli.Context) error {
 		"Revoked system role %q from %s %q.\n\n",
 		role,
 		readablePrincipalType,
-		userID,
+		roleAssignment.PrincipalID,
 	)
 
 	return nil
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v2/authx"
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var teamCommand = &cli.Command{
+	Name:  "team",
+	Usage: "Manage teams",
+	Description: "Teams are named sets of users and service accounts. Roles " +
+		"granted to a team are held by all of its members.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "Create a new team",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Create a team with the specified ID (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagDescription,
+					Aliases: []string{"d"},
+					Usage:   "Create a team with the specified description",
+				},
+			},
+			Action: teamCreate,
+		},
+		{
+			Name:  "delete",
+			Usage: "Delete a team and all roles assigned to it",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Delete the specified team (required)",
+					Required: true,
+				},
+				&cli.BoolFlag{
+					Name:    flagYes,
+					Aliases: []string{"y"},
+					Usage:   "Non-interactively confirm deletion",
+				},
+			},
+			Action: teamDelete,
+		},
+		{
+			Name:  "get",
+			Usage: "Retrieve a team",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Retrieve the specified team (required)",
+					Required: true,
+				},
+				cliFlagOutput,
+			},
+			Action: teamGet,
+		},
+		{
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "List teams",
+			Flags: []cli.Flag{
+				cliFlagOutput,
+			},
+			Action: teamList,
+		},
+		teamMemberCommand,
+	},
+}
+
+var teamMemberCommand = &cli.Command{
+	Name:  "member",
+	Usage: "Manage team membership",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "add",
+			Usage: "Add a user or service account to a team",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Add the member to the specified team (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagServiceAccount,
+					Aliases: []string{"s"},
+					Usage: "Add the specified service account; mutually exclusive " +
+						"with --user",
+				},
+				&cli.StringFlag{
+					Name:    flagUser,
+					Aliases: []string{"u"},
+					Usage: "Add the specified user; mutually exclusive with " +
+						"--service-account",
+				},
+			},
+			Action: teamMemberAdd,
+		},
+		{
+			Name:  "remove",
+			Usage: "Remove a user or service account from a team",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Remove the member from the specified team (required)",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:    flagServiceAccount,
+					Aliases: []string{"s"},
+					Usage: "Remove the specified service account; mutually " +
+						"exclusive with --user",
+				},
+				&cli.StringFlag{
+					Name:    flagUser,
+					Aliases: []string{"u"},
+					Usage: "Remove the specified user; mutually exclusive with " +
+						"--service-account",
+				},
+			},
+			Action: teamMemberRemove,
+		},
+	},
+}
+
+// teamMember references a user or service account that belongs to a team.
+type teamMember struct {
+	PrincipalType authx.PrincipalType `json:"principalType"`
+	PrincipalID   string              `json:"principalID"`
+}
+
+// team represents a Team, which the SDK does not yet support.
+type team struct {
+	meta.ObjectMeta `json:"metadata"`
+	Description     string       `json:"description,omitempty"`
+	Members         []teamMember `json:"members,omitempty"`
+}
+
+// MarshalJSON amends team instances with type metadata.
+func (t team) MarshalJSON() ([]byte, error) {
+	type Alias team
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "Team",
+			},
+			Alias: (Alias)(t),
+		},
+	)
+}
+
+// teamList is an ordered and pageable list of teams.
+type teamList struct {
+	meta.ListMeta `json:"metadata"`
+	Items         []team `json:"items,omitempty"`
+}
+
+// MarshalJSON amends teamList instances with type metadata.
+func (t teamList) MarshalJSON() ([]byte, error) {
+	type Alias teamList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "TeamList",
+			},
+			Alias: (Alias)(t),
+		},
+	)
+}
+
+func teamCreate(c *cli.Context) error {
+	id := c.String(flagID)
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   "v2/teams",
+			ReqBodyObj: team{
+				ObjectMeta: meta.ObjectMeta{
+					ID: id,
+				},
+				Description: c.String(flagDescription),
+			},
+			SuccessCode: http.StatusCreated,
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Team %q created.\n", id)
+
+	return nil
+}
+
+func teamList(c *cli.Context) error {
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	var continueVal string
+	for {
+		teams := teamList{}
+		queryParams := map[string]string{}
+		if continueVal != "" {
+			queryParams["continue"] = continueVal
+		}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodGet,
+				Path:        "v2/teams",
+				QueryParams: queryParams,
+				RespObj:     &teams,
+			},
+		); err != nil {
+			return err
+		}
+
+		if len(teams.Items) == 0 {
+			fmt.Println("No teams found.")
+			return nil
+		}
+
+		switch strings.ToLower(output) {
+		case "table":
+			table := uitable.New()
+			table.AddRow("ID", "DESCRIPTION", "MEMBERS", "AGE")
+			for _, team := range teams.Items {
+				var age string
+				if team.Created != nil {
+					age = duration.ShortHumanDuration(time.Since(*team.Created))
+				}
+				table.AddRow(team.ID, team.Description, len(team.Members), age)
+			}
+			fmt.Println(table)
+
+		case "yaml":
+			yamlBytes, err := yaml.Marshal(teams)
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list teams operation",
+				)
+			}
+			fmt.Println(string(yamlBytes))
+
+		case "json":
+			prettyJSON, err := json.MarshalIndent(teams, "", "  ")
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list teams operation",
+				)
+			}
+			fmt.Println(string(prettyJSON))
+		}
+
+		if teams.RemainingItemCount < 1 || teams.Continue == "" {
+			break
+		}
+
+		// Exit after one page of output if this isn't a terminal
+		if !terminal.IsTerminal(int(os.Stdout.Fd())) {
+			break
+		}
+
+		if shouldContinue, err :=
+			shouldContinue(teams.RemainingItemCount); err != nil {
+			return err
+		} else if !shouldContinue {
+			break
+		}
+
+		continueVal = teams.Continue
+	}
+
+	return nil
+}
+
+func teamGet(c *cli.Context) error {
+	id := c.String(flagID)
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	team := team{}
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method:  http.MethodGet,
+			Path:    fmt.Sprintf("v2/teams/%s", id),
+			RespObj: &team,
+		},
+	); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(output) {
+	case "table":
+		var age string
+		if team.Created != nil {
+			age = duration.ShortHumanDuration(time.Since(*team.Created))
+		}
+		table := uitable.New()
+		table.AddRow("ID", "DESCRIPTION", "AGE")
+		table.AddRow(team.ID, team.Description, age)
+		fmt.Println(table)
+
+		if len(team.Members) == 0 {
+			fmt.Println("\nThis team has no members.")
+			break
+		}
+		table = uitable.New()
+		table.AddRow("MEMBER TYPE", "MEMBER ID")
+		for _, member := range team.Members {
+			table.AddRow(member.PrincipalType, member.PrincipalID)
+		}
+		fmt.Printf("\n%s\n", table)
+
+	case "yaml":
+		yamlBytes, err := yaml.Marshal(team)
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from get team operation",
+			)
+		}
+		fmt.Println(string(yamlBytes))
+
+	case "json":
+		prettyJSON, err := json.MarshalIndent(team, "", "  ")
+		if err != nil {
+			return errors.Wrap(
+				err,
+				"error formatting output from get team operation",
+			)
+		}
+		fmt.Println(string(prettyJSON))
+	}
+
+	return nil
+}
+
+func teamDelete(c *cli.Context) error {
+	id := c.String(flagID)
+
+	confirmed, err := confirmed(c)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("v2/teams/%s", id),
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Team %q deleted.\n", id)
+
+	return nil
+}
+
+func teamMemberAdd(c *cli.Context) error {
+	id := c.String(flagID)
+	member, readableType, err := teamMemberPrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   fmt.Sprintf("v2/teams/%s/members", id),
+			ReqBodyObj: struct {
+				meta.TypeMeta `json:",inline"`
+				teamMember    `json:",inline"`
+			}{
+				TypeMeta: meta.TypeMeta{
+					APIVersion: meta.APIVersion,
+					Kind:       "TeamMember",
+				},
+				teamMember: member,
+			},
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"Added %s %q to team %q.\n",
+		readableType,
+		member.PrincipalID,
+		id,
+	)
+
+	return nil
+}
+
+func teamMemberRemove(c *cli.Context) error {
+	id := c.String(flagID)
+	member, readableType, err := teamMemberPrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("v2/teams/%s/members", id),
+			QueryParams: map[string]string{
+				"principalType": string(member.PrincipalType),
+				"principalID":   member.PrincipalID,
+			},
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"Removed %s %q from team %q.\n",
+		readableType,
+		member.PrincipalID,
+		id,
+	)
+
+	return nil
+}
+
+// teamMemberPrincipal returns a teamMember referencing the principal specified
+// using exactly one of the --user or --service-account flags, along with a
+// human-readable description of the principal's type.
+func teamMemberPrincipal(c *cli.Context) (teamMember, string, error) {
+	userID := c.String(flagUser)
+	serviceAccountID := c.String(flagServiceAccount)
+	switch {
+	case userID != "" && serviceAccountID != "":
+		return teamMember{}, "", errors.New(
+			"only one of --user or --service-account must be specified",
+		)
+	case userID != "":
+		return teamMember{
+			PrincipalType: authx.PrincipalTypeUser,
+			PrincipalID:   userID,
+		}, "user", nil
+	case serviceAccountID != "":
+		return teamMember{
+			PrincipalType: authx.PrincipalTypeServiceAccount,
+			PrincipalID:   serviceAccountID,
+		}, "service account", nil
+	}
+	return teamMember{}, "", errors.New(
+		"one of --user or --service-account must be specified",
+	)
+}
@@ -32,6 +32,7 @@ var userCommand = &cli.Command{
 			},
 			Action: userGet,
 		},
+		userInvitationCommand,
 		{
 			Name:    "list",
 			Aliases: []string{"ls"},
@@ -54,6 +55,7 @@ var userCommand = &cli.Command{
 			},
 			Action: userLock,
 		},
+		userTokenCommand,
 		{
 			Name:  "unlock",
 			Usage: "Restore a user's access to Brigade",
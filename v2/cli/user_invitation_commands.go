@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var userInvitationCommand = &cli.Command{
+	Name:  "invitation",
+	Usage: "Manage invitations for new users",
+	Description: "Invitations permit new users to log in when the server " +
+		"restricts which users are provisioned automatically.",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "Invite a new user",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Invite the specified email address (required)",
+					Required: true,
+				},
+			},
+			Action: userInvitationCreate,
+		},
+		{
+			Name:  "delete",
+			Usage: "Withdraw an invitation",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Withdraw the invitation for the specified email address (required)", // nolint: lll
+					Required: true,
+				},
+			},
+			Action: userInvitationDelete,
+		},
+		{
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "List outstanding invitations",
+			Flags: []cli.Flag{
+				cliFlagOutput,
+			},
+			Action: userInvitationList,
+		},
+	},
+}
+
+// invitation represents a UserInvitation, which the SDK does not yet support.
+type invitation struct {
+	meta.ObjectMeta `json:"metadata"`
+}
+
+// MarshalJSON amends invitation instances with type metadata.
+func (i invitation) MarshalJSON() ([]byte, error) {
+	type Alias invitation
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "UserInvitation",
+			},
+			Alias: (Alias)(i),
+		},
+	)
+}
+
+// invitationList is an ordered and pageable list of invitations.
+type invitationList struct {
+	meta.ListMeta `json:"metadata"`
+	Items         []invitation `json:"items,omitempty"`
+}
+
+// MarshalJSON amends invitationList instances with type metadata.
+func (i invitationList) MarshalJSON() ([]byte, error) {
+	type Alias invitationList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "UserInvitationList",
+			},
+			Alias: (Alias)(i),
+		},
+	)
+}
+
+func userInvitationCreate(c *cli.Context) error {
+	id := c.String(flagID)
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   "v2/user-invitations",
+			ReqBodyObj: struct {
+				meta.TypeMeta `json:",inline"`
+				Metadata      struct {
+					ID string `json:"id"`
+				} `json:"metadata"`
+			}{
+				TypeMeta: meta.TypeMeta{
+					APIVersion: meta.APIVersion,
+					Kind:       "UserInvitation",
+				},
+				Metadata: struct {
+					ID string `json:"id"`
+				}{
+					ID: id,
+				},
+			},
+			SuccessCode: http.StatusCreated,
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Invited %q.\n", id)
+
+	return nil
+}
+
+func userInvitationList(c *cli.Context) error {
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	var continueVal string
+	for {
+		invitations := invitationList{}
+		queryParams := map[string]string{}
+		if continueVal != "" {
+			queryParams["continue"] = continueVal
+		}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodGet,
+				Path:        "v2/user-invitations",
+				QueryParams: queryParams,
+				RespObj:     &invitations,
+			},
+		); err != nil {
+			return err
+		}
+
+		if len(invitations.Items) == 0 {
+			fmt.Println("No invitations found.")
+			return nil
+		}
+
+		switch strings.ToLower(output) {
+		case "table":
+			table := uitable.New()
+			table.AddRow("EMAIL", "AGE")
+			for _, invitation := range invitations.Items {
+				var age string
+				if invitation.Created != nil {
+					age = duration.ShortHumanDuration(time.Since(*invitation.Created))
+				}
+				table.AddRow(invitation.ID, age)
+			}
+			fmt.Println(table)
+
+		case "yaml":
+			yamlBytes, err := yaml.Marshal(invitations)
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list invitations operation",
+				)
+			}
+			fmt.Println(string(yamlBytes))
+
+		case "json":
+			prettyJSON, err := json.MarshalIndent(invitations, "", "  ")
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list invitations operation",
+				)
+			}
+			fmt.Println(string(prettyJSON))
+		}
+
+		if invitations.RemainingItemCount < 1 || invitations.Continue == "" {
+			break
+		}
+
+		// Exit after one page of output if this isn't a terminal
+		if !terminal.IsTerminal(int(os.Stdout.Fd())) {
+			break
+		}
+
+		if shouldContinue, err :=
+			shouldContinue(invitations.RemainingItemCount); err != nil {
+			return err
+		} else if !shouldContinue {
+			break
+		}
+
+		continueVal = invitations.Continue
+	}
+
+	return nil
+}
+
+func userInvitationDelete(c *cli.Context) error {
+	id := c.String(flagID)
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("v2/user-invitations/%s", id),
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Invitation for %q withdrawn.\n", id)
+
+	return nil
+}
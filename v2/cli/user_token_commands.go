@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/sdk/v2/authx"
+	"github.com/brigadecore/brigade/sdk/v2/meta"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var userTokenCommand = &cli.Command{
+	Name:  "token",
+	Usage: "Manage your personal access tokens",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "create",
+			Usage: "Create a new personal access token",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name: flagExpires,
+					Usage: "Expire the token after the specified duration (e.g. " +
+						"720h) or at the specified RFC3339 timestamp; by default, the " +
+						"token never expires",
+				},
+				&cli.StringFlag{
+					Name:     flagName,
+					Aliases:  []string{"n"},
+					Usage:    "A name that describes the token's purpose (required)",
+					Required: true,
+				},
+				&cli.StringSliceFlag{
+					Name:    flagRole,
+					Aliases: []string{"r"},
+					Usage: "Restrict the token to the specified role, given as ROLE " +
+						"or ROLE:SCOPE (e.g. PROJECT_USER:my-project); may be " +
+						"specified multiple times; by default, the token carries all " +
+						"of your roles",
+				},
+			},
+			Action: userTokenCreate,
+		},
+		{
+			Name:    "list",
+			Aliases: []string{"ls"},
+			Usage:   "List your personal access tokens",
+			Flags: []cli.Flag{
+				cliFlagOutput,
+			},
+			Action: userTokenList,
+		},
+		{
+			Name:  "revoke",
+			Usage: "Revoke one of your personal access tokens",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     flagID,
+					Aliases:  []string{"i"},
+					Usage:    "Revoke the specified token (required)",
+					Required: true,
+				},
+			},
+			Action: userTokenRevoke,
+		},
+	},
+}
+
+// personalAccessToken represents a PersonalAccessToken, which the SDK does not
+// yet support.
+type personalAccessToken struct {
+	meta.ObjectMeta `json:"metadata"`
+	Name            string       `json:"name"`
+	Expires         *time.Time   `json:"expires,omitempty"`
+	Roles           []authx.Role `json:"roles,omitempty"`
+}
+
+// MarshalJSON amends personalAccessToken instances with type metadata.
+func (p personalAccessToken) MarshalJSON() ([]byte, error) {
+	type Alias personalAccessToken
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "PersonalAccessToken",
+			},
+			Alias: (Alias)(p),
+		},
+	)
+}
+
+// personalAccessTokenList is an ordered and pageable list of personal access
+// tokens.
+type personalAccessTokenList struct {
+	meta.ListMeta `json:"metadata"`
+	Items         []personalAccessToken `json:"items,omitempty"`
+}
+
+// MarshalJSON amends personalAccessTokenList instances with type metadata.
+func (p personalAccessTokenList) MarshalJSON() ([]byte, error) {
+	type Alias personalAccessTokenList
+	return json.Marshal(
+		struct {
+			meta.TypeMeta `json:",inline"`
+			Alias         `json:",inline"`
+		}{
+			TypeMeta: meta.TypeMeta{
+				APIVersion: meta.APIVersion,
+				Kind:       "PersonalAccessTokenList",
+			},
+			Alias: (Alias)(p),
+		},
+	)
+}
+
+// parseRoleFlag parses a role given as ROLE or ROLE:SCOPE. The role's type is
+// inferred from its name.
+func parseRoleFlag(str string) authx.Role {
+	role := authx.Role{
+		Type: "SYSTEM",
+	}
+	nameAndScope := strings.SplitN(str, ":", 2)
+	role.Name = authx.RoleName(strings.ToUpper(nameAndScope[0]))
+	if len(nameAndScope) == 2 {
+		role.Scope = nameAndScope[1]
+	}
+	if strings.HasPrefix(string(role.Name), "PROJECT_") &&
+		role.Name != "PROJECT_CREATOR" {
+		role.Type = "PROJECT"
+	}
+	return role
+}
+
+// formatRoles returns a comma-delimited, human-readable list of the provided
+// roles.
+func formatRoles(roles []authx.Role) string {
+	strs := make([]string, len(roles))
+	for i, role := range roles {
+		strs[i] = string(role.Name)
+		if role.Scope != "" {
+			strs[i] = fmt.Sprintf("%s:%s", role.Name, role.Scope)
+		}
+	}
+	return strings.Join(strs, ",")
+}
+
+func userTokenCreate(c *cli.Context) error {
+	name := c.String(flagName)
+	expires, err := parseFutureTimeFlag(c.String(flagExpires))
+	if err != nil {
+		return errors.Wrapf(err, "error parsing --%s", flagExpires)
+	}
+	var roles []authx.Role
+	for _, roleStr := range c.StringSlice(flagRole) {
+		roles = append(roles, parseRoleFlag(roleStr))
+	}
+
+	token := authx.Token{}
+	if err = executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodPost,
+			Path:   "v2/personal-access-tokens",
+			ReqBodyObj: struct {
+				meta.TypeMeta `json:",inline"`
+				Name          string       `json:"name"`
+				Expires       *time.Time   `json:"expires,omitempty"`
+				Roles         []authx.Role `json:"roles,omitempty"`
+			}{
+				TypeMeta: meta.TypeMeta{
+					APIVersion: meta.APIVersion,
+					Kind:       "PersonalAccessToken",
+				},
+				Name:    name,
+				Expires: expires,
+				Roles:   roles,
+			},
+			SuccessCode: http.StatusCreated,
+			RespObj:     &token,
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nPersonal access token %q created:\n", name)
+	fmt.Printf("\n\t%s\n", token.Value)
+	fmt.Println(
+		"\nStore this token someplace secure NOW. It cannot be retrieved " +
+			"later through any other means.",
+	)
+
+	return nil
+}
+
+func userTokenList(c *cli.Context) error {
+	output := c.String(flagOutput)
+
+	if err := validateOutputFormat(output); err != nil {
+		return err
+	}
+
+	var continueVal string
+	for {
+		tokens := personalAccessTokenList{}
+		queryParams := map[string]string{}
+		if continueVal != "" {
+			queryParams["continue"] = continueVal
+		}
+		if err := executeAPIRequest(
+			c,
+			apiRequest{
+				Method:      http.MethodGet,
+				Path:        "v2/personal-access-tokens",
+				QueryParams: queryParams,
+				RespObj:     &tokens,
+			},
+		); err != nil {
+			return err
+		}
+
+		if len(tokens.Items) == 0 {
+			fmt.Println("No personal access tokens found.")
+			return nil
+		}
+
+		switch strings.ToLower(output) {
+		case "table":
+			table := uitable.New()
+			table.AddRow("ID", "NAME", "AGE", "EXPIRES", "ROLES")
+			for _, token := range tokens.Items {
+				var age string
+				if token.Created != nil {
+					age = duration.ShortHumanDuration(time.Since(*token.Created))
+				}
+				roles := formatRoles(token.Roles)
+				if roles == "" {
+					roles = "<all>"
+				}
+				table.AddRow(
+					token.ID,
+					token.Name,
+					age,
+					formatTokenTime(token.Expires, "never"),
+					roles,
+				)
+			}
+			fmt.Println(table)
+
+		case "yaml":
+			yamlBytes, err := yaml.Marshal(tokens)
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list personal access tokens "+
+						"operation",
+				)
+			}
+			fmt.Println(string(yamlBytes))
+
+		case "json":
+			prettyJSON, err := json.MarshalIndent(tokens, "", "  ")
+			if err != nil {
+				return errors.Wrap(
+					err,
+					"error formatting output from list personal access tokens "+
+						"operation",
+				)
+			}
+			fmt.Println(string(prettyJSON))
+		}
+
+		if tokens.RemainingItemCount < 1 || tokens.Continue == "" {
+			break
+		}
+
+		// Exit after one page of output if this isn't a terminal
+		if !terminal.IsTerminal(int(os.Stdout.Fd())) {
+			break
+		}
+
+		if shouldContinue, err :=
+			shouldContinue(tokens.RemainingItemCount); err != nil {
+			return err
+		} else if !shouldContinue {
+			break
+		}
+
+		continueVal = tokens.Continue
+	}
+
+	return nil
+}
+
+func userTokenRevoke(c *cli.Context) error {
+	id := c.String(flagID)
+
+	if err := executeAPIRequest(
+		c,
+		apiRequest{
+			Method: http.MethodDelete,
+			Path:   fmt.Sprintf("v2/personal-access-tokens/%s", id),
+		},
+	); err != nil {
+		return err
+	}
+
+	fmt.Printf("Personal access token %q revoked.\n", id)
+
+	return nil
+}
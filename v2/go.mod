@@ -9,7 +9,8 @@ require (
 	github.com/Azure/go-amqp v0.12.7
 	github.com/brigadecore/brigade/sdk/v2 v2.0.0-20200923171232-9f56c474d8bf
 	github.com/coreos/go-oidc v2.2.1+incompatible
-	github.com/fatih/color v1.9.0 // indirect
+	github.com/evanphx/json-patch v4.2.0+incompatible
+	github.com/fatih/color v1.9.0
 	github.com/ghodss/yaml v1.0.0
 	github.com/gorilla/mux v1.7.4
 	github.com/gosuri/uitable v0.0.4
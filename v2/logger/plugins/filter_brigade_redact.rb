@@ -0,0 +1,123 @@
+require "base64"
+require "fluent/plugin/filter"
+require "kubeclient"
+
+module Fluent
+  module Plugin
+    # BrigadeRedactFilter masks the values of a Brigade Project's Secrets
+    # wherever they occur in the logs of that Project's Workers and Jobs so that
+    # they are never written to the logs store. Secret values are read from the
+    # "project-secrets" Kubernetes Secret in the namespace the log originated
+    # from and are cached for a short while. Lines of multi-line values are
+    # matched individually and, just as when the API server redacts logs it
+    # serves, short or punctuation-only lines are never treated as sensitive.
+    class BrigadeRedactFilter < Filter
+      Fluent::Plugin.register_filter("brigade_redact", self)
+
+      # This must agree with redaction.MinSensitiveLineLength in the API server
+      MIN_LINE_LENGTH = 4
+
+      desc "The string that replaces each occurrence of a secret value"
+      config_param :mask, :string, default: "***"
+      desc "How long a Project's secret values are cached"
+      config_param :cache_ttl, :time, default: 30
+      desc "The URL of the Kubernetes API server; defaults to in-cluster config"
+      config_param :kubernetes_url, :string, default: nil
+      desc "The CA certificate used to verify the Kubernetes API server"
+      config_param :ca_file, :string,
+                   default: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+      desc "The token used to authenticate to the Kubernetes API server"
+      config_param :bearer_token_file, :string,
+                   default: "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+      def configure(conf)
+        super
+        @kubernetes_url ||= "https://#{ENV["KUBERNETES_SERVICE_HOST"]}:" \
+          "#{ENV["KUBERNETES_SERVICE_PORT"]}/api"
+        @cache = {}
+        @cache_lock = Mutex.new
+      end
+
+      def start
+        super
+        @client = Kubeclient::Client.new(
+          @kubernetes_url,
+          "v1",
+          ssl_options: {
+            ca_file: @ca_file,
+            verify_ssl: OpenSSL::SSL::VERIFY_PEER,
+          },
+          auth_options: {
+            bearer_token_file: @bearer_token_file,
+          },
+        )
+      end
+
+      def filter(_tag, _time, record)
+        namespace = record.dig("kubernetes", "namespace_name")
+        message = record["log"]
+        return record if namespace.nil? || !message.is_a?(String)
+        pattern = pattern_for(namespace)
+        if pattern == :unknown
+          # The Project's secret values couldn't be retrieved, so there's no
+          # telling what this line may contain. Withholding it is the only way
+          # to be certain no secret is stored.
+          record["log"] = @mask
+        elsif !pattern.nil?
+          record["log"] = message.scrub.gsub(pattern, @mask)
+        end
+        record
+      end
+
+      private
+
+      # pattern_for returns a Regexp matching any run of secret values of the
+      # Project hosted in the specified namespace, nil if that Project has no
+      # secret values, or :unknown if its secret values can't be retrieved and
+      # none were previously cached.
+      def pattern_for(namespace)
+        @cache_lock.synchronize do
+          entry = @cache[namespace]
+          return entry[:pattern] if !entry.nil? && Time.now < entry[:expires]
+          begin
+            pattern = load_pattern(namespace)
+          rescue StandardError => e
+            log.warn "error retrieving secret values for namespace " \
+              "#{namespace}; #{e.message}"
+            return entry.nil? ? :unknown : entry[:pattern]
+          end
+          @cache[namespace] = {
+            pattern: pattern,
+            expires: Time.now + @cache_ttl,
+          }
+          pattern
+        end
+      end
+
+      def load_pattern(namespace)
+        begin
+          secret = @client.get_secret("project-secrets", namespace)
+        rescue Kubeclient::ResourceNotFoundError
+          # Not a Project namespace, or the Project is being deleted
+          return nil
+        end
+        lines = (secret.data || {}).to_h.values.flat_map do |value|
+          Base64.decode64(value).force_encoding("UTF-8").scrub.split("\n")
+        end
+        lines = lines.map(&:strip).select { |line| sensitive?(line) }.uniq
+        return nil if lines.empty?
+        # Runs of adjacent or overlapping values are replaced by a single mask,
+        # and trying longer values first ensures they're masked in their
+        # entirety
+        values = Regexp.union(lines.sort_by { |line| -line.length })
+        Regexp.new("(?:#{values.source})+")
+      end
+
+      def sensitive?(line)
+        line.length >= MIN_LINE_LENGTH &&
+          line.match?(/[[:alnum:]]/) &&
+          !(line.start_with?("-----") && line.end_with?("-----"))
+      end
+    end
+  end
+end